@@ -1,23 +1,108 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/vpramatarov/pdf-tools/internal/config"
 	"github.com/vpramatarov/pdf-tools/internal/pdf"
 )
 
 func main() {
-	levelFlag := flag.String("level", "ebook", "Compression level: extreme, screen, ebook, printer")
+	levelFlag := flag.String("level", "ebook", "Compression level: extreme, screen, ebook, printer, lossless")
 	outDirFlag := flag.String("out", "uploads", "Output directory for compressed files")
-	modeFlag := flag.String("mode", "compress", "Mode: 'compress' or 'word'")
+	modeFlag := flag.String("mode", "compress", "Mode: compress, word, markdown, html, text, ocr, merge, split, pages, images, images-to-pdf, to-pdf, watermark, stamp, encrypt, decrypt, sign, verify or meta")
 	sortMode := flag.Bool("sort", true, "Enable smart sorting for columns (default true)")
+
+	// Advanced compression options, applied on top of the -level preset
+	colorDPI := flag.Int("color-dpi", 0, "Color image resolution (overrides level)")
+	grayDPI := flag.Int("gray-dpi", 0, "Grayscale image resolution (overrides level)")
+	monoDPI := flag.Int("mono-dpi", 0, "Monochrome image resolution (overrides level)")
+	jpegQuality := flag.Int("jpeg-quality", 0, "Re-encode images as JPEG with this quality 1-100, 0 keeps them as they are (overrides level)")
+	downsample := flag.String("downsample", "", "Downsample filter: bicubic, average, subsample")
+	keepBookmarks := flag.Bool("keep-bookmarks", false, "Keep bookmarks (overrides level)")
+	keepAnnotations := flag.Bool("keep-annotations", false, "Keep annotations instead of flattening them")
+	keepForms := flag.Bool("keep-forms", false, "Keep interactive form fields instead of flattening them")
+	colorStrategy := flag.String("color-strategy", "", "Color conversion: unchanged, rgb, gray, cmyk")
+	pdfVersion := flag.String("pdf-version", "", "Output PDF compatibility level, e.g. 1.4")
+	targetFlag := flag.String("target", "", "Search for the best quality under this size, e.g. 2MB")
+	extractorFlag := flag.String("extractor", "", "Text extractor for conversion modes: pdftotext or pymupdf (default: TEXT_EXTRACTOR or first available)")
+	filtersFlag := flag.String("filters", "", "Text filters for conversion modes: profile name, path to a .json rules file or 'none' (default: TEXT_FILTERS)")
+	keepImages := flag.Bool("keep-images", false, "Keep images when converting, placed where they appear in the text")
+	tables := flag.Bool("tables", false, "Detect tables when converting and write them as real tables")
+	ocrFlag := flag.Bool("ocr", false, "OCR pages without a text layer with tesseract when converting")
+	ocrLang := flag.String("ocr-lang", "", "Tesseract language(s) for -ocr and ocr mode, e.g. bul+eng (default: OCR_LANGUAGE)")
+	deskew := flag.Bool("deskew", false, "Straighten scanned pages before OCR (ocr mode)")
+	forceOCR := flag.Bool("force-ocr", false, "OCR pages that already have a text layer too (ocr mode)")
+	compressAfter := flag.Bool("compress", false, "Compress the result with -level afterwards (ocr, images-to-pdf and to-pdf modes)")
+	outputFlag := flag.String("o", "", "Output file (merge, pages, images-to-pdf, watermark, stamp, encrypt, decrypt, sign and meta modes), default <out>/merged.pdf, <out>/<name>_edited.pdf, <out>/images.pdf or <out>/<name>_<mode>ed.pdf")
+	outlineFlag := flag.String("outline", "files", "Bookmarks of the merged PDF: files, keep or none (merge mode)")
+	blankPages := flag.Bool("blank-pages", false, "Insert a blank page between merged documents (merge mode)")
+	splitBy := flag.String("split-by", "ranges", "Where to cut (split mode): ranges, every or bookmarks")
+	rangesFlag := flag.String("ranges", "", "Page ranges, one file each, e.g. 1-3,5,8- (split mode)")
+	everyFlag := flag.Int("every", 0, "Pages per file with -split-by every (split mode)")
+	opsFlag := flag.String("ops", "", "Page operations (pages mode), e.g. 'rotate 2,4 by 90; delete 7-9; move 10 to 1; extract 3-5'")
+	formatFlag := flag.String("format", "png", "Image format (images mode): png, jpeg or webp")
+	dpiFlag := flag.Int("dpi", pdf.DefaultRenderDPI, "Image resolution (images mode)")
+	pagesFlag := flag.String("pages", "", "Pages to render or stamp, e.g. 1-3,5 (images, watermark and stamp modes, default all)")
+	maxWidth := flag.Int("max-width", 0, "Maximum image width in pixels (images mode)")
+	maxHeight := flag.Int("max-height", 0, "Maximum image height in pixels (images mode)")
+	qualityFlag := flag.Int("quality", 0, "JPEG/WebP quality 1-100 (images mode, default 85)")
+	pageSizeFlag := flag.String("page-size", "a4", "Page size (images-to-pdf mode): a4, letter or fit")
+	orientationFlag := flag.String("orientation", "auto", "Page orientation (images-to-pdf mode): auto, portrait or landscape")
+	marginFlag := flag.Float64("margin", 0, "Margin around each image in mm (images-to-pdf mode), distance of the watermark or signature box from the page edges (watermark and sign modes, default 10 and 15)")
+	autoRotate := flag.Bool("auto-rotate", true, "Turn images upright from their EXIF orientation (images-to-pdf mode)")
+	textFlag := flag.String("text", "", "Watermark text, e.g. CONFIDENTIAL (watermark mode)")
+	imageFlag := flag.String("image", "", "Watermark image, PNG, GIF or JPEG, instead of -text (watermark mode)")
+	fontFlag := flag.String("font", "helvetica", "Text font: "+strings.Join(pdf.FontNames, ", ")+" (watermark and stamp modes)")
+	fontSize := flag.Float64("font-size", 0, "Font size in points (watermark and stamp modes, default 48 and 10)")
+	colorFlag := flag.String("color", "", "Text color, #rrggbb (watermark and stamp modes, default #808080 and #000000)")
+	opacityFlag := flag.Float64("opacity", 0.3, "Watermark opacity 0-1 (watermark mode)")
+	rotationFlag := flag.Float64("rotation", 45, "Watermark rotation in degrees, counter-clockwise (watermark mode)")
+	scaleFlag := flag.Float64("scale", 0.3, "Watermark image width as a fraction of the page width (watermark mode)")
+	positionFlag := flag.String("position", "", "Position: center, top, bottom, left, right, top-left, top-right, bottom-left, bottom-right (watermark, stamp and sign modes, default center, bottom and bottom-right)")
+	underlay := flag.Bool("underlay", false, "Put the watermark behind the page content (watermark mode)")
+	templateFlag := flag.String("template", pdf.DefaultStampTemplate, "Stamp text with {page}, {total}, {filename}, {date} and {bates} (stamp mode)")
+	batesPrefix := flag.String("bates-prefix", "", "Text before the Bates counter, e.g. ACME (stamp mode)")
+	batesDigits := flag.Int("bates-digits", 6, "Digits of the Bates counter, zero-padded (stamp mode)")
+	batesStart := flag.Int("bates-start", 1, "First Bates number (stamp mode)")
+	dateFlag := flag.String("date", "", "Date for {date}, YYYY-MM-DD (stamp mode, default today)")
+	marginX := flag.Float64("margin-x", 15, "Distance of the stamp from the left and right edges in mm (stamp mode)")
+	marginY := flag.Float64("margin-y", 10, "Distance of the stamp from the top and bottom edges in mm (stamp mode)")
+	userPassword := flag.String("user-password", "", "Password to open the file (encrypt mode)")
+	ownerPassword := flag.String("owner-password", "", "Password to change the permissions (encrypt mode, default random)")
+	passwordFlag := flag.String("password", "", "Password of encrypted input (decrypt, compress and conversion modes)")
+	allowPrint := flag.Bool("allow-print", false, "Allow printing (encrypt mode)")
+	allowCopy := flag.Bool("allow-copy", false, "Allow copying text and images (encrypt mode)")
+	allowModify := flag.Bool("allow-modify", false, "Allow editing (encrypt mode)")
+	allowAnnotate := flag.Bool("allow-annotate", false, "Allow comments and form filling (encrypt mode)")
+	certFlag := flag.String("cert", "", "Signing certificate: .p12/.pfx or PEM file (sign mode, default: SIGN_CERT)")
+	keyFlag := flag.String("key", "", "PEM private key when -cert is a PEM certificate (sign mode, default: SIGN_KEY or -cert)")
+	certPassword := flag.String("cert-password", "", "Password of the .p12/.pfx file (sign mode, default: SIGN_PASSWORD)")
+	reasonFlag := flag.String("reason", "", "Reason for signing, e.g. Approved (sign mode)")
+	locationFlag := flag.String("location", "", "Where the document was signed (sign mode)")
+	contactFlag := flag.String("contact", "", "Signer's contact information (sign mode)")
+	visibleSig := flag.Bool("visible", false, "Draw a box with the signer's name and the date (sign mode)")
+	signPage := flag.Int("sign-page", 0, "Page of the visible signature, 1 is the first (sign mode, default last)")
+	stripMetadata := flag.Bool("strip-metadata", false, "Remove the document metadata, Info and XMP (compress and meta modes)")
+	keepMetadata := flag.String("keep-metadata", "", "Metadata fields copied from the input to the compressed file, e.g. title,author")
+	metaFlags := map[string]*string{}
+	for _, name := range pdf.MetadataFields {
+		metaFlags[name] = flag.String(name, "", "Set the document's "+name+", empty removes it (meta mode)")
+	}
+	forceFlag := flag.Bool("force", false, "Compress or edit the metadata of signed PDFs too, which invalidates their signatures (default: leave them unchanged)")
+	pipelineFlag := flag.String("pipeline", "", "Compression backends in order, e.g. gs,qpdf or qpdf (default: COMPRESS_PIPELINE)")
 	flag.Parse()
 	files := flag.Args()
 
@@ -27,12 +112,403 @@ func main() {
 		os.Exit(1)
 	}
 
+	level, err := pdf.ParseLevel(*levelFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := level.Options()
+	opts.Force = *forceFlag
+
+	var optErr error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "color-dpi":
+			opts.ColorDPI = *colorDPI
+		case "gray-dpi":
+			opts.GrayDPI = *grayDPI
+		case "mono-dpi":
+			opts.MonoDPI = *monoDPI
+		case "jpeg-quality":
+			opts.JPEGQuality = *jpegQuality
+		case "downsample":
+			opts.Downsample, optErr = pdf.ParseDownsampleType(*downsample)
+		case "keep-bookmarks":
+			opts.KeepBookmarks = *keepBookmarks
+		case "keep-annotations":
+			opts.KeepAnnotations = *keepAnnotations
+		case "keep-forms":
+			opts.KeepForms = *keepForms
+		case "color-strategy":
+			opts.ColorStrategy, optErr = pdf.ParseColorStrategy(*colorStrategy)
+		case "pdf-version":
+			opts.CompatibilityLevel = *pdfVersion
+		case "strip-metadata":
+			opts.StripMetadata = *stripMetadata
+		case "keep-metadata":
+			for _, name := range strings.Split(*keepMetadata, ",") {
+				if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+					opts.KeepMetadata = append(opts.KeepMetadata, name)
+				}
+			}
+		}
+	})
+	if optErr == nil {
+		optErr = opts.Validate()
+	}
+	if optErr != nil {
+		log.Fatalf("Invalid compression options: %v", optErr)
+	}
+
+	var targetSize int64
+	if *targetFlag != "" {
+		if targetSize, err = pdf.ParseSize(*targetFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	if err := os.MkdirAll(*outDirFlag, 0755); err != nil {
 		log.Fatalf("Failed to create output directory: %v", err)
 	}
 
+	// Ctrl+C stops every running gs/qpdf/python3 process and removes partial outputs
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if *modeFlag == "merge" {
+		output := *outputFlag
+		if output == "" {
+			output = filepath.Join(*outDirFlag, "merged.pdf")
+		}
+		if err := mergeFiles(ctx, files, output, *outlineFlag, *blankPages); err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("\n🛑 Interrupted, unfinished files were removed.")
+				os.Exit(130)
+			}
+			log.Fatalf("❌ Merge failed: %v", err)
+		}
+		return
+	}
+
+	if *modeFlag == "stamp" {
+		if *outputFlag != "" && len(files) > 1 {
+			log.Fatal("-o takes a single input file in stamp mode")
+		}
+		stamper := pdf.NewStamper()
+		stamper.Template = *templateFlag
+		stamper.Font = *fontFlag
+		if *fontSize != 0 {
+			stamper.FontSize = *fontSize
+		}
+		if *colorFlag != "" {
+			stamper.Color = *colorFlag
+		}
+		stamper.MarginX = *marginX
+		stamper.MarginY = *marginY
+		stamper.Pages = *pagesFlag
+		stamper.BatesPrefix = *batesPrefix
+		stamper.BatesDigits = *batesDigits
+		stamper.BatesStart = *batesStart
+		if stamper.Position, err = pdf.ParsePosition(*positionFlag, pdf.PositionBottom); err != nil {
+			log.Fatal(err)
+		}
+		if *dateFlag != "" {
+			if stamper.Date, err = time.Parse("2006-01-02", *dateFlag); err != nil {
+				log.Fatalf("Invalid -date %q, use YYYY-MM-DD", *dateFlag)
+			}
+		}
+
+		if err := stampFiles(ctx, stamper, files, *outDirFlag, *outputFlag); err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("\n🛑 Interrupted, unfinished files were removed.")
+				os.Exit(130)
+			}
+			log.Fatalf("❌ Stamping failed: %v", err)
+		}
+		return
+	}
+
+	if *modeFlag == "encrypt" || *modeFlag == "decrypt" {
+		if *outputFlag != "" && len(files) > 1 {
+			log.Fatalf("-o takes a single input file in %s mode", *modeFlag)
+		}
+		var encrypt *pdf.EncryptOptions
+		if *modeFlag == "encrypt" {
+			encrypt = &pdf.EncryptOptions{
+				UserPassword:  *userPassword,
+				OwnerPassword: *ownerPassword,
+				Permissions: pdf.Permissions{
+					Print:    *allowPrint,
+					Copy:     *allowCopy,
+					Modify:   *allowModify,
+					Annotate: *allowAnnotate,
+				},
+			}
+			if encrypt.UserPassword == "" && encrypt.OwnerPassword == "" {
+				log.Fatal("encrypt mode needs -user-password or -owner-password")
+			}
+		}
+
+		if err := secureFiles(ctx, encrypt, *passwordFlag, files, *outDirFlag, *outputFlag); err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("\n🛑 Interrupted, unfinished files were removed.")
+				os.Exit(130)
+			}
+			log.Fatalf("❌ %s failed: %v", *modeFlag, err)
+		}
+		return
+	}
+
+	if *modeFlag == "sign" {
+		if *outputFlag != "" && len(files) > 1 {
+			log.Fatal("-o takes a single input file in sign mode")
+		}
+		cfg := config.Load()
+		certPath, keyPath, password := *certFlag, *keyFlag, *certPassword
+		if certPath == "" {
+			certPath, keyPath = cfg.SignCert, cfg.SignKey
+		}
+		if password == "" {
+			password = cfg.SignPassword
+		}
+		if certPath == "" {
+			log.Fatal("sign mode needs -cert or SIGN_CERT")
+		}
+		signer, err := pdf.LoadSigner(certPath, keyPath, password)
+		if err != nil {
+			log.Fatal(err)
+		}
+		signer.Reason = *reasonFlag
+		signer.Location = *locationFlag
+		signer.ContactInfo = *contactFlag
+		signer.Visible = *visibleSig
+		signer.Page = *signPage
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "margin" {
+				signer.Margin = *marginFlag
+			}
+		})
+		if signer.Position, err = pdf.ParsePosition(*positionFlag, signer.Position); err != nil {
+			log.Fatal(err)
+		}
+
+		if err := signFiles(ctx, signer, files, *outDirFlag, *outputFlag); err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("\n🛑 Interrupted, unfinished files were removed.")
+				os.Exit(130)
+			}
+			log.Fatalf("❌ Signing failed: %v", err)
+		}
+		return
+	}
+
+	if *modeFlag == "meta" {
+		// Only the flags given on the command line change a field
+		fields := map[string]string{}
+		flag.Visit(func(f *flag.Flag) {
+			if value, ok := metaFlags[f.Name]; ok {
+				fields[f.Name] = *value
+			}
+		})
+		if *stripMetadata {
+			for _, name := range pdf.MetadataFields {
+				fields[name] = ""
+			}
+		}
+		if len(fields) == 0 {
+			if !printMetadata(ctx, files) {
+				os.Exit(1)
+			}
+			return
+		}
+
+		if *outputFlag != "" && len(files) > 1 {
+			log.Fatal("-o takes a single input file in meta mode")
+		}
+		if err := metadataFiles(ctx, fields, files, *outDirFlag, *outputFlag, *forceFlag); err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("\n🛑 Interrupted, unfinished files were removed.")
+				os.Exit(130)
+			}
+			log.Fatalf("❌ Updating metadata failed: %v", err)
+		}
+		return
+	}
+
+	if *modeFlag == "verify" {
+		if !verifyFiles(files) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg := config.Load()
+
+	pipelineSpec := *pipelineFlag
+	if pipelineSpec == "" {
+		pipelineSpec = cfg.CompressPipeline
+	}
+	pipeline, err := pdf.ParsePipeline(pipelineSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	compressor := pdf.NewCompressor()
+	compressor.Pipeline = pipeline
+	if *modeFlag == "images-to-pdf" {
+		output := *outputFlag
+		if output == "" {
+			output = filepath.Join(*outDirFlag, "images.pdf")
+		}
+		converter := pdf.NewImagesToPDF()
+		if converter.PageSize, err = pdf.ParsePageSize(*pageSizeFlag); err != nil {
+			log.Fatal(err)
+		}
+		if converter.Orientation, err = pdf.ParseOrientation(*orientationFlag); err != nil {
+			log.Fatal(err)
+		}
+		converter.Margin = *marginFlag
+		converter.AutoRotate = *autoRotate
+
+		fmt.Printf("🖼️  Putting %d images into %s ...\n", len(files), output)
+		res, err := converter.Convert(ctx, files, output)
+		if err != nil {
+			log.Fatalf("❌ Conversion failed: %v", err)
+		}
+		fmt.Printf("✅ %d pages\n", res.PageCount)
+
+		if *compressAfter {
+			report, err := compressInPlace(ctx, compressor, output, opts)
+			if err != nil {
+				log.Fatalf("❌ Error compressing %s: %v", output, err)
+			}
+			printReport(output, report)
+		}
+		fmt.Printf("Done: %s\n", output)
+		return
+	}
+
 	converter := pdf.NewConverter()
+	var convertOpts pdf.ConvertOptions
+	convert, isConvertMode := convertModes[*modeFlag]
+	if !isConvertMode && !slices.Contains([]string{"compress", "ocr", "split", "pages", "images", "to-pdf", "watermark"}, *modeFlag) {
+		log.Fatalf("Unknown mode %q", *modeFlag)
+	}
+	if isConvertMode {
+		extractorName := *extractorFlag
+		if extractorName == "" {
+			extractorName = cfg.TextExtractor
+		}
+		if converter.Extractor, err = pdf.ResolveExtractor(ctx, extractorName, cfg.PythonBin); err != nil {
+			log.Fatalf("PDF conversion is not available: %v", err)
+		}
+
+		filters := *filtersFlag
+		if filters == "" {
+			filters = cfg.TextFilters
+		}
+		if filters != "none" {
+			if convertOpts.Filters, err = pdf.ResolveFilterRules(filters, cfg.FilterProfilesDir); err != nil {
+				log.Fatal(err)
+			}
+		}
+		convertOpts.Sort = *sortMode
+		convertOpts.KeepImages = *keepImages
+		convertOpts.Tables = *tables
+		convertOpts.OCR = *ocrFlag
+		convertOpts.OCRLanguage = *ocrLang
+		if convertOpts.OCRLanguage == "" {
+			convertOpts.OCRLanguage = cfg.OCRLanguage
+		}
+		if convertOpts.OCR {
+			if err := pdf.CheckOCRLanguage(ctx, convertOpts.OCRLanguage); err != nil {
+				log.Fatalf("OCR is not available: %v", err)
+			}
+		}
+	}
+
+	var ocr *pdf.OCR
+	if *modeFlag == "ocr" {
+		lang := *ocrLang
+		if lang == "" {
+			lang = cfg.OCRLanguage
+		}
+		if err := pdf.CheckOCRLanguage(ctx, lang); err != nil {
+			log.Fatalf("OCR is not available: %v", err)
+		}
+		ocr = pdf.NewOCR(lang)
+		ocr.Deskew = *deskew
+		ocr.Force = *forceOCR
+	}
+
+	var splitter *pdf.Splitter
+	if *modeFlag == "split" {
+		splitter = &pdf.Splitter{Ranges: *rangesFlag, Every: *everyFlag}
+		if splitter.Mode, err = pdf.ParseSplitMode(*splitBy); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var pageOps []pdf.PageOp
+	if *modeFlag == "pages" {
+		if pageOps, err = pdf.ParsePageOps(*opsFlag); err != nil {
+			log.Fatal(err)
+		}
+		if len(pageOps) == 0 {
+			log.Fatal("pages mode needs -ops")
+		}
+		if *outputFlag != "" && len(files) > 1 {
+			log.Fatal("-o takes a single input file in pages mode")
+		}
+	}
+
+	var renderer *pdf.Renderer
+	if *modeFlag == "images" {
+		renderer = pdf.NewRenderer()
+		if renderer.Format, err = pdf.ParseImageFormat(*formatFlag); err != nil {
+			log.Fatal(err)
+		}
+		renderer.DPI = *dpiFlag
+		renderer.MaxWidth = *maxWidth
+		renderer.MaxHeight = *maxHeight
+		renderer.Quality = *qualityFlag
+		renderer.Workers = cfg.RenderWorkers
+	}
+
+	var watermarker *pdf.Watermarker
+	if *modeFlag == "watermark" {
+		watermarker = pdf.NewWatermarker()
+		watermarker.Text = *textFlag
+		watermarker.Image = *imageFlag
+		watermarker.Font = *fontFlag
+		if *fontSize != 0 {
+			watermarker.FontSize = *fontSize
+		}
+		if *colorFlag != "" {
+			watermarker.Color = *colorFlag
+		}
+		watermarker.Opacity = *opacityFlag
+		watermarker.Rotation = *rotationFlag
+		watermarker.Scale = *scaleFlag
+		watermarker.Pages = *pagesFlag
+		watermarker.Underlay = *underlay
+		if watermarker.Position, err = pdf.ParsePosition(*positionFlag, pdf.PositionCenter); err != nil {
+			log.Fatal(err)
+		}
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "margin" {
+				watermarker.Margin = *marginFlag
+			}
+		})
+		if *outputFlag != "" && len(files) > 1 {
+			log.Fatal("-o takes a single input file in watermark mode")
+		}
+	}
+
+	var office *pdf.OfficeConverter
+	if *modeFlag == "to-pdf" {
+		office = pdf.NewOfficeConverter(cfg.OfficeMaxJobs)
+		office.Timeout = time.Duration(cfg.OfficeTimeoutSeconds) * time.Second
+	}
 
 	absOutDir, _ := filepath.Abs(*outDirFlag)
 	fmt.Printf("📂 Saving files to: %s\n", absOutDir)
@@ -45,16 +521,168 @@ func main() {
 		go func(input string) {
 			defer wg.Done()
 
-			// --- Convert to WORD ---
-			if *modeFlag == "word" {
-				fmt.Printf("📝 Converting to Word: %s ...\n", filepath.Base(input))
+			// Signatures are counted on the file as given: decryption and OCR
+			// rewrite it, and the rewrite no longer carries valid ones
+			fileOpts := opts
+			signatures := 0
+			if *modeFlag == "compress" || (ocr != nil && *compressAfter) {
+				n, err := pdf.CountSignatures(ctx, input)
+				if err != nil {
+					log.Printf("❌ Cannot open %s: %v", input, err)
+					return
+				}
+				signatures = n
+			}
+
+			// Ghostscript and the text extractors cannot read encrypted files;
+			// work on a decrypted copy and leave the input alone
+			if isConvertMode || *modeFlag == "compress" {
+				decrypted, cleanup, err := decryptedInput(ctx, input, *passwordFlag)
+				if err != nil {
+					log.Printf("❌ Cannot open %s: %v", input, err)
+					return
+				}
+				defer cleanup()
+				if decrypted != input {
+					fileOpts.SourceSignatures = signatures
+				}
+				input = decrypted
+			}
+
+			// --- Convert to WORD, Markdown, HTML or text ---
+			if isConvertMode {
+				fmt.Printf("📝 Converting to %s: %s ...\n", *modeFlag, filepath.Base(input))
 
-				resPath, err := converter.ToWord(input, *outDirFlag, *sortMode)
+				res, err := convert(converter, ctx, input, *outDirFlag, convertOpts)
 				if err != nil {
 					log.Printf("❌ Conversion failed for %s: %v", input, err)
 					return
 				}
-				fmt.Printf("✅ Converted: %s\n", filepath.Base(resPath))
+				for _, warning := range res.Warnings {
+					fmt.Printf("⚠️  %s: %s\n", filepath.Base(input), warning)
+				}
+				if len(res.OCRPages) > 0 {
+					fmt.Printf("🔎 %s: OCR on pages %v\n", filepath.Base(input), res.OCRPages)
+				}
+				fmt.Printf("✅ Converted: %s\n", filepath.Base(res.Path))
+				return
+			}
+
+			// --- Searchable PDF ---
+			if ocr != nil {
+				baseName := filepath.Base(input)
+				outputFile := filepath.Join(*outDirFlag, strings.TrimSuffix(baseName, filepath.Ext(baseName))+"_ocr.pdf")
+				fmt.Printf("🔎 OCR: %s ...\n", baseName)
+
+				res, err := ocr.Run(ctx, input, outputFile)
+				if err != nil {
+					log.Printf("❌ OCR failed for %s: %v", input, err)
+					return
+				}
+				fmt.Printf("✅ %s: text layer on %d of %d pages (%v)\n",
+					baseName, len(res.OCRPages), res.PageCount, res.Duration.Round(time.Millisecond))
+
+				if *compressAfter {
+					// Without new text the input was copied as it is and its
+					// signatures are still valid
+					if len(res.OCRPages) > 0 {
+						fileOpts.SourceSignatures = signatures
+					}
+					report, err := compressInPlace(ctx, compressor, outputFile, fileOpts)
+					if err != nil {
+						log.Printf("❌ Error compressing %s: %v", outputFile, err)
+						return
+					}
+					printReport(input, report)
+				}
+				fmt.Printf("Done: %s\n", outputFile)
+				return
+			}
+
+			// --- Split ---
+			if splitter != nil {
+				fmt.Printf("✂️  Splitting %s ...\n", filepath.Base(input))
+
+				parts, err := splitter.Split(ctx, input, *outDirFlag)
+				if err != nil {
+					log.Printf("❌ Split failed for %s: %v", input, err)
+					return
+				}
+				for _, part := range parts {
+					fmt.Printf("   %-8s %s\n", part.PageRange, filepath.Base(part.Path))
+				}
+				fmt.Printf("✅ %s: %d files\n", filepath.Base(input), len(parts))
+				return
+			}
+
+			// --- Page editing ---
+			if pageOps != nil {
+				baseName := filepath.Base(input)
+				outputFile := *outputFlag
+				if outputFile == "" {
+					outputFile = filepath.Join(*outDirFlag, strings.TrimSuffix(baseName, filepath.Ext(baseName))+"_edited.pdf")
+				}
+
+				res, err := pdf.EditPages(ctx, input, outputFile, pageOps)
+				if err != nil {
+					log.Printf("❌ Editing pages of %s failed: %v", input, err)
+					return
+				}
+				fmt.Printf("✅ %s: %d pages -> %s\n", baseName, len(res.Pages), outputFile)
+				return
+			}
+
+			// --- Images ---
+			if renderer != nil {
+				baseName := filepath.Base(input)
+				fmt.Printf("🖼️  Rendering %s ...\n", baseName)
+
+				images, err := renderer.Render(ctx, input, *outDirFlag, *pagesFlag)
+				if err != nil {
+					log.Printf("❌ Rendering failed for %s: %v", input, err)
+					return
+				}
+				fmt.Printf("✅ %s: %d images\n", baseName, len(images))
+				return
+			}
+
+			// --- Watermark ---
+			if watermarker != nil {
+				baseName := filepath.Base(input)
+				outputFile := *outputFlag
+				if outputFile == "" {
+					outputFile = filepath.Join(*outDirFlag, strings.TrimSuffix(baseName, filepath.Ext(baseName))+"_watermarked.pdf")
+				}
+
+				res, err := watermarker.Apply(ctx, input, outputFile)
+				if err != nil {
+					log.Printf("❌ Watermarking %s failed: %v", input, err)
+					return
+				}
+				fmt.Printf("✅ %s: %d pages stamped -> %s\n", baseName, len(res.Pages), outputFile)
+				return
+			}
+
+			// --- Office document to PDF ---
+			if office != nil {
+				baseName := filepath.Base(input)
+				fmt.Printf("📄 Converting %s to PDF ...\n", baseName)
+
+				outputFile, err := office.Convert(ctx, input, *outDirFlag)
+				if err != nil {
+					log.Printf("❌ Conversion failed for %s: %v", input, err)
+					return
+				}
+
+				if *compressAfter {
+					report, err := compressInPlace(ctx, compressor, outputFile, fileOpts)
+					if err != nil {
+						log.Printf("❌ Error compressing %s: %v", outputFile, err)
+						return
+					}
+					printReport(outputFile, report)
+				}
+				fmt.Printf("Done: %s\n", outputFile)
 				return
 			}
 
@@ -64,63 +692,296 @@ func main() {
 			newName := strings.TrimSuffix(baseName, ext) + "_compressed" + ext
 			outputFile := filepath.Join(*outDirFlag, newName)
 
-			var level pdf.CompressionLevel
-			switch *levelFlag {
-			case "extreme":
-				level = pdf.LevelScreen
-			case "screen":
-				level = pdf.LevelScreen
-			case "printer":
-				level = pdf.LevelPrinter
-			default:
-				level = pdf.LevelEbook
+			fmt.Printf("⏳ Compressing %s ...\n", baseName)
+			if targetSize > 0 {
+				res, err := compressor.CompressToSize(ctx, input, outputFile, targetSize, fileOpts)
+				if err != nil {
+					log.Printf("❌ Error compressing %s: %v", input, err)
+					return
+				}
+				for _, warning := range res.Report.Warnings {
+					fmt.Printf("⚠️  %s: %s\n", baseName, warning)
+				}
+				switch {
+				case !res.KeptOriginal:
+					fmt.Printf("🎯 %s: %d DPI, JPEG quality %d -> %s (%d attempts)\n",
+						baseName, res.Options.ColorDPI, res.Options.JPEGQuality, formatSize(res.Size), res.Attempts)
+				case res.Size <= targetSize:
+					fmt.Printf("🎯 %s: already under %s, copied unchanged\n", baseName, formatSize(targetSize))
+				}
+				fmt.Printf("Done: %s\n", outputFile)
+				return
 			}
 
-			fmt.Printf("⏳ Compressing %s ...\n", baseName)
-			err := compressor.Compress(inputFile, outputFile, level)
+			report, err := compressor.CompressWith(ctx, input, outputFile, fileOpts)
 			if err != nil {
-				log.Printf("❌ Error compressing %s: %v", inputFile, err)
+				log.Printf("❌ Error compressing %s: %v", input, err)
 				return
 			}
-			checkSizeAndReport(inputFile, outputFile)
+			printReport(input, report)
 			fmt.Printf("Done: %s\n", outputFile)
 
 		}(inputFile)
 	}
 
 	wg.Wait()
+	if ctx.Err() != nil {
+		fmt.Println("\n🛑 Interrupted, unfinished files were removed.")
+		os.Exit(130)
+	}
 	fmt.Printf("\n✨ All done in %v\n", time.Since(startTime))
 }
 
-func checkSizeAndReport(input, output string) {
-	inInfo, err1 := os.Stat(input)
-	outInfo, err2 := os.Stat(output)
+// convertModes maps the -mode values that convert PDFs to their converter.
+var convertModes = map[string]func(*pdf.Converter, context.Context, string, string, pdf.ConvertOptions) (*pdf.ConvertResult, error){
+	"word":     (*pdf.Converter).ToWordWith,
+	"markdown": (*pdf.Converter).ToMarkdown,
+	"html":     (*pdf.Converter).ToHTML,
+	"text":     (*pdf.Converter).ToText,
+}
 
-	if err1 != nil || err2 != nil {
-		return
+// mergeFiles merges files, in command line order, into output.
+func mergeFiles(ctx context.Context, files []string, output string, outline string, blankPages bool) error {
+	merger := pdf.NewMerger()
+	mode, err := pdf.ParseOutlineMode(outline)
+	if err != nil {
+		return err
+	}
+	merger.Outline = mode
+	merger.BlankPages = blankPages
+
+	inputs := make([]pdf.MergeInput, len(files))
+	for i, file := range files {
+		inputs[i] = pdf.MergeInput{Path: file}
+	}
+
+	fmt.Printf("📎 Merging %d files ...\n", len(files))
+	res, err := merger.Merge(ctx, inputs, output)
+	if err != nil {
+		return err
+	}
+	for _, file := range res.Files {
+		fmt.Printf("   %-30s pages %d-%d\n", file.Title, file.FirstPage, file.FirstPage+file.Pages-1)
+	}
+	fmt.Printf("✅ Merged %d pages: %s\n", res.PageCount, output)
+	return nil
+}
+
+// stampFiles stamps files one after the other, in command line order, so
+// the Bates numbers run on from one file to the next.
+func stampFiles(ctx context.Context, stamper *pdf.Stamper, files []string, outDir string, output string) error {
+	for _, input := range files {
+		baseName := filepath.Base(input)
+		outputFile := output
+		if outputFile == "" {
+			outputFile = filepath.Join(outDir, strings.TrimSuffix(baseName, filepath.Ext(baseName))+"_stamped.pdf")
+		}
+
+		res, err := stamper.Stamp(ctx, input, outputFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", baseName, err)
+		}
+		if res.FirstBates != "" {
+			fmt.Printf("✅ %s: %s - %s -> %s\n", baseName, res.FirstBates, res.LastBates, outputFile)
+		} else {
+			fmt.Printf("✅ %s: %d pages stamped -> %s\n", baseName, len(res.Pages), outputFile)
+		}
+	}
+	return nil
+}
+
+// secureFiles encrypts files with encrypt or, when it is nil, decrypts them
+// with password.
+func secureFiles(ctx context.Context, encrypt *pdf.EncryptOptions, password string, files []string, outDir string, output string) error {
+	suffix := "_decrypted.pdf"
+	if encrypt != nil {
+		suffix = "_encrypted.pdf"
+	}
+	for _, input := range files {
+		baseName := filepath.Base(input)
+		outputFile := output
+		if outputFile == "" {
+			outputFile = filepath.Join(outDir, strings.TrimSuffix(baseName, filepath.Ext(baseName))+suffix)
+		}
+
+		var err error
+		if encrypt != nil {
+			err = pdf.Encrypt(ctx, input, outputFile, *encrypt)
+		} else {
+			err = pdf.Decrypt(ctx, input, outputFile, password)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", baseName, err)
+		}
+		fmt.Printf("✅ %s -> %s\n", baseName, outputFile)
+	}
+	return nil
+}
+
+// signFiles signs files one after the other.
+func signFiles(ctx context.Context, signer *pdf.Signer, files []string, outDir string, output string) error {
+	for _, input := range files {
+		baseName := filepath.Base(input)
+		outputFile := output
+		if outputFile == "" {
+			outputFile = filepath.Join(outDir, strings.TrimSuffix(baseName, filepath.Ext(baseName))+"_signed.pdf")
+		}
+
+		res, err := signer.Sign(ctx, input, outputFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", baseName, err)
+		}
+		fmt.Printf("✅ %s: signed by %s (%s) -> %s\n", baseName, res.Signer, res.Field, outputFile)
+	}
+	return nil
+}
+
+// verifyFiles prints the signatures of each file and reports whether all
+// of them are valid.
+func verifyFiles(files []string) bool {
+	ok := true
+	for _, input := range files {
+		baseName := filepath.Base(input)
+		sigs, err := pdf.Verify(input)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", baseName, err)
+			ok = false
+			continue
+		}
+		if len(sigs) == 0 {
+			fmt.Printf("➖ %s: no signatures\n", baseName)
+			continue
+		}
+
+		fmt.Printf("📄 %s: %d signature(s)\n", baseName, len(sigs))
+		for i, sig := range sigs {
+			status := "✅ valid"
+			switch {
+			case !sig.Valid:
+				status = "❌ INVALID"
+				ok = false
+			case !sig.Trusted:
+				status = "⚠️  valid, untrusted certificate"
+			}
+			fmt.Printf("   %d. %s: %s\n", i+1, sig.Signer, status)
+			if !sig.SignedAt.IsZero() {
+				fmt.Printf("      Signed:   %s\n", sig.SignedAt.Format("2006-01-02 15:04:05 -07:00"))
+			}
+			if sig.Reason != "" {
+				fmt.Printf("      Reason:   %s\n", sig.Reason)
+			}
+			if sig.Valid && !sig.WholeDocument {
+				fmt.Println("      Note:     the file was changed after this signature")
+			}
+			if sig.Problem != "" {
+				fmt.Printf("      Problem:  %s\n", sig.Problem)
+			}
+		}
+	}
+	return ok
+}
+
+// printMetadata prints the metadata of each file and reports whether all
+// of them could be read.
+func printMetadata(ctx context.Context, files []string) bool {
+	ok := true
+	for _, input := range files {
+		baseName := filepath.Base(input)
+		meta, err := pdf.ReadMetadata(ctx, input)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", baseName, err)
+			ok = false
+			continue
+		}
+
+		fmt.Printf("📄 %s\n", baseName)
+		for _, name := range pdf.MetadataFields {
+			if value := meta.Field(name); value != "" {
+				fmt.Printf("   %-9s %s\n", name+":", value)
+			}
+		}
+		if meta.XMP != "" {
+			fmt.Println("   XMP packet present")
+		}
+	}
+	return ok
+}
+
+// metadataFiles writes fields into each file. Signed files are refused
+// unless force is set, rewriting them breaks their signatures.
+func metadataFiles(ctx context.Context, fields map[string]string, files []string, outDir string, output string, force bool) error {
+	for _, input := range files {
+		baseName := filepath.Base(input)
+		signatures, err := pdf.CountSignatures(ctx, input)
+		if err != nil {
+			return fmt.Errorf("%s: %w", baseName, err)
+		}
+		if signatures > 0 && !force {
+			return fmt.Errorf("%s: %w, changing its metadata breaks %d signature(s) (-force accepts that)", baseName, pdf.ErrSigned, signatures)
+		}
+		outputFile := output
+		if outputFile == "" {
+			outputFile = filepath.Join(outDir, strings.TrimSuffix(baseName, filepath.Ext(baseName))+"_meta.pdf")
+		}
+
+		if err := pdf.WriteMetadata(ctx, input, outputFile, fields); err != nil {
+			os.Remove(outputFile)
+			return fmt.Errorf("%s: %w", baseName, err)
+		}
+		fmt.Printf("✅ %s -> %s\n", baseName, outputFile)
+	}
+	return nil
+}
+
+// decryptedInput returns input or, when it is encrypted, a decrypted copy
+// of it in a temporary directory that cleanup removes.
+func decryptedInput(ctx context.Context, input string, password string) (string, func(), error) {
+	noop := func() {}
+	if _, err := exec.LookPath("qpdf"); err != nil && password == "" {
+		return input, noop, nil
+	}
+	encrypted, err := pdf.IsEncrypted(ctx, input)
+	if err != nil || !encrypted {
+		return input, noop, err
 	}
 
-	oldSize := inInfo.Size()
-	newSize := outInfo.Size()
+	dir, err := os.MkdirTemp("", "pdf_decrypt_")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	decrypted := filepath.Join(dir, filepath.Base(input))
+	if err := pdf.Decrypt(ctx, input, decrypted, password); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+	return decrypted, cleanup, nil
+}
+
+// compressInPlace replaces the PDF at path with its compressed version.
+func compressInPlace(ctx context.Context, compressor *pdf.Compressor, path string, opts pdf.CompressOptions) (*pdf.CompressionReport, error) {
+	original := path + ".orig.pdf"
+	if err := os.Rename(path, original); err != nil {
+		return nil, err
+	}
+	defer os.Remove(original)
+	return compressor.CompressWith(ctx, original, path, opts)
+}
 
-	if newSize >= oldSize {
-		fmt.Printf("⚠️  %s: Result was larger/same, reverting to original.\n", filepath.Base(input))
-		inputContent, _ := os.ReadFile(input)
-		os.WriteFile(output, inputContent, 0644)
-		newSize = oldSize
+func printReport(input string, report *pdf.CompressionReport) {
+	for _, warning := range report.Warnings {
+		fmt.Printf("⚠️  %s: %s\n", filepath.Base(input), warning)
 	}
 
-	savedBytes := oldSize - newSize
-	percent := 0.0
-	if oldSize > 0 {
-		percent = (float64(savedBytes) / float64(oldSize)) * 100
+	for _, stage := range report.Stages {
+		fmt.Printf("   %-12s %-8s %10s  %v\n", stage.Name, stage.Status, formatSize(stage.Size), stage.Duration.Round(time.Millisecond))
 	}
 
 	fmt.Printf("✅ %s: Saved %.1f%% (%s -> %s)\n",
 		filepath.Base(input),
-		percent,
-		formatSize(oldSize),
-		formatSize(newSize))
+		report.SavedPercent(),
+		formatSize(report.InputSize),
+		formatSize(report.OutputSize))
 }
 
 func formatSize(bytes int64) string {
@@ -24,10 +24,29 @@ func main() {
 	cfg := config.Load()
 
 	if err := os.MkdirAll(cfg.UploadDir, 0755); err != nil {
-		panic("Failed to create upload directory: " + err.Error())
+		log.Fatalf("Failed to create upload directory: %v", err)
 	}
 
-	h := handlers.New(cfg)
+	h, err := handlers.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, b := range h.Backends {
+		if !b.Available {
+			log.Printf("⚠️ Backend %s (%s) not found on PATH", b.Name, b.Binary)
+		}
+	}
+	if h.ExtractorErr != nil {
+		log.Printf("⚠️ PDF to Word disabled: %v", h.ExtractorErr)
+	} else {
+		log.Printf("📝 Text extractor: %s", h.Extractor.Name())
+	}
+
+	if h.Signer != nil {
+		log.Printf("✍️  Signing as: %s", h.Signer.Certificates[0].Subject.CommonName)
+	} else if h.Cfg.SignCert != "" {
+		log.Printf("⚠️ Signing disabled: %v", h.SignerErr)
+	}
 
 	h.StartCleanupCron()
 
@@ -41,6 +60,7 @@ func main() {
 
 	log.Printf("Server starting on %v:%d ...", host, cfg.Port)
 	log.Printf("📂 Upload Limit: %d MB | Cleanup: Every %d min\n", cfg.MaxUploadSizeMB, cfg.CleanupIntervalMinutes)
+	log.Printf("🔧 Compression pipeline: %s", h.Pipeline)
 
 	go func() {
 		if err := apiServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -4,4 +4,9 @@ go 1.25.1
 
 require github.com/go-chi/chi/v5 v5.2.3
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/joho/godotenv v1.5.1
+	software.sslmate.com/src/go-pkcs12 v0.5.0
+)
+
+require golang.org/x/crypto v0.55.0 // indirect
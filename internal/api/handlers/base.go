@@ -1,13 +1,137 @@
 package handlers
 
-import "github.com/vpramatarov/pdf-tools/internal/config"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/config"
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
 
 type Handler struct {
 	Cfg *config.Config
+
+	// Pipeline is the compression pipeline built from Cfg.CompressPipeline.
+	Pipeline *pdf.Pipeline
+
+	// Backends records which compression backends were on PATH at startup.
+	Backends []pdf.BackendInfo
+
+	// Extractor is the text extractor chosen at startup, nil when none is
+	// usable; ExtractorErr then says why.
+	Extractor    pdf.TextExtractor
+	ExtractorErr error
+
+	// Office converts documents to PDF; shared so OFFICE_MAX_JOBS caps
+	// the soffice processes of all requests together.
+	Office *pdf.OfficeConverter
+
+	// Signer holds the identity from SIGN_CERT, nil when it is not set or
+	// cannot be loaded; SignerErr then says why.
+	Signer    *pdf.Signer
+	SignerErr error
 }
 
-func New(cfg *config.Config) *Handler {
+// New builds a Handler from cfg. It fails when COMPRESS_PIPELINE names an
+// unknown backend rather than silently compressing with another pipeline.
+func New(cfg *config.Config) (*Handler, error) {
+	pipeline, err := pdf.ParsePipeline(cfg.CompressPipeline)
+	if err != nil {
+		return nil, fmt.Errorf("invalid COMPRESS_PIPELINE %q: %w", cfg.CompressPipeline, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	extractor, extractorErr := pdf.ResolveExtractor(ctx, cfg.TextExtractor, cfg.PythonBin)
+
+	office := pdf.NewOfficeConverter(cfg.OfficeMaxJobs)
+	office.Timeout = time.Duration(cfg.OfficeTimeoutSeconds) * time.Second
+
+	var signer *pdf.Signer
+	signerErr := errors.New("SIGN_CERT is not set")
+	if cfg.SignCert != "" {
+		signer, signerErr = pdf.LoadSigner(cfg.SignCert, cfg.SignKey, cfg.SignPassword)
+	}
+
 	return &Handler{
-		Cfg: cfg,
+		Cfg:          cfg,
+		Pipeline:     pipeline,
+		Backends:     pdf.DetectBackends(),
+		Extractor:    extractor,
+		ExtractorErr: extractorErr,
+		Office:       office,
+		Signer:       signer,
+		SignerErr:    signerErr,
+	}, nil
+}
+
+// newConverter returns a Converter using the text extractor chosen at startup.
+func (h *Handler) newConverter() *pdf.Converter {
+	converter := pdf.NewConverter()
+	converter.Extractor = h.Extractor
+	return converter
+}
+
+// filterRules resolves the "filters" form field: a profile name, "none", or
+// empty for the TEXT_FILTERS default.
+func (h *Handler) filterRules(r *http.Request) (*pdf.FilterRules, error) {
+	name := r.FormValue("filters")
+	switch name {
+	case "none":
+		return nil, nil
+	case "":
+		name = h.Cfg.TextFilters
+		if name == "" {
+			return nil, nil
+		}
+	}
+	return pdf.FilterProfile(name, h.Cfg.FilterProfilesDir)
+}
+
+// newCompressor returns a Compressor running the configured pipeline.
+func (h *Handler) newCompressor() *pdf.Compressor {
+	compressor := pdf.NewCompressor()
+	compressor.Pipeline = h.Pipeline
+	return compressor
+}
+
+// compressLevel reads an optional compression level from field, nil when
+// the field is empty.
+func compressLevel(r *http.Request, field string) (*pdf.CompressOptions, error) {
+	v := r.FormValue(field)
+	if v == "" {
+		return nil, nil
+	}
+	level, err := pdf.ParseLevel(v)
+	if err != nil {
+		return nil, err
+	}
+	opts := level.Options()
+	return &opts, nil
+}
+
+// describeCompression sums up a compression for the result cards, with its
+// warnings (among them what happened to the signatures).
+func describeCompression(report *pdf.CompressionReport) string {
+	note := fmt.Sprintf("Compressed %s → %s.", formatSize(report.InputSize), formatSize(report.OutputSize))
+	if len(report.Warnings) > 0 {
+		note += " " + html.EscapeString(strings.Join(report.Warnings, "; ")) + "."
+	}
+	return note
+}
+
+// compressInPlace replaces the PDF at path with its compressed version.
+func (h *Handler) compressInPlace(ctx context.Context, path string, opts pdf.CompressOptions) (*pdf.CompressionReport, error) {
+	original := path + ".orig.pdf"
+	if err := os.Rename(path, original); err != nil {
+		return nil, err
 	}
+	defer os.Remove(original)
+	return h.newCompressor().CompressWith(ctx, original, path, opts)
 }
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+type capabilitiesResponse struct {
+	Pipeline      string            `json:"pipeline"`
+	Backends      []pdf.BackendInfo `json:"backends"`
+	TextExtractor string            `json:"text_extractor,omitempty"`
+	ExtractorErr  string            `json:"text_extractor_error,omitempty"`
+	TextFilters   []string          `json:"text_filters"`
+	OCR           bool              `json:"ocr"`
+	OCRLanguage   string            `json:"ocr_language"`
+	Office        bool              `json:"office"`
+	Signing       bool              `json:"signing"`
+	SigningErr    string            `json:"signing_error,omitempty"`
+}
+
+// Capabilities lists the compression backends found on PATH at startup, the
+// pipeline the server runs, the text extractor used for conversions, the
+// text filter profiles the convert form accepts and whether OCR and
+// LibreOffice are installed and whether a signing identity is configured.
+func (h *Handler) Capabilities(w http.ResponseWriter, r *http.Request) {
+	resp := capabilitiesResponse{
+		Pipeline: h.Pipeline.String(),
+		Backends: h.Backends,
+
+		TextFilters: pdf.FilterProfiles(h.Cfg.FilterProfilesDir),
+		OCR:         pdf.TesseractAvailable(),
+		OCRLanguage: h.Cfg.OCRLanguage,
+		Office:      pdf.OfficeAvailable(),
+	}
+	if h.Signer != nil {
+		resp.Signing = true
+	} else if h.SignerErr != nil {
+		resp.SigningErr = h.SignerErr.Error()
+	}
+	if h.Extractor != nil {
+		resp.TextExtractor = h.Extractor.Name()
+	} else if h.ExtractorErr != nil {
+		resp.ExtractorErr = h.ExtractorErr.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"io"
+	"html"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,18 +39,22 @@ func (h *Handler) Compress(w http.ResponseWriter, r *http.Request) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	levelStr := r.FormValue("level")
-	level := pdf.LevelEbook
-	switch levelStr {
-	case "extreme":
-		level = pdf.LevelExtreme
-	case "screen":
-		level = pdf.LevelScreen
-	case "printer":
-		level = pdf.LevelPrinter
+	opts, err := compressOptionsFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	compressor := pdf.NewCompressor()
+	var targetSize int64
+	if v := r.FormValue("target_size"); v != "" {
+		if targetSize, err = pdf.ParseSize(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	compressor := h.newCompressor()
+	password := r.FormValue("password")
 
 	for i, fileHeader := range files {
 		wg.Add(1)
@@ -61,31 +68,56 @@ func (h *Handler) Compress(w http.ResponseWriter, r *http.Request) {
 			defer srcFile.Close()
 
 			tempInput := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("in_%d_%d_%s", time.Now().Unix(), idx, fh.Filename))
-			dstFile, err := os.Create(tempInput)
-			if err != nil {
+			if err := saveUpload(srcFile, tempInput); err != nil {
+				os.Remove(tempInput)
+				mu.Lock()
+				results[idx] = processingResult{filename: fh.Filename, err: err}
+				mu.Unlock()
 				return
 			}
-			io.Copy(dstFile, srcFile)
-			dstFile.Close()
 
-			info, _ := os.Stat(tempInput)
-			origSize := info.Size()
+			// Ghostscript cannot read encrypted files. Decrypting rewrites the
+			// file, so its signatures are counted before.
+			fileOpts := opts
+			signatures, err := pdf.CountSignatures(r.Context(), tempInput)
+			var decrypted bool
+			if err == nil {
+				decrypted, err = pdf.DecryptInPlace(r.Context(), tempInput, password)
+			}
+			if err != nil {
+				os.Remove(tempInput)
+				mu.Lock()
+				results[idx] = processingResult{filename: fh.Filename, err: err}
+				mu.Unlock()
+				return
+			}
+			if decrypted {
+				fileOpts.SourceSignatures = signatures
+			}
 
 			tempOutput := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("compressed_%d_%d_%s", time.Now().Unix(), idx, fh.Filename))
-			err = compressor.Compress(tempInput, tempOutput, level)
 
-			finalSize := int64(0)
+			var report *pdf.CompressionReport
+			var note string
+			if targetSize > 0 {
+				var res *pdf.TargetResult
+				res, err = compressor.CompressToSize(r.Context(), tempInput, tempOutput, targetSize, fileOpts)
+				if err == nil {
+					note = describeTargetResult(res, targetSize)
+					report = res.Report
+				}
+			} else {
+				report, err = compressor.CompressWith(r.Context(), tempInput, tempOutput, fileOpts)
+			}
+
+			var origSize, finalSize int64
 			finalPath := tempOutput
 
 			if err == nil {
-				outInfo, _ := os.Stat(tempOutput)
-				finalSize = outInfo.Size()
-
-				// Revert if larger
-				if finalSize >= origSize {
-					finalSize = origSize
-					inputContent, _ := os.ReadFile(tempInput)
-					os.WriteFile(tempOutput, inputContent, 0644)
+				origSize = report.InputSize
+				finalSize = report.OutputSize
+				if len(report.Warnings) > 0 {
+					note = strings.TrimSpace(note + " " + strings.Join(report.Warnings, "; ") + ".")
 				}
 			}
 
@@ -98,6 +130,7 @@ func (h *Handler) Compress(w http.ResponseWriter, r *http.Request) {
 				originalSize:   origSize,
 				finalSize:      finalSize,
 				filename:       fh.Filename,
+				note:           note,
 				err:            err,
 			}
 			mu.Unlock()
@@ -106,27 +139,46 @@ func (h *Handler) Compress(w http.ResponseWriter, r *http.Request) {
 
 	wg.Wait()
 
+	// The client went away or the router timeout fired; the compressor has
+	// already killed its tools and removed partial outputs.
+	if r.Context().Err() != nil {
+		return
+	}
+
 	var totalOrig, totalFinal int64
 	var finalDownloadName, displayTitle string
 
 	validResults := []processingResult{}
+	var firstErr error
 	for _, res := range results {
 		if res.err == nil && res.finalSize > 0 {
 			totalOrig += res.originalSize
 			totalFinal += res.finalSize
 			validResults = append(validResults, res)
+		} else if firstErr == nil {
+			firstErr = res.err
 		}
 	}
 
 	if len(validResults) == 0 {
+		if errors.Is(firstErr, pdf.ErrPassword) {
+			writeDecryptError(w, firstErr)
+			return
+		}
+		if errors.Is(firstErr, pdf.ErrTargetUnreachable) || errors.Is(firstErr, pdf.ErrSigned) {
+			http.Error(w, firstErr.Error(), http.StatusUnprocessableEntity)
+			return
+		}
 		http.Error(w, "Failed to compress files", http.StatusInternalServerError)
 		return
 	}
 
+	var details string
 	if len(validResults) == 1 {
 		res := validResults[0]
 		finalDownloadName = filepath.Base(res.compressedPath)
 		displayTitle = res.filename
+		details = html.EscapeString(res.note)
 	} else {
 		zipName := fmt.Sprintf("compressed_batch_%d.zip", time.Now().Unix())
 		zipPath := filepath.Join(h.Cfg.UploadDir, zipName)
@@ -137,6 +189,16 @@ func (h *Handler) Compress(w http.ResponseWriter, r *http.Request) {
 		}
 		finalDownloadName = zipName
 		displayTitle = fmt.Sprintf("Archive created from %d files", len(validResults))
+
+		// Each file may have been left unchanged or had its signatures
+		// broken; the batch card lists them by name
+		var notes []string
+		for _, res := range validResults {
+			if res.note != "" {
+				notes = append(notes, html.EscapeString(res.filename+": "+res.note))
+			}
+		}
+		details = strings.Join(notes, "<br>")
 	}
 
 	savedBytes := totalOrig - totalFinal
@@ -151,7 +213,7 @@ func (h *Handler) Compress(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "text/html")
-	html := fmt.Sprintf(`
+	page := fmt.Sprintf(`
 		<div class="p-4 bg-%s-100 border border-%s-400 text-%s-700 rounded fade-in">
 			<div class="flex items-center mb-2">
 				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M9 12l2 2 4-4m6 2a9 9 0 11-18 0 9 9 0 0118 0z"></path></svg>
@@ -169,6 +231,8 @@ func (h *Handler) Compress(w http.ResponseWriter, r *http.Request) {
 				</div>
 			</div>
 
+			<p class="text-xs mb-2">%s</p>
+
 			<div class="mb-4">
 				<div class="w-full bg-gray-200 rounded-full h-2.5">
 					<div class="bg-%s-600 h-2.5 rounded-full" style="width: %.0f%%"></div>
@@ -186,11 +250,95 @@ func (h *Handler) Compress(w http.ResponseWriter, r *http.Request) {
 		displayTitle,
 		formatSize(totalOrig),
 		formatSize(totalFinal),
+		details,
 		statusColor, savedPercent,
 		formatSize(savedBytes), savedPercent,
 		finalDownloadName,
 		statusColor, statusColor, statusColor,
 		filepath.Ext(finalDownloadName))
 
-	w.Write([]byte(html))
+	w.Write([]byte(page))
+}
+
+// compressOptionsFromForm starts from the preset named by the "level" field
+// and overrides every advanced field that was submitted.
+func compressOptionsFromForm(r *http.Request) (pdf.CompressOptions, error) {
+	level, err := pdf.ParseLevel(r.FormValue("level"))
+	if err != nil {
+		return pdf.CompressOptions{}, err
+	}
+	opts := level.Options()
+
+	for field, dst := range map[string]*int{
+		"color_dpi":    &opts.ColorDPI,
+		"gray_dpi":     &opts.GrayDPI,
+		"mono_dpi":     &opts.MonoDPI,
+		"jpeg_quality": &opts.JPEGQuality,
+	} {
+		if v := r.FormValue(field); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s: %q", field, v)
+			}
+			*dst = n
+		}
+	}
+
+	for field, dst := range map[string]*bool{
+		"keep_bookmarks":   &opts.KeepBookmarks,
+		"keep_annotations": &opts.KeepAnnotations,
+		"keep_forms":       &opts.KeepForms,
+		"force":            &opts.Force,
+		"strip_metadata":   &opts.StripMetadata,
+	} {
+		if v := r.FormValue(field); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s: %q", field, v)
+			}
+			*dst = b
+		}
+	}
+
+	if v := r.FormValue("downsample"); v != "" {
+		if opts.Downsample, err = pdf.ParseDownsampleType(v); err != nil {
+			return opts, err
+		}
+	}
+
+	if v := r.FormValue("color_strategy"); v != "" {
+		if opts.ColorStrategy, err = pdf.ParseColorStrategy(v); err != nil {
+			return opts, err
+		}
+	}
+
+	if v := r.FormValue("pdf_version"); v != "" {
+		opts.CompatibilityLevel = v
+	}
+
+	for _, name := range strings.Split(r.FormValue("keep_metadata"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			opts.KeepMetadata = append(opts.KeepMetadata, name)
+		}
+	}
+
+	if err := opts.Validate(); err != nil {
+		return opts, fmt.Errorf("invalid compression options: %w", err)
+	}
+
+	return opts, nil
+}
+
+func describeTargetResult(res *pdf.TargetResult, target int64) string {
+	switch {
+	case res.KeptOriginal && res.Size > target:
+		// A signed file left unchanged, the report's warning says why
+		return ""
+	case res.KeptOriginal && res.Report.Signatures > 0:
+		return "The original already fits the target size, signature preserved."
+	case res.KeptOriginal:
+		return "The original already fits the target size."
+	}
+	return fmt.Sprintf("Target met with %d DPI and JPEG quality %d after %d attempts.",
+		res.Options.ColorDPI, res.Options.JPEGQuality, res.Attempts)
 }
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// convertFormat is one output of the PDF converter.
+type convertFormat struct {
+	label string // shown in the result box
+	ext   string
+	run   func(c *pdf.Converter, ctx context.Context, input, outDir string, opts pdf.ConvertOptions) (*pdf.ConvertResult, error)
+}
+
+var (
+	formatWord     = convertFormat{"Word document", ".docx", (*pdf.Converter).ToWordWith}
+	formatMarkdown = convertFormat{"Markdown file", ".md", (*pdf.Converter).ToMarkdown}
+	formatHTML     = convertFormat{"HTML page", ".html", (*pdf.Converter).ToHTML}
+	formatText     = convertFormat{"Text file", ".txt", (*pdf.Converter).ToText}
+)
+
+func (h *Handler) ConvertToWord(w http.ResponseWriter, r *http.Request) {
+	h.convert(w, r, formatWord)
+}
+
+func (h *Handler) ConvertToMarkdown(w http.ResponseWriter, r *http.Request) {
+	h.convert(w, r, formatMarkdown)
+}
+
+func (h *Handler) ConvertToHTML(w http.ResponseWriter, r *http.Request) {
+	h.convert(w, r, formatHTML)
+}
+
+func (h *Handler) ConvertToText(w http.ResponseWriter, r *http.Request) {
+	h.convert(w, r, formatText)
+}
+
+// describeConvertResult lists the OCR'd pages and warnings, if any.
+func describeConvertResult(res *pdf.ConvertResult) string {
+	var notes []string
+	if len(res.OCRPages) > 0 {
+		pages := make([]string, len(res.OCRPages))
+		for i, n := range res.OCRPages {
+			pages[i] = strconv.Itoa(n)
+		}
+		notes = append(notes, "Read with OCR: page "+strings.Join(pages, ", ")+".")
+	}
+	notes = append(notes, res.Warnings...)
+	if len(notes) == 0 {
+		return ""
+	}
+	return `<p class="mb-4 text-xs">` + html.EscapeString(strings.Join(notes, " ")) + `</p>`
+}
+
+func (h *Handler) convert(w http.ResponseWriter, r *http.Request, format convertFormat) {
+	if h.Extractor == nil {
+		msg := "PDF conversion is not available"
+		if h.ExtractorErr != nil {
+			msg += ": " + h.ExtractorErr.Error()
+		}
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	file, handler, err := r.FormFile("pdf")
+	if err != nil {
+		http.Error(w, "Invalid file or 'pdf' field missing", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	useOCR := formBool(r, "ocr")
+	ocrLang := r.FormValue("ocr_lang")
+	if ocrLang == "" {
+		ocrLang = h.Cfg.OCRLanguage
+	}
+	if useOCR {
+		if err := pdf.CheckOCRLanguage(r.Context(), ocrLang); err != nil {
+			writeOCRLanguageError(w, r, err)
+			return
+		}
+	}
+
+	tempInput := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("word_in_%d_%s", time.Now().Unix(), handler.Filename))
+	defer os.Remove(tempInput)
+	if err := saveUpload(file, tempInput); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !decryptUpload(w, r, tempInput) {
+		return
+	}
+
+	sortParam := r.FormValue("sort")
+	useSort := true
+	if sortParam == "false" || sortParam == "0" {
+		useSort = false
+	}
+
+	filters, err := h.filterRules(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	converter := h.newConverter()
+	result, err := format.run(converter, r.Context(), tempInput, h.Cfg.UploadDir, pdf.ConvertOptions{
+		Sort:        useSort,
+		Filters:     filters,
+		KeepImages:  formBool(r, "keep_images"),
+		Tables:      formBool(r, "tables"),
+		OCR:         useOCR,
+		OCRLanguage: ocrLang,
+	})
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Conversion failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	outputName := filepath.Base(result.Path)
+
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M9 12h6m-6 4h6m2 5H7a2 2 0 01-2-2V5a2 2 0 012-2h5.586a1 1 0 01.707.293l5.414 5.414a1 1 0 01.293.707V19a2 2 0 01-2 2z"></path></svg>
+				<span class="font-bold text-lg">Success!</span>
+			</div>
+			
+			<p class="mb-4 text-sm">Your %s is ready.</p>
+			%s
+
+			<a href="/download/%s" 
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download %s
+			</a>
+		</div>
+	`, format.label, describeConvertResult(result), outputName, format.ext)
+
+	w.Write([]byte(page))
+}
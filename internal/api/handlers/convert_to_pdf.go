@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// ConvertToPDF turns the uploaded office document ("document" field: DOCX,
+// XLSX, PPTX, ODT, ...) into a PDF with LibreOffice, optionally compressed
+// with the level named by "compress".
+func (h *Handler) ConvertToPDF(w http.ResponseWriter, r *http.Request) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	file, handler, err := r.FormFile("document")
+	if err != nil {
+		http.Error(w, "Invalid file or 'document' field missing", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if !pdf.IsOfficeDocument(handler.Filename) {
+		http.Error(w, "Unsupported document type, upload DOCX, XLSX, PPTX, ODT or similar", http.StatusBadRequest)
+		return
+	}
+
+	compressOpts, err := compressLevel(r, "compress")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.Office == nil || !pdf.OfficeAvailable() {
+		http.Error(w, "LibreOffice is not installed on the server", http.StatusServiceUnavailable)
+		return
+	}
+
+	stamp := time.Now().Unix()
+	workDir, err := os.MkdirTemp(h.Cfg.UploadDir, "to_pdf_")
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	// soffice names the PDF after its input, so keep the uploaded name
+	tempInput := filepath.Join(workDir, filepath.Base(handler.Filename))
+	if err := saveUpload(file, tempInput); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	converted, err := h.Office.Convert(r.Context(), tempInput, workDir)
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Conversion failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	outputPath := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("office_%d_%s", stamp, filepath.Base(converted)))
+	if err := os.Rename(converted, outputPath); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	var notes []string
+	if compressOpts != nil {
+		report, err := h.compressInPlace(r.Context(), outputPath, *compressOpts)
+		if pdf.IsAborted(err) {
+			return
+		}
+		if err != nil {
+			http.Error(w, "Compression failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		notes = append(notes, describeCompression(report))
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M7 21h10a2 2 0 002-2V9.414a1 1 0 00-.293-.707l-5.414-5.414A1 1 0 0012.586 3H7a2 2 0 00-2 2v14a2 2 0 002 2z"></path></svg>
+				<span class="font-bold text-lg">PDF ready!</span>
+			</div>
+
+			<p class="mb-4 text-xs">%s</p>
+
+			<a href="/download/%s" 
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download .pdf
+			</a>
+		</div>
+	`, strings.Join(notes, " "), filepath.Base(outputPath))
+
+	w.Write([]byte(page))
+}
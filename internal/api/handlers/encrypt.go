@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// Encrypt protects the uploaded PDF with AES-256. Fields: user_password
+// (needed to open the file), owner_password (needed to change the
+// permissions; random when empty) and the permissions allow_print,
+// allow_copy, allow_modify and allow_annotate.
+func (h *Handler) Encrypt(w http.ResponseWriter, r *http.Request) {
+	workDir, input, ok := h.securityUpload(w, r, "encrypt_")
+	if !ok {
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	opts := pdf.EncryptOptions{
+		UserPassword:  r.FormValue("user_password"),
+		OwnerPassword: r.FormValue("owner_password"),
+		Permissions: pdf.Permissions{
+			Print:    formBool(r, "allow_print"),
+			Copy:     formBool(r, "allow_copy"),
+			Modify:   formBool(r, "allow_modify"),
+			Annotate: formBool(r, "allow_annotate"),
+		},
+	}
+	if opts.UserPassword == "" && opts.OwnerPassword == "" {
+		http.Error(w, "Give a user or an owner password", http.StatusBadRequest)
+		return
+	}
+
+	outputPath := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("encrypted_%d_%s", time.Now().Unix(), filepath.Base(input)))
+	err := pdf.Encrypt(r.Context(), input, outputPath, opts)
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Encryption failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	notes := "Encrypted with AES-256."
+	if opts.UserPassword == "" {
+		notes += " Anyone can open it; the permissions apply."
+	}
+	writeSecurityResult(w, "PDF encrypted!", notes, filepath.Base(outputPath))
+}
+
+// Decrypt removes the encryption of the uploaded PDF. Field: password (user
+// or owner password; empty for files that only restrict permissions).
+func (h *Handler) Decrypt(w http.ResponseWriter, r *http.Request) {
+	workDir, input, ok := h.securityUpload(w, r, "decrypt_")
+	if !ok {
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	encrypted, err := pdf.IsEncrypted(r.Context(), input)
+	if pdf.IsAborted(err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, "Decryption failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if !encrypted {
+		http.Error(w, "The PDF is not encrypted", http.StatusUnprocessableEntity)
+		return
+	}
+
+	outputPath := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("decrypted_%d_%s", time.Now().Unix(), filepath.Base(input)))
+	err = pdf.Decrypt(r.Context(), input, outputPath, r.FormValue("password"))
+	if pdf.IsAborted(err) {
+		return
+	}
+	if err != nil {
+		writeDecryptError(w, err)
+		return
+	}
+
+	writeSecurityResult(w, "PDF decrypted!", "The password and the restrictions were removed.", filepath.Base(outputPath))
+}
+
+// decryptUpload decrypts an uploaded file in place before processing, with
+// the optional "password" field. It answers the request and returns false
+// when that fails.
+func decryptUpload(w http.ResponseWriter, r *http.Request, path string) bool {
+	_, err := pdf.DecryptInPlace(r.Context(), path, r.FormValue("password"))
+	if pdf.IsAborted(err) {
+		return false
+	}
+	if err != nil {
+		writeDecryptError(w, err)
+		return false
+	}
+	return true
+}
+
+func writeDecryptError(w http.ResponseWriter, err error) {
+	if errors.Is(err, pdf.ErrPassword) {
+		http.Error(w, "The PDF is encrypted: enter its password", http.StatusUnprocessableEntity)
+		return
+	}
+	http.Error(w, "Decryption failed: "+err.Error(), http.StatusUnprocessableEntity)
+}
+
+// securityUpload saves the "pdf" upload under its own name in a new work
+// directory.
+func (h *Handler) securityUpload(w http.ResponseWriter, r *http.Request, prefix string) (string, string, bool) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "File too large or invalid form", http.StatusBadRequest)
+		return "", "", false
+	}
+
+	file, header, err := r.FormFile("pdf")
+	if err != nil {
+		http.Error(w, "Invalid file or 'pdf' field missing", http.StatusBadRequest)
+		return "", "", false
+	}
+	defer file.Close()
+
+	workDir, err := os.MkdirTemp(h.Cfg.UploadDir, prefix)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return "", "", false
+	}
+	input := filepath.Join(workDir, filepath.Base(header.Filename))
+	if err := saveUpload(file, input); err != nil {
+		os.RemoveAll(workDir)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return "", "", false
+	}
+	return workDir, input, true
+}
+
+func writeSecurityResult(w http.ResponseWriter, title, notes, downloadName string) {
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M12 15v2m-6 4h12a2 2 0 002-2v-6a2 2 0 00-2-2H6a2 2 0 00-2 2v6a2 2 0 002 2zm10-10V7a4 4 0 00-8 0v4h8z"></path></svg>
+				<span class="font-bold text-lg">%s</span>
+			</div>
+
+			<p class="mb-4 text-xs">%s</p>
+
+			<a href="/download/%s"
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download .pdf
+			</a>
+		</div>
+	`, title, notes, downloadName)
+
+	w.Write([]byte(page))
+}
@@ -2,17 +2,25 @@ package handlers
 
 import (
 	"bytes"
+	"errors"
+	"image"
+	"image/png"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
+
 	"github.com/vpramatarov/pdf-tools/internal/config"
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
 )
 
 // Helper for creating multipart request
@@ -73,7 +81,10 @@ func TestHandler_Compress_Integration(t *testing.T) {
 		MaxUploadSizeMB:        50,
 		CleanupIntervalMinutes: 10,
 	}
-	h := New(testCfg)
+	h, err := New(testCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	req, filename := createMultipartRequest(t, "/compress", "pdf", testFilePath)
 	rr := httptest.NewRecorder()
@@ -137,7 +148,10 @@ func TestHandler_Compress_NoFile(t *testing.T) {
 		CleanupIntervalMinutes: 10,
 	}
 
-	h := New(testCfg)
+	h, err := New(testCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	req := httptest.NewRequest("POST", "/compress", nil)
 	rr := httptest.NewRecorder()
@@ -160,7 +174,10 @@ func TestHandler_Home(t *testing.T) {
 	os.WriteFile("web/templates/index.html", []byte("<html></html>"), 0644)
 	defer os.RemoveAll("web") // cleanup
 
-	h := New(testCfg)
+	h, err := New(testCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
 	req := httptest.NewRequest("GET", "/", nil)
 	rr := httptest.NewRecorder()
 
@@ -170,3 +187,432 @@ func TestHandler_Home(t *testing.T) {
 		t.Errorf("Home handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
 	}
 }
+
+func TestHandler_Capabilities(t *testing.T) {
+	testCfg := &config.Config{
+		UploadDir:        t.TempDir(),
+		CompressPipeline: "qpdf",
+	}
+
+	h, err := New(testCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", "/capabilities", nil)
+	rr := httptest.NewRecorder()
+
+	h.Capabilities(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Capabilities handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	body := rr.Body.String()
+	if !strings.Contains(body, `"pipeline":"qpdf"`) {
+		t.Errorf("Response does not report the configured pipeline: %s", body)
+	}
+	for _, name := range []string{"ghostscript", "qpdf", "mutool"} {
+		if !strings.Contains(body, `"name":"`+name+`"`) {
+			t.Errorf("Response does not list backend %s: %s", name, body)
+		}
+	}
+}
+
+func TestHandler_OCR_InvalidLanguage(t *testing.T) {
+	testCfg := &config.Config{
+		UploadDir:       t.TempDir(),
+		MaxUploadSizeMB: 10,
+		OCRLanguage:     "eng",
+	}
+	h := &Handler{Cfg: testCfg}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("pdf", "scan.pdf")
+	part.Write([]byte("%PDF-1.4"))
+	writer.WriteField("ocr_lang", "-c foo")
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/ocr", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+
+	h.OCR(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("OCR handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+	if !strings.Contains(rr.Body.String(), "invalid OCR language") {
+		t.Errorf("Unexpected error message: %s", rr.Body.String())
+	}
+}
+
+func TestHandler_Convert_InvalidOCRLanguage(t *testing.T) {
+	uploadDir := t.TempDir()
+	h := &Handler{Cfg: &config.Config{UploadDir: uploadDir, MaxUploadSizeMB: 10, OCRLanguage: "eng"}, Extractor: pdf.PdftotextExtractor{}}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("pdf", "scan.pdf")
+	part.Write([]byte("%PDF-1.4"))
+	writer.WriteField("ocr", "true")
+	writer.WriteField("ocr_lang", "-c foo")
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/convert-text", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+
+	h.ConvertToText(rr, req)
+
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "invalid OCR language") {
+		t.Errorf("handler returned %v %q, want 400 for the language", rr.Code, rr.Body.String())
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(uploadDir, "word_in_*")); len(leftovers) != 0 {
+		t.Errorf("Upload was not removed: %v", leftovers)
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	if got, err := parseOrder("", 3); err != nil || len(got) != 3 || got[2] != 2 {
+		t.Errorf("parseOrder(\"\", 3) = %v, %v; want upload order", got, err)
+	}
+	if got, err := parseOrder("2, 0,1", 3); err != nil || got[0] != 2 || got[1] != 0 || got[2] != 1 {
+		t.Errorf("parseOrder(2,0,1) = %v, %v", got, err)
+	}
+	for _, bad := range []string{"0,1", "0,0,1", "0,1,3", "a,b,c"} {
+		if _, err := parseOrder(bad, 3); err == nil {
+			t.Errorf("parseOrder(%q, 3) returned no error", bad)
+		}
+	}
+}
+
+func TestHandler_Split_InvalidMode(t *testing.T) {
+	testCfg := &config.Config{
+		UploadDir:       t.TempDir(),
+		MaxUploadSizeMB: 10,
+	}
+	h := &Handler{Cfg: testCfg}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("pdf", "doc.pdf")
+	part.Write([]byte("%PDF-1.4"))
+	writer.WriteField("mode", "chapters")
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/split", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+
+	h.Split(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Split handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+	if !strings.Contains(rr.Body.String(), "unknown split mode") {
+		t.Errorf("Unexpected error message: %s", rr.Body.String())
+	}
+}
+
+func TestPageOpsFromForm(t *testing.T) {
+	jsonOps, err := pageOpsFromForm(`[{"op":"rotate","pages":"2,4","angle":90},{"op":"move","pages":"3","to":1}]`)
+	if err != nil || len(jsonOps) != 2 || jsonOps[0].Angle != 90 || jsonOps[1].To != 1 {
+		t.Errorf("JSON ops = %+v, %v", jsonOps, err)
+	}
+
+	textOps, err := pageOpsFromForm("rotate 2,4 by 90; move 3 to 1")
+	if err != nil || !reflect.DeepEqual(textOps, jsonOps) {
+		t.Errorf("text ops = %+v, %v; want %+v", textOps, err, jsonOps)
+	}
+
+	for _, bad := range []string{"", "[{", "spin 1"} {
+		if _, err := pageOpsFromForm(bad); err == nil {
+			t.Errorf("pageOpsFromForm(%q) returned no error", bad)
+		}
+	}
+}
+
+func TestHandler_Thumbnail_InvalidID(t *testing.T) {
+	h := &Handler{Cfg: &config.Config{UploadDir: t.TempDir()}}
+	r := chi.NewRouter()
+	r.Get("/thumbnail/{id}/{page}", h.Thumbnail)
+
+	cases := map[string]int{
+		"/thumbnail/..%2F..%2Fetc/1":    http.StatusBadRequest,
+		"/thumbnail/0123456789abcdef/0": http.StatusBadRequest,
+		"/thumbnail/0123456789abcdef/x": http.StatusBadRequest,
+		"/thumbnail/0123456789abcdef/1": http.StatusNotFound,
+	}
+	for url, want := range cases {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		if rr.Code != want {
+			t.Errorf("GET %s: got %d, want %d", url, rr.Code, want)
+		}
+	}
+}
+
+func TestHandler_ImagesToPDF(t *testing.T) {
+	uploadDir := t.TempDir()
+	h := &Handler{Cfg: &config.Config{UploadDir: uploadDir, MaxUploadSizeMB: 10}}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for _, name := range []string{"first.png", "second.png"} {
+		part, _ := writer.CreateFormFile("images", name)
+		png.Encode(part, image.NewGray(image.Rect(0, 0, 30, 20)))
+	}
+	writer.WriteField("order", "1,0")
+	writer.WriteField("page_size", "letter")
+	writer.WriteField("margin", "5")
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/images-to-pdf", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+
+	h.ImagesToPDF(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ImagesToPDF returned %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "2 images, 2 pages.") {
+		t.Errorf("Unexpected response: %s", rr.Body.String())
+	}
+
+	outputs, _ := filepath.Glob(filepath.Join(uploadDir, "images_*.pdf"))
+	if len(outputs) != 1 {
+		t.Fatalf("Expected one PDF in the upload dir, found %v", outputs)
+	}
+	if inputs, _ := filepath.Glob(filepath.Join(uploadDir, "img_in_*")); len(inputs) != 0 {
+		t.Errorf("Uploaded images were not removed: %v", inputs)
+	}
+}
+
+func TestHandler_ConvertToPDF_UnsupportedType(t *testing.T) {
+	h := &Handler{Cfg: &config.Config{UploadDir: t.TempDir(), MaxUploadSizeMB: 10}}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("document", "notes.exe")
+	part.Write([]byte("MZ"))
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/convert-to-pdf", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+
+	h.ConvertToPDF(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("ConvertToPDF returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandler_Watermark_InvalidOptions(t *testing.T) {
+	uploadDir := t.TempDir()
+	h := &Handler{Cfg: &config.Config{UploadDir: uploadDir, MaxUploadSizeMB: 10}}
+
+	for field, value := range map[string]string{
+		"layer":    "middle",
+		"position": "somewhere",
+		"opacity":  "half",
+	} {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("pdf", "test.pdf")
+		part.Write([]byte("%PDF-1.4"))
+		writer.WriteField("text", "CONFIDENTIAL")
+		writer.WriteField(field, value)
+		writer.Close()
+
+		req := httptest.NewRequest("POST", "/watermark", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rr := httptest.NewRecorder()
+
+		h.Watermark(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s=%q: got status %v want %v", field, value, rr.Code, http.StatusBadRequest)
+		}
+	}
+
+	if leftovers, _ := filepath.Glob(filepath.Join(uploadDir, "watermark_*")); len(leftovers) != 0 {
+		t.Errorf("Work directories were not removed: %v", leftovers)
+	}
+}
+
+func TestStamperFromForm(t *testing.T) {
+	form := url.Values{
+		"template":     {"{bates}"},
+		"bates_prefix": {"ACME"},
+		"bates_digits": {"4"},
+		"bates_start":  {"100"},
+		"position":     {"bottom-right"},
+		"date":         {"2024-05-01"},
+	}
+	req := httptest.NewRequest("POST", "/stamp", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	stamper, err := stamperFromForm(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stamper.BatesPrefix != "ACME" || stamper.BatesDigits != 4 || stamper.BatesStart != 100 {
+		t.Errorf("Unexpected Bates settings: %+v", stamper)
+	}
+	if stamper.Position != pdf.PositionBottomRight || stamper.Date.Day() != 1 {
+		t.Errorf("Unexpected position or date: %+v", stamper)
+	}
+
+	for field, value := range map[string]string{"date": "01.05.2024", "bates_start": "x", "position": "middle"} {
+		bad := url.Values{field: {value}}
+		req := httptest.NewRequest("POST", "/stamp", strings.NewReader(bad.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if _, err := stamperFromForm(req); err == nil {
+			t.Errorf("%s=%q: expected an error", field, value)
+		}
+	}
+}
+
+func TestStampTitle(t *testing.T) {
+	for template, want := range map[string]string{
+		"{bates}":                "Bates numbers added!",
+		"Page {page} of {total}": "Pages numbered!",
+		"{filename} – {date}":    "Header/footer added!",
+		"ACME {bates} p. {page}": "Bates numbers added!",
+	} {
+		if got := stampTitle(template); got != want {
+			t.Errorf("stampTitle(%q) = %q, want %q", template, got, want)
+		}
+	}
+}
+
+func TestHandler_Encrypt_NoPassword(t *testing.T) {
+	uploadDir := t.TempDir()
+	h := &Handler{Cfg: &config.Config{UploadDir: uploadDir, MaxUploadSizeMB: 10}}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("pdf", "test.pdf")
+	part.Write([]byte("%PDF-1.4"))
+	writer.WriteField("allow_print", "on")
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/encrypt", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+
+	h.Encrypt(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(uploadDir, "encrypt_*")); len(leftovers) != 0 {
+		t.Errorf("Work directories were not removed: %v", leftovers)
+	}
+}
+
+func TestHandler_Convert_NoExtractor(t *testing.T) {
+	// Built without New, so ExtractorErr is nil too
+	h := &Handler{Cfg: &config.Config{UploadDir: t.TempDir(), MaxUploadSizeMB: 10}}
+
+	req := httptest.NewRequest("POST", "/convert", nil)
+	rr := httptest.NewRecorder()
+
+	h.ConvertToWord(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHandler_Sign_NotConfigured(t *testing.T) {
+	h := &Handler{Cfg: &config.Config{UploadDir: t.TempDir(), MaxUploadSizeMB: 10}, SignerErr: errors.New("SIGN_CERT is not set")}
+
+	req := httptest.NewRequest("POST", "/sign", nil)
+	rr := httptest.NewRecorder()
+
+	h.Sign(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHandler_Verify_Unsigned(t *testing.T) {
+	uploadDir := t.TempDir()
+	h := &Handler{Cfg: &config.Config{UploadDir: uploadDir, MaxUploadSizeMB: 10}}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("pdf", "test.pdf")
+	part.Write([]byte("%PDF-1.4\n%%EOF\n"))
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/verify", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+
+	h.Verify(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if !strings.Contains(rr.Body.String(), "No signatures found") {
+		t.Errorf("unexpected body: %s", rr.Body.String())
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(uploadDir, "verify_*")); len(leftovers) != 0 {
+		t.Errorf("Work directories were not removed: %v", leftovers)
+	}
+}
+
+func TestHandler_Metadata_Invalid(t *testing.T) {
+	uploadDir := t.TempDir()
+	h := &Handler{Cfg: &config.Config{UploadDir: uploadDir, MaxUploadSizeMB: 10}}
+	r := chi.NewRouter()
+	r.Get("/metadata/{filename}", h.ReadMetadata)
+	r.Post("/metadata", h.EditMetadata)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/metadata/missing.pdf", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("GET returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("pdf", "test.pdf")
+	part.Write([]byte("%PDF-1.4"))
+	writer.WriteField("remove", "title,colour")
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/metadata", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("POST returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	body = &bytes.Buffer{}
+	writer = multipart.NewWriter(body)
+	part, _ = writer.CreateFormFile("pdf", "test.pdf")
+	part.Write([]byte("%PDF-1.4"))
+	writer.WriteField("created", "last tuesday")
+	writer.Close()
+
+	req = httptest.NewRequest("POST", "/metadata", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "invalid date") {
+		t.Errorf("POST with a bad date = %v %q, want 400", rr.Code, rr.Body.String())
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(uploadDir, "metadata_*")); len(leftovers) != 0 {
+		t.Errorf("Work directories were not removed: %v", leftovers)
+	}
+}
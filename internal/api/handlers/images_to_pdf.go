@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// ImagesToPDF puts the uploaded images ("images" field) on the pages of one
+// PDF. Fields: order (as for /merge), page_size (a4, letter, fit),
+// orientation (auto, portrait, landscape), margin in mm, auto_rotate
+// (default true) and compress (a compression level, optional).
+func (h *Handler) ImagesToPDF(w http.ResponseWriter, r *http.Request) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "File too large or invalid form", http.StatusBadRequest)
+		return
+	}
+
+	if r.MultipartForm == nil || r.MultipartForm.File == nil {
+		http.Error(w, "No files uploaded", http.StatusBadRequest)
+		return
+	}
+
+	files := r.MultipartForm.File["images"]
+	if len(files) == 0 {
+		http.Error(w, "No images uploaded", http.StatusBadRequest)
+		return
+	}
+
+	order, err := parseOrder(r.FormValue("order"), len(files))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	converter, err := imagesToPDFFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	compressOpts, err := compressLevel(r, "compress")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stamp := time.Now().Unix()
+	var inputs []string
+	defer func() {
+		for _, in := range inputs {
+			os.Remove(in)
+		}
+	}()
+
+	for _, idx := range order {
+		fh := files[idx]
+		src, err := fh.Open()
+		if err != nil {
+			http.Error(w, "Invalid file "+fh.Filename, http.StatusBadRequest)
+			return
+		}
+
+		tempInput := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("img_in_%d_%d_%s", stamp, idx, filepath.Base(fh.Filename)))
+		err = saveUpload(src, tempInput)
+		src.Close()
+		if err != nil {
+			os.Remove(tempInput)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+		inputs = append(inputs, tempInput)
+	}
+
+	outputPath := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("images_%d.pdf", stamp))
+	result, err := converter.Convert(r.Context(), inputs, outputPath)
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Conversion failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	notes := []string{fmt.Sprintf("%d images, %d pages.", len(files), result.PageCount)}
+	if compressOpts != nil {
+		report, err := h.compressInPlace(r.Context(), outputPath, *compressOpts)
+		if pdf.IsAborted(err) {
+			return
+		}
+		if err != nil {
+			http.Error(w, "Compression failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		notes = append(notes, describeCompression(report))
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 16l4.586-4.586a2 2 0 012.828 0L16 16m-2-2l1.586-1.586a2 2 0 012.828 0L20 14m-6-6h.01M6 20h12a2 2 0 002-2V6a2 2 0 00-2-2H6a2 2 0 00-2 2v12a2 2 0 002 2z"></path></svg>
+				<span class="font-bold text-lg">PDF created!</span>
+			</div>
+
+			<p class="mb-4 text-xs">%s</p>
+
+			<a href="/download/%s" 
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download .pdf
+			</a>
+		</div>
+	`, strings.Join(notes, " "), filepath.Base(outputPath))
+
+	w.Write([]byte(page))
+}
+
+func imagesToPDFFromForm(r *http.Request) (*pdf.ImagesToPDF, error) {
+	converter := pdf.NewImagesToPDF()
+	var err error
+	if converter.PageSize, err = pdf.ParsePageSize(r.FormValue("page_size")); err != nil {
+		return nil, err
+	}
+	if converter.Orientation, err = pdf.ParseOrientation(r.FormValue("orientation")); err != nil {
+		return nil, err
+	}
+	if v := r.FormValue("margin"); v != "" {
+		if converter.Margin, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("invalid margin: %q", v)
+		}
+	}
+	if v := r.FormValue("auto_rotate"); v != "" {
+		if converter.AutoRotate, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid auto_rotate: %q", v)
+		}
+	}
+	return converter, nil
+}
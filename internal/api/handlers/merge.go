@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// Merge concatenates the uploaded PDFs. "order" lists the upload indices in
+// the wanted order (e.g. "2,0,1", default upload order), "outline" is files,
+// keep or none and "blank_pages" separates the documents with an empty page.
+func (h *Handler) Merge(w http.ResponseWriter, r *http.Request) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "File too large or invalid form", http.StatusBadRequest)
+		return
+	}
+
+	if r.MultipartForm == nil || r.MultipartForm.File == nil {
+		http.Error(w, "No files uploaded", http.StatusBadRequest)
+		return
+	}
+
+	files := r.MultipartForm.File["pdf"]
+	if len(files) < 2 {
+		http.Error(w, "Upload at least two PDFs to merge", http.StatusBadRequest)
+		return
+	}
+
+	order, err := parseOrder(r.FormValue("order"), len(files))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	merger := pdf.NewMerger()
+	if merger.Outline, err = pdf.ParseOutlineMode(r.FormValue("outline")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	merger.BlankPages = formBool(r, "blank_pages")
+
+	stamp := time.Now().Unix()
+	inputs := make([]pdf.MergeInput, 0, len(files))
+	defer func() {
+		for _, in := range inputs {
+			os.Remove(in.Path)
+		}
+	}()
+
+	for _, idx := range order {
+		fh := files[idx]
+		src, err := fh.Open()
+		if err != nil {
+			http.Error(w, "Invalid file "+fh.Filename, http.StatusBadRequest)
+			return
+		}
+
+		tempInput := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("merge_in_%d_%d_%s", stamp, idx, filepath.Base(fh.Filename)))
+		err = saveUpload(src, tempInput)
+		src.Close()
+		if err != nil {
+			os.Remove(tempInput)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+
+		title := strings.TrimSuffix(filepath.Base(fh.Filename), filepath.Ext(fh.Filename))
+		inputs = append(inputs, pdf.MergeInput{Path: tempInput, Title: title})
+	}
+
+	outputPath := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("merged_%d.pdf", stamp))
+	result, err := merger.Merge(r.Context(), inputs, outputPath)
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Merge failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 6h16M4 12h16M4 18h16"></path></svg>
+				<span class="font-bold text-lg">PDFs merged!</span>
+			</div>
+
+			<p class="mb-4 text-xs">%d files, %d pages.</p>
+
+			<a href="/download/%s" 
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download .pdf
+			</a>
+		</div>
+	`, len(result.Files), result.PageCount, filepath.Base(outputPath))
+
+	w.Write([]byte(page))
+}
+
+// parseOrder reads a comma separated permutation of 0..n-1. Empty means the
+// upload order.
+func parseOrder(s string, n int) ([]int, error) {
+	if strings.TrimSpace(s) == "" {
+		order := make([]int, n)
+		for i := range n {
+			order[i] = i
+		}
+		return order, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("order must list all %d files", n)
+	}
+	seen := make([]bool, n)
+	order := make([]int, n)
+	for i, part := range parts {
+		idx, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || idx < 0 || idx >= n || seen[idx] {
+			return nil, fmt.Errorf("invalid order %q", s)
+		}
+		seen[idx] = true
+		order[i] = idx
+	}
+	return order, nil
+}
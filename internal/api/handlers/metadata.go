@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// ReadMetadata returns the metadata of a file in the upload directory, such
+// as a result offered under /download, as JSON.
+func (h *Handler) ReadMetadata(w http.ResponseWriter, r *http.Request) {
+	path := filepath.Join(h.Cfg.UploadDir, filepath.Base(chi.URLParam(r, "filename")))
+	if _, err := os.Stat(path); err != nil {
+		http.Error(w, "File not found or expired", http.StatusNotFound)
+		return
+	}
+
+	meta, err := pdf.ReadMetadata(r.Context(), path)
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Reading metadata failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(meta)
+}
+
+// EditMetadata changes the metadata of the uploaded PDF. Fields: title,
+// author, subject, keywords, creator, producer, created and modified (empty
+// ones are left as they are), remove (comma separated fields to clear),
+// strip (remove everything) and force (change signed PDFs too, which breaks
+// their signatures). Without changes it only shows the metadata.
+func (h *Handler) EditMetadata(w http.ResponseWriter, r *http.Request) {
+	workDir, input, ok := h.securityUpload(w, r, "metadata_")
+	if !ok {
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	fields := map[string]string{}
+	for _, name := range pdf.MetadataFields {
+		if v := strings.TrimSpace(r.FormValue(name)); v != "" {
+			fields[name] = v
+		}
+	}
+	for _, name := range []string{"created", "modified"} {
+		if v, ok := fields[name]; ok {
+			if _, err := pdf.ParseMetadataDate(v); err != nil {
+				http.Error(w, name+": "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	for _, name := range strings.Split(r.FormValue("remove"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name == "" {
+			continue
+		}
+		if !slices.Contains(pdf.MetadataFields, name) {
+			http.Error(w, fmt.Sprintf("unknown metadata field %q (use %s)", name, strings.Join(pdf.MetadataFields, ", ")), http.StatusBadRequest)
+			return
+		}
+		fields[name] = ""
+	}
+	if formBool(r, "strip") {
+		for _, name := range pdf.MetadataFields {
+			fields[name] = ""
+		}
+	}
+
+	if len(fields) == 0 {
+		meta, err := pdf.ReadMetadata(r.Context(), input)
+		if pdf.IsAborted(err) {
+			return
+		}
+		if err != nil {
+			http.Error(w, "Reading metadata failed: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		writeMetadataResult(w, meta, "")
+		return
+	}
+
+	// Writing the metadata rewrites the file, like compression
+	signatures, err := pdf.CountSignatures(r.Context(), input)
+	if pdf.IsAborted(err) {
+		return
+	}
+	if err == nil && signatures > 0 && !formBool(r, "force") {
+		http.Error(w, fmt.Sprintf("The PDF has %d digital signature(s), changing its metadata breaks them (force changes it anyway)", signatures), http.StatusUnprocessableEntity)
+		return
+	}
+
+	outputPath := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("metadata_%d_%s", time.Now().Unix(), filepath.Base(input)))
+	err = pdf.WriteMetadata(r.Context(), input, outputPath, fields)
+	if pdf.IsAborted(err) {
+		return
+	}
+	if err != nil {
+		os.Remove(outputPath)
+		http.Error(w, "Updating metadata failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	meta, err := pdf.ReadMetadata(r.Context(), outputPath)
+	if pdf.IsAborted(err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, "Reading metadata failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeMetadataResult(w, meta, filepath.Base(outputPath))
+}
+
+// writeMetadataResult lists the fields of meta, with a download link when
+// downloadName is set.
+func writeMetadataResult(w http.ResponseWriter, meta *pdf.Metadata, downloadName string) {
+	var rows strings.Builder
+	for _, name := range pdf.MetadataFields {
+		value := meta.Field(name)
+		if value == "" {
+			value = "—"
+		}
+		fmt.Fprintf(&rows, `
+				<tr><td class="pr-4 py-1 font-medium capitalize">%s</td><td class="py-1 break-all">%s</td></tr>`, name, html.EscapeString(value))
+	}
+	xmp := "no"
+	if meta.XMP != "" {
+		xmp = "yes"
+	}
+
+	title, link := "PDF metadata", ""
+	if downloadName != "" {
+		title = "Metadata updated!"
+		link = fmt.Sprintf(`
+			<a href="/download/%s"
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download .pdf
+			</a>`, downloadName)
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, `
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<span class="font-bold text-lg">%s</span>
+			<table class="w-full text-sm my-2">%s
+				<tr><td class="pr-4 py-1 font-medium">XMP packet</td><td class="py-1">%s</td></tr>
+			</table>%s
+		</div>
+	`, title, rows.String(), xmp, link)
+}
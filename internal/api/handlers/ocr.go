@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// OCR returns the uploaded PDF with an invisible text layer on its scanned
+// pages, optionally compressed with the level named by "compress".
+// "force_signed" compresses signed uploads whose pages got a text layer,
+// which breaks their signatures.
+func (h *Handler) OCR(w http.ResponseWriter, r *http.Request) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	file, handler, err := r.FormFile("pdf")
+	if err != nil {
+		http.Error(w, "Invalid file or 'pdf' field missing", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	compressOpts, err := compressLevel(r, "compress")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ocr := pdf.NewOCR(r.FormValue("ocr_lang"))
+	if ocr.Language == "" {
+		ocr.Language = h.Cfg.OCRLanguage
+	}
+	ocr.Deskew = formBool(r, "deskew")
+	ocr.Force = formBool(r, "force")
+	ocr.Extractor = h.Extractor
+
+	if err := pdf.CheckOCRLanguage(r.Context(), ocr.Language); err != nil {
+		writeOCRLanguageError(w, r, err)
+		return
+	}
+
+	stamp := time.Now().Unix()
+	tempInput := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("ocr_in_%d_%s", stamp, handler.Filename))
+	defer os.Remove(tempInput)
+	if err := saveUpload(file, tempInput); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	// A text layer breaks the signatures before compression runs, so they
+	// are counted on the upload
+	var signatures int
+	if compressOpts != nil {
+		signatures, err = pdf.CountSignatures(r.Context(), tempInput)
+		if pdf.IsAborted(err) {
+			return
+		}
+		compressOpts.Force = formBool(r, "force_signed")
+	}
+
+	outputPath := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("ocr_%d_%s", stamp, handler.Filename))
+	result, err := ocr.Run(r.Context(), tempInput, outputPath)
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "OCR failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	notes := []string{describeOCRResult(result)}
+	if compressOpts != nil {
+		// Without new text the upload was copied as it is and its
+		// signatures are still valid
+		if len(result.OCRPages) > 0 {
+			compressOpts.SourceSignatures = signatures
+		}
+		report, err := h.compressInPlace(r.Context(), outputPath, *compressOpts)
+		if pdf.IsAborted(err) {
+			return
+		}
+		if errors.Is(err, pdf.ErrSigned) {
+			os.Remove(outputPath)
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Compression failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		notes = append(notes, describeCompression(report))
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M21 21l-6-6m2-5a7 7 0 11-14 0 7 7 0 0114 0z"></path></svg>
+				<span class="font-bold text-lg">Searchable PDF ready!</span>
+			</div>
+
+			<p class="mb-4 text-xs">%s</p>
+
+			<a href="/download/%s" 
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download .pdf
+			</a>
+		</div>
+	`, strings.Join(notes, " "), filepath.Base(outputPath))
+
+	w.Write([]byte(page))
+}
+
+// writeOCRLanguageError answers a failed pdf.CheckOCRLanguage. A language
+// the client asked for is its mistake; a missing tesseract or default
+// language is the server's.
+func writeOCRLanguageError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, pdf.ErrInvalidOCRLanguage) || (errors.Is(err, pdf.ErrOCRLanguageMissing) && r.FormValue("ocr_lang") != "") {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, "OCR is not available: "+err.Error(), http.StatusServiceUnavailable)
+}
+
+func describeOCRResult(res *pdf.OCRResult) string {
+	switch {
+	case len(res.OCRPages) == 0:
+		return fmt.Sprintf("All %d pages already have a text layer, nothing was changed.", res.PageCount)
+	case len(res.SkippedPages) == 0:
+		return fmt.Sprintf("Text layer added to all %d pages.", res.PageCount)
+	default:
+		return fmt.Sprintf("Text layer added to %d of %d pages, the others already had text.", len(res.OCRPages), res.PageCount)
+	}
+}
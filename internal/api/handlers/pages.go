@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// Pages rotates, deletes, moves and extracts pages of the uploaded PDF
+// without re-rendering it. "ops" is a JSON array of pdf.PageOp, e.g.
+// [{"op":"delete","pages":"7-9"},{"op":"move","pages":"7","to":1}], or the
+// same operations as text ("delete 7-9; move 7 to 1").
+func (h *Handler) Pages(w http.ResponseWriter, r *http.Request) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	file, handler, err := r.FormFile("pdf")
+	if err != nil {
+		http.Error(w, "Invalid file or 'pdf' field missing", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	ops, err := pageOpsFromForm(r.FormValue("ops"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stamp := time.Now().Unix()
+	tempInput := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("pages_in_%d_%s", stamp, handler.Filename))
+	defer os.Remove(tempInput)
+	if err := saveUpload(file, tempInput); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	outputPath := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("edited_%d_%s", stamp, handler.Filename))
+	result, err := pdf.EditPages(r.Context(), tempInput, outputPath, ops)
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Editing pages failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 5a1 1 0 011-1h4a1 1 0 011 1v4a1 1 0 01-1 1H5a1 1 0 01-1-1V5zm10 0a1 1 0 011-1h4a1 1 0 011 1v4a1 1 0 01-1 1h-4a1 1 0 01-1-1V5zM4 15a1 1 0 011-1h4a1 1 0 011 1v4a1 1 0 01-1 1H5a1 1 0 01-1-1v-4zm10 0a1 1 0 011-1h4a1 1 0 011 1v4a1 1 0 01-1 1h-4a1 1 0 01-1-1v-4z"></path></svg>
+				<span class="font-bold text-lg">Pages updated!</span>
+			</div>
+
+			<p class="mb-4 text-xs">The new document has %d pages.</p>
+
+			<a href="/download/%s" 
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download .pdf
+			</a>
+		</div>
+	`, len(result.Pages), filepath.Base(outputPath))
+
+	w.Write([]byte(page))
+}
+
+// pageOpsFromForm reads the "ops" field as JSON or, when it is not an
+// array, as text operations.
+func pageOpsFromForm(v string) ([]pdf.PageOp, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, fmt.Errorf("no page operations given")
+	}
+	if !strings.HasPrefix(v, "[") {
+		return pdf.ParsePageOps(v)
+	}
+
+	var ops []pdf.PageOp
+	if err := json.Unmarshal([]byte(v), &ops); err != nil {
+		return nil, fmt.Errorf("invalid ops: %v", err)
+	}
+	return ops, nil
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// thumbnailSize is the longest side of a /thumbnail image in pixels.
+const thumbnailSize = 240
+
+var previewIDPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// newRenderer returns a Renderer limited to RENDER_WORKERS parallel pages.
+func (h *Handler) newRenderer() *pdf.Renderer {
+	renderer := pdf.NewRenderer()
+	renderer.Workers = h.Cfg.RenderWorkers
+	return renderer
+}
+
+// Render rasterizes the uploaded PDF and returns the images as a ZIP.
+// Fields: format (png, jpeg, webp), dpi, pages (e.g. 1-3,5; empty for all),
+// max_width, max_height (pixels) and quality (jpeg/webp).
+func (h *Handler) Render(w http.ResponseWriter, r *http.Request) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	file, handler, err := r.FormFile("pdf")
+	if err != nil {
+		http.Error(w, "Invalid file or 'pdf' field missing", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	renderer := h.newRenderer()
+	if renderer.Format, err = pdf.ParseImageFormat(r.FormValue("format")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for field, dst := range map[string]*int{
+		"dpi":        &renderer.DPI,
+		"max_width":  &renderer.MaxWidth,
+		"max_height": &renderer.MaxHeight,
+		"quality":    &renderer.Quality,
+	} {
+		if v := r.FormValue(field); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, fmt.Sprintf("invalid %s: %q", field, v), http.StatusBadRequest)
+				return
+			}
+			*dst = n
+		}
+	}
+
+	stamp := time.Now().Unix()
+	workDir, err := os.MkdirTemp(h.Cfg.UploadDir, "render_")
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	// The images are named after the input, so keep the uploaded name
+	tempInput := filepath.Join(workDir, filepath.Base(handler.Filename))
+	if err := saveUpload(file, tempInput); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	imagesDir := filepath.Join(workDir, "images")
+	if err := os.Mkdir(imagesDir, 0755); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	images, err := renderer.Render(r.Context(), tempInput, imagesDir, r.FormValue("pages"))
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Rendering failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	results := make([]processingResult, len(images))
+	for i, img := range images {
+		results[i] = processingResult{compressedPath: img.Path, filename: filepath.Base(img.Path)}
+	}
+
+	zipName := fmt.Sprintf("images_%d.zip", stamp)
+	if err := createZip(filepath.Join(h.Cfg.UploadDir, zipName), results); err != nil {
+		http.Error(w, "Failed to create zip", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 16l4.586-4.586a2 2 0 012.828 0L16 16m-2-2l1.586-1.586a2 2 0 012.828 0L20 14m-6-6h.01M6 20h12a2 2 0 002-2V6a2 2 0 00-2-2H6a2 2 0 00-2 2v12a2 2 0 002 2z"></path></svg>
+				<span class="font-bold text-lg">%d pages rendered!</span>
+			</div>
+
+			<a href="/download/%s" 
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download .zip
+			</a>
+		</div>
+	`, len(images), zipName)
+
+	w.Write([]byte(page))
+}
+
+// Preview stores the uploaded PDF for /thumbnail and answers with
+// {"id": "...", "pages": N}. Previews are removed by the cleanup cron.
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("pdf")
+	if err != nil {
+		http.Error(w, "Invalid file or 'pdf' field missing", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	var raw [8]byte
+	rand.Read(raw[:])
+	id := hex.EncodeToString(raw[:])
+
+	path := h.previewPath(id)
+	if err := saveUpload(file, path); err != nil {
+		os.Remove(path)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	pages, err := pdf.PageCount(r.Context(), path)
+	if err != nil {
+		os.Remove(path)
+		http.Error(w, "Invalid PDF: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"id": id, "pages": pages})
+}
+
+// Thumbnail serves a small PNG of one page of a PDF stored by Preview.
+// Thumbnails are cached next to the preview.
+func (h *Handler) Thumbnail(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	page, err := strconv.Atoi(chi.URLParam(r, "page"))
+	if !previewIDPattern.MatchString(id) || err != nil || page < 1 {
+		http.Error(w, "Invalid thumbnail", http.StatusBadRequest)
+		return
+	}
+
+	source := h.previewPath(id)
+	if _, err := os.Stat(source); err != nil {
+		http.Error(w, "Preview not found or expired", http.StatusNotFound)
+		return
+	}
+
+	thumb := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("thumb_%s_%d.png", id, page))
+	if _, err := os.Stat(thumb); err != nil {
+		renderer := h.newRenderer()
+		renderer.DPI = 72
+		renderer.MaxWidth = thumbnailSize
+		renderer.MaxHeight = thumbnailSize
+
+		// Render under a private name so parallel requests never serve a
+		// half written file
+		tmp := fmt.Sprintf("%s.%d.tmp", thumb, time.Now().UnixNano())
+		err := renderer.RenderPage(r.Context(), source, page, tmp)
+		if pdf.IsAborted(err) {
+			os.Remove(tmp)
+			return
+		}
+		if err != nil {
+			os.Remove(tmp)
+			http.Error(w, "Rendering failed: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if err := os.Rename(tmp, thumb); err != nil {
+			os.Remove(tmp)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, max-age=600")
+	http.ServeFile(w, r, thumb)
+}
+
+func (h *Handler) previewPath(id string) string {
+	return filepath.Join(h.Cfg.UploadDir, "preview_"+id+".pdf")
+}
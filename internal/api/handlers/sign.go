@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// Sign adds a PAdES signature with the server's identity (SIGN_CERT) to the
+// uploaded PDF. Fields: reason, location, contact, visible (draw a box with
+// the signer and the date), page (1 is the first, empty the last) and
+// position.
+func (h *Handler) Sign(w http.ResponseWriter, r *http.Request) {
+	if h.Signer == nil {
+		msg := "Signing is not configured"
+		if h.SignerErr != nil {
+			msg += ": " + h.SignerErr.Error()
+		}
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+
+	workDir, input, ok := h.securityUpload(w, r, "sign_")
+	if !ok {
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	// A copy, the shared signer must not change between requests
+	signer := *h.Signer
+	signer.Reason = r.FormValue("reason")
+	signer.Location = r.FormValue("location")
+	signer.ContactInfo = r.FormValue("contact")
+	signer.Visible = formBool(r, "visible")
+	if v := r.FormValue("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			http.Error(w, fmt.Sprintf("invalid page: %q", v), http.StatusBadRequest)
+			return
+		}
+		signer.Page = page
+	}
+	var err error
+	if signer.Position, err = pdf.ParsePosition(r.FormValue("position"), h.Signer.Position); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	outputPath := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("signed_%d_%s", time.Now().Unix(), filepath.Base(input)))
+	res, err := signer.Sign(r.Context(), input, outputPath)
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Signing failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	notes := fmt.Sprintf("Signed by %s as %s", html.EscapeString(res.Signer), html.EscapeString(res.Field))
+	if signer.Visible {
+		notes += fmt.Sprintf(", visible on page %d", res.Page)
+	}
+	notes += ". Any later change to the file breaks the signature."
+	writeSecurityResult(w, "PDF signed!", notes, filepath.Base(outputPath))
+}
+
+// Verify lists the signatures of the uploaded PDF and whether each is
+// valid.
+func (h *Handler) Verify(w http.ResponseWriter, r *http.Request) {
+	workDir, input, ok := h.securityUpload(w, r, "verify_")
+	if !ok {
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	sigs, err := pdf.Verify(input)
+	if err != nil {
+		http.Error(w, "Verification failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if len(sigs) == 0 {
+		w.Write([]byte(`
+		<div class="p-4 bg-gray-50 border border-gray-300 text-gray-700 rounded fade-in">
+			<span class="font-bold">No signatures found.</span>
+		</div>
+	`))
+		return
+	}
+
+	var rows strings.Builder
+	for i, sig := range sigs {
+		status, color := "✅ Valid", "text-green-700"
+		switch {
+		case !sig.Valid:
+			status, color = "❌ Invalid", "text-red-700"
+		case !sig.Trusted:
+			status, color = "⚠️ Valid, untrusted certificate", "text-yellow-700"
+		}
+
+		var details []string
+		if !sig.SignedAt.IsZero() {
+			details = append(details, "signed "+sig.SignedAt.Format("2006-01-02 15:04 MST"))
+		}
+		if sig.Issuer != "" {
+			details = append(details, "issued by "+sig.Issuer)
+		}
+		if sig.Reason != "" {
+			details = append(details, "reason: "+sig.Reason)
+		}
+		if sig.Location != "" {
+			details = append(details, "location: "+sig.Location)
+		}
+		if sig.Valid && !sig.WholeDocument {
+			details = append(details, "the file was changed after this signature")
+		}
+		if sig.Problem != "" {
+			details = append(details, sig.Problem)
+		}
+
+		signer := sig.Signer
+		if signer == "" {
+			signer = "unknown signer"
+		}
+		fmt.Fprintf(&rows, `
+			<li class="py-2">
+				<span class="font-medium">%d. %s</span> <span class="%s">%s</span>
+				<p class="text-xs text-gray-600">%s</p>
+			</li>`, i+1, html.EscapeString(signer), color, status, html.EscapeString(strings.Join(details, "; ")))
+	}
+
+	fmt.Fprintf(w, `
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<span class="font-bold text-lg">%d signature(s)</span>
+			<ul class="divide-y divide-blue-200 text-sm">%s
+			</ul>
+		</div>
+	`, len(sigs), rows.String())
+}
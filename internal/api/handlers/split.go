@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// Split cuts the uploaded PDF and returns the parts as a ZIP. "mode" is
+// ranges (with "ranges", e.g. 1-3,5,8-), every (with "every", pages per
+// file) or bookmarks.
+func (h *Handler) Split(w http.ResponseWriter, r *http.Request) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	file, handler, err := r.FormFile("pdf")
+	if err != nil {
+		http.Error(w, "Invalid file or 'pdf' field missing", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	splitter := &pdf.Splitter{Ranges: r.FormValue("ranges")}
+	if splitter.Mode, err = pdf.ParseSplitMode(r.FormValue("mode")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if splitter.Mode == pdf.SplitEvery {
+		if splitter.Every, err = strconv.Atoi(r.FormValue("every")); err != nil {
+			http.Error(w, "invalid every: "+strconv.Quote(r.FormValue("every")), http.StatusBadRequest)
+			return
+		}
+	}
+
+	stamp := time.Now().Unix()
+	workDir, err := os.MkdirTemp(h.Cfg.UploadDir, "split_")
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	// The parts are named after the input, so keep the uploaded name
+	tempInput := filepath.Join(workDir, filepath.Base(handler.Filename))
+	if err := saveUpload(file, tempInput); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	partsDir := filepath.Join(workDir, "parts")
+	if err := os.Mkdir(partsDir, 0755); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	parts, err := splitter.Split(r.Context(), tempInput, partsDir)
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Split failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	results := make([]processingResult, len(parts))
+	for i, part := range parts {
+		results[i] = processingResult{compressedPath: part.Path, filename: filepath.Base(part.Path)}
+	}
+
+	zipName := fmt.Sprintf("split_%d.zip", stamp)
+	if err := createZip(filepath.Join(h.Cfg.UploadDir, zipName), results); err != nil {
+		http.Error(w, "Failed to create zip", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M14.121 14.121L19 19m-7-7l7-7m-7 7l-2.879 2.879M12 12L9.121 9.121m0 5.758a3 3 0 10-4.243 4.243 3 3 0 004.243-4.243zm0-5.758a3 3 0 10-4.243-4.243 3 3 0 004.243 4.243z"></path></svg>
+				<span class="font-bold text-lg">PDF split into %d files!</span>
+			</div>
+
+			<a href="/download/%s" 
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download .zip
+			</a>
+		</div>
+	`, len(parts), zipName)
+
+	w.Write([]byte(page))
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// Stamp writes page numbers, Bates numbers or a header/footer line on the
+// uploaded PDFs. The Bates counter runs on across all files of the request,
+// in the order of "order" (as for /merge). Fields: template (with {page},
+// {total}, {filename}, {date}, {bates}), position, font, font_size, color,
+// margin_x, margin_y (mm), pages, bates_prefix, bates_digits, bates_start
+// and date (YYYY-MM-DD, default today).
+func (h *Handler) Stamp(w http.ResponseWriter, r *http.Request) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "File too large or invalid form", http.StatusBadRequest)
+		return
+	}
+
+	if r.MultipartForm == nil || r.MultipartForm.File == nil {
+		http.Error(w, "No files uploaded", http.StatusBadRequest)
+		return
+	}
+
+	files := r.MultipartForm.File["pdf"]
+	if len(files) == 0 {
+		http.Error(w, "No files uploaded", http.StatusBadRequest)
+		return
+	}
+
+	order, err := parseOrder(r.FormValue("order"), len(files))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stamper, err := stamperFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stamp := time.Now().Unix()
+	workDir, err := os.MkdirTemp(h.Cfg.UploadDir, "stamp_")
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	// Files are stamped one after the other so the Bates numbers follow
+	// the order
+	var results []processingResult
+	var firstBates, lastBates string
+	for _, idx := range order {
+		fh := files[idx]
+		src, err := fh.Open()
+		if err != nil {
+			http.Error(w, "Invalid file "+fh.Filename, http.StatusBadRequest)
+			return
+		}
+
+		// {filename} is the uploaded name, so keep it
+		dir := filepath.Join(workDir, strconv.Itoa(idx))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			src.Close()
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+		input := filepath.Join(dir, filepath.Base(fh.Filename))
+		err = saveUpload(src, input)
+		src.Close()
+		if err != nil {
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+
+		output := filepath.Join(dir, "stamped.pdf")
+		res, err := stamper.Stamp(r.Context(), input, output)
+		if pdf.IsAborted(err) {
+			// middleware.Timeout answers with 504 once the handler returns.
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Stamping %s failed: %v", fh.Filename, err), http.StatusUnprocessableEntity)
+			return
+		}
+		if firstBates == "" {
+			firstBates = res.FirstBates
+		}
+		lastBates = res.LastBates
+		results = append(results, processingResult{compressedPath: output, filename: filepath.Base(fh.Filename)})
+	}
+
+	var downloadName, label string
+	if len(results) == 1 {
+		downloadName = fmt.Sprintf("stamped_%d_%s", stamp, results[0].filename)
+		label = "⬇️ Download .pdf"
+		if err := os.Rename(results[0].compressedPath, filepath.Join(h.Cfg.UploadDir, downloadName)); err != nil {
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		downloadName = fmt.Sprintf("stamped_%d.zip", stamp)
+		label = "⬇️ Download .zip"
+		if err := createZip(filepath.Join(h.Cfg.UploadDir, downloadName), results); err != nil {
+			http.Error(w, "Failed to create zip", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	notes := "1 file stamped."
+	if len(results) > 1 {
+		notes = fmt.Sprintf("%d files stamped.", len(results))
+	}
+	if firstBates != "" {
+		notes += fmt.Sprintf(" Bates numbers %s – %s.", html.EscapeString(firstBates), html.EscapeString(lastBates))
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M7 20l4-16m2 16l4-16M6 9h14M4 15h14"></path></svg>
+				<span class="font-bold text-lg">%s</span>
+			</div>
+
+			<p class="mb-4 text-xs">%s</p>
+
+			<a href="/download/%s"
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   %s
+			</a>
+		</div>
+	`, stampTitle(stamper.Template), notes, downloadName, label)
+
+	w.Write([]byte(page))
+}
+
+// stampTitle names what the template put on the pages.
+func stampTitle(template string) string {
+	switch {
+	case strings.Contains(template, "{bates}"):
+		return "Bates numbers added!"
+	case strings.Contains(template, "{page}"):
+		return "Pages numbered!"
+	default:
+		return "Header/footer added!"
+	}
+}
+
+func stamperFromForm(r *http.Request) (*pdf.Stamper, error) {
+	stamper := pdf.NewStamper()
+	if v := r.FormValue("template"); v != "" {
+		stamper.Template = v
+	}
+	stamper.Font = r.FormValue("font")
+	stamper.Pages = r.FormValue("pages")
+	stamper.BatesPrefix = r.FormValue("bates_prefix")
+	if v := r.FormValue("color"); v != "" {
+		stamper.Color = v
+	}
+
+	var err error
+	if stamper.Position, err = pdf.ParsePosition(r.FormValue("position"), pdf.PositionBottom); err != nil {
+		return nil, err
+	}
+	if v := r.FormValue("date"); v != "" {
+		if stamper.Date, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, fmt.Errorf("invalid date %q (use YYYY-MM-DD)", v)
+		}
+	}
+
+	for field, dst := range map[string]*float64{
+		"font_size": &stamper.FontSize,
+		"margin_x":  &stamper.MarginX,
+		"margin_y":  &stamper.MarginY,
+	} {
+		if v := r.FormValue(field); v != "" {
+			if *dst, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("invalid %s: %q", field, v)
+			}
+		}
+	}
+	for field, dst := range map[string]*int{
+		"bates_digits": &stamper.BatesDigits,
+		"bates_start":  &stamper.BatesStart,
+	} {
+		if v := r.FormValue(field); v != "" {
+			if *dst, err = strconv.Atoi(strings.TrimSpace(v)); err != nil {
+				return nil, fmt.Errorf("invalid %s: %q", field, v)
+			}
+		}
+	}
+	return stamper, nil
+}
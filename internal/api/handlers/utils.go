@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 )
 
@@ -13,9 +14,32 @@ type processingResult struct {
 	originalSize   int64
 	finalSize      int64
 	filename       string
+	note           string
 	err            error
 }
 
+// formBool reads a checkbox: "true", "on" or "1" mean checked.
+func formBool(r *http.Request, field string) bool {
+	switch r.FormValue(field) {
+	case "true", "on", "1":
+		return true
+	}
+	return false
+}
+
+// saveUpload copies an uploaded file to path.
+func saveUpload(src io.Reader, path string) error {
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
 func formatSize(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vpramatarov/pdf-tools/internal/pdf"
+)
+
+// watermarkPreviewSize is the longest side of the /watermark/preview image
+// in pixels.
+const watermarkPreviewSize = 600
+
+// Watermark stamps text or an image on the pages of the uploaded PDF.
+// Fields: text, font, font_size, color (#rrggbb) or image (PNG, GIF or
+// JPEG) with scale (fraction of the page width); opacity (0-1), rotation
+// (degrees), position (center, top-left, ...), margin (mm), pages (e.g.
+// 1-3,5; empty for all) and layer (over or under).
+func (h *Handler) Watermark(w http.ResponseWriter, r *http.Request) {
+	workDir, input, wm, ok := h.watermarkUpload(w, r)
+	if !ok {
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	outputPath := filepath.Join(h.Cfg.UploadDir, fmt.Sprintf("watermarked_%d_%s", time.Now().Unix(), filepath.Base(input)))
+	result, err := wm.Apply(r.Context(), input, outputPath)
+	if pdf.IsAborted(err) {
+		// middleware.Timeout answers with 504 once the handler returns.
+		return
+	}
+	if err != nil {
+		http.Error(w, "Watermarking failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	page := fmt.Sprintf(`
+		<div class="p-4 bg-blue-100 border border-blue-400 text-blue-700 rounded fade-in">
+			<div class="flex items-center mb-2">
+				<svg class="w-6 h-6 mr-2" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M7 21a4 4 0 01-4-4V5a2 2 0 012-2h4a2 2 0 012 2v12a4 4 0 01-4 4zm0 0h12a2 2 0 002-2v-4a2 2 0 00-2-2h-2.343M11 7.343l1.657-1.657a2 2 0 012.828 0l2.829 2.829a2 2 0 010 2.828l-8.486 8.485M7 17h.01"></path></svg>
+				<span class="font-bold text-lg">Watermark added!</span>
+			</div>
+
+			<p class="mb-4 text-xs">%d pages stamped.</p>
+
+			<a href="/download/%s"
+			   class="block w-full text-center text-white bg-blue-600 hover:bg-blue-700 focus:ring-4 focus:ring-blue-300 font-medium rounded-lg text-sm px-5 py-2.5">
+			   ⬇️ Download .pdf
+			</a>
+		</div>
+	`, len(result.Pages), filepath.Base(outputPath))
+
+	w.Write([]byte(page))
+}
+
+// WatermarkPreview takes the same form as Watermark and answers with an
+// image of the first selected page, watermarked.
+func (h *Handler) WatermarkPreview(w http.ResponseWriter, r *http.Request) {
+	workDir, input, wm, ok := h.watermarkUpload(w, r)
+	if !ok {
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	previewPDF := filepath.Join(workDir, "preview.pdf")
+	page, err := wm.Preview(r.Context(), input, previewPDF)
+	if pdf.IsAborted(err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, "Watermarking failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	renderer := h.newRenderer()
+	renderer.DPI = 72
+	renderer.MaxWidth = watermarkPreviewSize
+	renderer.MaxHeight = watermarkPreviewSize
+	previewPNG := filepath.Join(workDir, "preview.png")
+	err = renderer.RenderPage(r.Context(), previewPDF, 1, previewPNG)
+	if pdf.IsAborted(err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, "Rendering failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	data, err := os.ReadFile(previewPNG)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, `
+		<div class="p-4 bg-gray-50 border border-gray-300 rounded fade-in text-center">
+			<p class="mb-2 text-xs text-gray-500">Preview of page %d</p>
+			<img src="data:image/png;base64,%s" alt="Watermark preview" class="mx-auto border border-gray-200 shadow-sm">
+		</div>
+	`, page, base64.StdEncoding.EncodeToString(data))
+}
+
+// watermarkUpload parses the form shared by Watermark and WatermarkPreview
+// and stores the uploads in a new work directory. On failure it has
+// answered the request and returns ok == false.
+func (h *Handler) watermarkUpload(w http.ResponseWriter, r *http.Request) (workDir, input string, wm *pdf.Watermarker, ok bool) {
+	// Calculate the limit in bytes: MB * 1024 * 1024
+	maxBytes := h.Cfg.MaxUploadSizeMB << 20 // bytes shifting << 20
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return "", "", nil, false
+	}
+
+	file, handler, err := r.FormFile("pdf")
+	if err != nil {
+		http.Error(w, "Invalid file or 'pdf' field missing", http.StatusBadRequest)
+		return "", "", nil, false
+	}
+	defer file.Close()
+
+	wm, err = watermarkerFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", "", nil, false
+	}
+
+	workDir, err = os.MkdirTemp(h.Cfg.UploadDir, "watermark_")
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return "", "", nil, false
+	}
+
+	input = filepath.Join(workDir, filepath.Base(handler.Filename))
+	if err := saveUpload(file, input); err != nil {
+		os.RemoveAll(workDir)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return "", "", nil, false
+	}
+
+	if img, imgHandler, err := r.FormFile("image"); err == nil {
+		defer img.Close()
+		wm.Image = filepath.Join(workDir, "image_"+filepath.Base(imgHandler.Filename))
+		if err := saveUpload(img, wm.Image); err != nil {
+			os.RemoveAll(workDir)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return "", "", nil, false
+		}
+	}
+	return workDir, input, wm, true
+}
+
+func watermarkerFromForm(r *http.Request) (*pdf.Watermarker, error) {
+	wm := pdf.NewWatermarker()
+	wm.Text = r.FormValue("text")
+	wm.Font = r.FormValue("font")
+	wm.Pages = r.FormValue("pages")
+	if v := r.FormValue("color"); v != "" {
+		wm.Color = v
+	}
+
+	var err error
+	if wm.Position, err = pdf.ParsePosition(r.FormValue("position"), pdf.PositionCenter); err != nil {
+		return nil, err
+	}
+	switch layer := strings.ToLower(r.FormValue("layer")); layer {
+	case "", "over":
+	case "under":
+		wm.Underlay = true
+	default:
+		return nil, fmt.Errorf("unknown layer %q (use over or under)", layer)
+	}
+
+	for field, dst := range map[string]*float64{
+		"font_size": &wm.FontSize,
+		"opacity":   &wm.Opacity,
+		"rotation":  &wm.Rotation,
+		"scale":     &wm.Scale,
+		"margin":    &wm.Margin,
+	} {
+		if v := r.FormValue(field); v != "" {
+			if *dst, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("invalid %s: %q", field, v)
+			}
+		}
+	}
+	return wm, nil
+}
@@ -28,6 +28,28 @@ func New(h *handlers.Handler) *chi.Mux {
 	r.Post("/compress", h.Compress)
 	r.Get("/download/{filename}", h.Download)
 	r.Post("/convert-word", h.ConvertToWord)
+	r.Post("/convert-markdown", h.ConvertToMarkdown)
+	r.Post("/convert-html", h.ConvertToHTML)
+	r.Post("/convert-text", h.ConvertToText)
+	r.Post("/ocr", h.OCR)
+	r.Post("/merge", h.Merge)
+	r.Post("/split", h.Split)
+	r.Post("/pages", h.Pages)
+	r.Post("/render", h.Render)
+	r.Post("/images-to-pdf", h.ImagesToPDF)
+	r.Post("/convert-to-pdf", h.ConvertToPDF)
+	r.Post("/watermark", h.Watermark)
+	r.Post("/watermark/preview", h.WatermarkPreview)
+	r.Post("/stamp", h.Stamp)
+	r.Post("/encrypt", h.Encrypt)
+	r.Post("/decrypt", h.Decrypt)
+	r.Post("/sign", h.Sign)
+	r.Post("/verify", h.Verify)
+	r.Post("/metadata", h.EditMetadata)
+	r.Get("/metadata/{filename}", h.ReadMetadata)
+	r.Post("/preview", h.Preview)
+	r.Get("/thumbnail/{id}/{page}", h.Thumbnail)
+	r.Get("/capabilities", h.Capabilities)
 
 	return r
 }
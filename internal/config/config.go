@@ -13,6 +13,18 @@ type Config struct {
 	MaxUploadSizeMB        int64
 	CleanupIntervalMinutes int
 	UploadDir              string
+	CompressPipeline       string
+	TextExtractor          string
+	PythonBin              string
+	TextFilters            string
+	FilterProfilesDir      string
+	OCRLanguage            string
+	RenderWorkers          int
+	OfficeMaxJobs          int
+	OfficeTimeoutSeconds   int
+	SignCert               string
+	SignKey                string
+	SignPassword           string
 }
 
 func Load() *Config {
@@ -25,6 +37,18 @@ func Load() *Config {
 		MaxUploadSizeMB:        getEnvAsInt64("MAX_FILE_UPLOAD_SIZE", 50),
 		CleanupIntervalMinutes: getEnvAsInt("CLEANUP_CRON_INTERVAL", 10),
 		UploadDir:              getEnv("UPLOAD_DIR", "./uploads"),
+		CompressPipeline:       getEnv("COMPRESS_PIPELINE", "gs,qpdf"),
+		TextExtractor:          getEnv("TEXT_EXTRACTOR", ""),
+		PythonBin:              getEnv("PYTHON_BIN", "python3"),
+		TextFilters:            getEnv("TEXT_FILTERS", ""),
+		FilterProfilesDir:      getEnv("FILTER_PROFILES_DIR", ""),
+		OCRLanguage:            getEnv("OCR_LANGUAGE", "eng"),
+		RenderWorkers:          getEnvAsInt("RENDER_WORKERS", 0),
+		OfficeMaxJobs:          getEnvAsInt("OFFICE_MAX_JOBS", 2),
+		OfficeTimeoutSeconds:   getEnvAsInt("OFFICE_TIMEOUT", 90),
+		SignCert:               getEnv("SIGN_CERT", ""),
+		SignKey:                getEnv("SIGN_KEY", ""),
+		SignPassword:           getEnv("SIGN_PASSWORD", ""),
 	}
 }
 
@@ -0,0 +1,226 @@
+// Package docx writes minimal Office Open XML word processing documents:
+// paragraphs of formatted text runs, inline images and simple tables. It only covers
+// what the PDF converters need and has no dependencies outside the standard
+// library.
+package docx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Run is a piece of text with uniform formatting.
+type Run struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Size   float64 // font size in points, 0 keeps the style default
+}
+
+// Paragraph is a block of runs.
+type Paragraph struct {
+	Runs []Run
+	// Style is a paragraph style id from styles.xml, e.g. "Heading1".
+	// Empty means "Normal".
+	Style string
+	// SpaceAfter is the spacing below the paragraph in points. A negative
+	// value keeps the style default.
+	SpaceAfter float64
+}
+
+// element is anything that can appear in the document body.
+type element interface {
+	writeXML(b *strings.Builder)
+}
+
+// Document collects the body of a DOCX file.
+type Document struct {
+	body   []element
+	images []*Image
+}
+
+// New returns an empty document.
+func New() *Document {
+	return &Document{}
+}
+
+// AddParagraph appends p to the body.
+func (d *Document) AddParagraph(p Paragraph) {
+	d.body = append(d.body, p)
+}
+
+func (p Paragraph) writeXML(b *strings.Builder) {
+	writeParagraph(b, p)
+}
+
+// AddText appends a paragraph with a single run.
+func (d *Document) AddText(text string, bold bool, size float64, spaceAfter float64) {
+	d.AddParagraph(Paragraph{
+		Runs:       []Run{{Text: text, Bold: bold, Size: size}},
+		SpaceAfter: spaceAfter,
+	})
+}
+
+// Len returns the number of body elements: paragraphs, images and tables.
+func (d *Document) Len() int {
+	return len(d.body)
+}
+
+// Save writes the document to path.
+func (d *Document) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Write(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes the document as a DOCX (zip) stream.
+func (d *Document) Write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"word/_rels/document.xml.rels", d.documentRelsXML()},
+		{"word/styles.xml", stylesXML},
+		{"word/document.xml", d.documentXML()},
+	}
+	for _, img := range d.images {
+		parts = append(parts, struct {
+			name    string
+			content string
+		}{"word/" + img.target(), string(img.Data)})
+	}
+
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func (d *Document) documentXML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`)
+	b.WriteString(` xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`)
+	b.WriteString(` xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing">`)
+	b.WriteString(`<w:body>`)
+
+	for _, el := range d.body {
+		el.writeXML(&b)
+	}
+	// Word expects a paragraph between a table and the section properties
+	if len(d.body) > 0 {
+		if _, ok := d.body[len(d.body)-1].(*Table); ok {
+			b.WriteString(`<w:p/>`)
+		}
+	}
+
+	// A4 portrait with 2.5 cm margins, the python-docx default was Letter
+	fmt.Fprintf(&b, `<w:sectPr><w:pgSz w:w="%d" w:h="%d"/>`, pageWidth, pageHeight)
+	fmt.Fprintf(&b, `<w:pgMar w:top="%[1]d" w:right="%[1]d" w:bottom="%[1]d" w:left="%[1]d" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>`, pageMargin)
+	b.WriteString(`</w:body></w:document>`)
+	return b.String()
+}
+
+func (d *Document) documentRelsXML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	b.WriteString(`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`)
+	for _, img := range d.images {
+		fmt.Fprintf(&b, `<Relationship Id="%s" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="%s"/>`, img.relID(), img.target())
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+func writeParagraph(b *strings.Builder, p Paragraph) {
+	b.WriteString(`<w:p>`)
+
+	if p.Style != "" || p.SpaceAfter >= 0 {
+		b.WriteString(`<w:pPr>`)
+		if p.Style != "" {
+			fmt.Fprintf(b, `<w:pStyle w:val="%s"/>`, escape(p.Style))
+		}
+		if p.SpaceAfter >= 0 {
+			// spacing is measured in twentieths of a point
+			fmt.Fprintf(b, `<w:spacing w:after="%d"/>`, int(p.SpaceAfter*20))
+		}
+		b.WriteString(`</w:pPr>`)
+	}
+
+	for _, r := range p.Runs {
+		writeRun(b, r)
+	}
+
+	b.WriteString(`</w:p>`)
+}
+
+func writeRun(b *strings.Builder, r Run) {
+	b.WriteString(`<w:r>`)
+
+	if r.Bold || r.Italic || r.Size > 0 {
+		b.WriteString(`<w:rPr>`)
+		if r.Bold {
+			b.WriteString(`<w:b/>`)
+		}
+		if r.Italic {
+			b.WriteString(`<w:i/>`)
+		}
+		if r.Size > 0 {
+			// font size is measured in half points
+			halfPoints := int(r.Size * 2)
+			fmt.Fprintf(b, `<w:sz w:val="%d"/><w:szCs w:val="%d"/>`, halfPoints, halfPoints)
+		}
+		b.WriteString(`</w:rPr>`)
+	}
+
+	// Line breaks inside a run become <w:br/>
+	for i, line := range strings.Split(r.Text, "\n") {
+		if i > 0 {
+			b.WriteString(`<w:br/>`)
+		}
+		fmt.Fprintf(b, `<w:t xml:space="preserve">%s</w:t>`, escape(line))
+	}
+
+	b.WriteString(`</w:r>`)
+}
+
+// escape XML-escapes s and drops the control characters XML 1.0 forbids.
+func escape(s string) string {
+	clean := strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		if r == 0xFFFE || r == 0xFFFF {
+			return -1
+		}
+		return r
+	}, s)
+
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(clean))
+	return b.String()
+}
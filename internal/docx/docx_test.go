@@ -0,0 +1,113 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readParts(t *testing.T, doc *Document) map[string]string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("output is not a zip archive: %v", err)
+	}
+
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(data)
+	}
+	return parts
+}
+
+func TestDocument_Write(t *testing.T) {
+	doc := New()
+	doc.AddText("ЗАГЛАВИЕ", true, 12, 12)
+	doc.AddText("Tom & Jerry <3\x07", false, 11, 6)
+
+	parts := readParts(t, doc)
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/styles.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+
+	body := parts["word/document.xml"]
+	for _, want := range []string{
+		`<w:b/><w:sz w:val="24"/>`,
+		`<w:spacing w:after="240"/>`,
+		`ЗАГЛАВИЕ`,
+		`Tom &amp; Jerry &lt;3</w:t>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("document.xml does not contain %q", want)
+		}
+	}
+}
+
+func TestDocument_ImagesAndTables(t *testing.T) {
+	imgPath := filepath.Join(t.TempDir(), "chart.png")
+	f, err := os.Create(imgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, image.NewRGBA(image.Rect(0, 0, 4, 3)))
+	f.Close()
+
+	doc := New()
+	doc.AddText("Before", false, 0, -1)
+	// Wider than the text area, scaled down to 453.6 pt
+	if err := doc.AddImage(imgPath, 907.2, 100); err != nil {
+		t.Fatalf("AddImage: %v", err)
+	}
+	doc.AddTable([][]string{{"Name", "Qty"}, {"Apples", "3"}, {"Pears"}}, true)
+
+	if err := doc.AddImage(filepath.Join("testdata", "missing.png"), 10, 10); err == nil {
+		t.Error("AddImage of a missing file should fail")
+	}
+	if doc.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", doc.Len())
+	}
+
+	parts := readParts(t, doc)
+	if _, ok := parts["word/media/image1.png"]; !ok {
+		t.Error("missing part word/media/image1.png")
+	}
+	if rels := parts["word/_rels/document.xml.rels"]; !strings.Contains(rels, `Id="rId2"`) || !strings.Contains(rels, `Target="media/image1.png"`) {
+		t.Errorf("image relationship missing: %s", rels)
+	}
+
+	body := parts["word/document.xml"]
+	for _, want := range []string{
+		`<wp:extent cx="5760720" cy="635000"/>`,
+		`<a:blip r:embed="rId2"/>`,
+		`<w:tblHeader/>`,
+		`<w:gridCol w:w="4536"/><w:gridCol w:w="4536"/>`,
+		`<w:b/></w:rPr><w:t xml:space="preserve">Name</w:t>`,
+		`Pears</w:t></w:r></w:p></w:tc><w:tc><w:tcPr><w:tcW w:w="4536" w:type="dxa"/></w:tcPr><w:p>`,
+		`</w:tbl><w:p/><w:sectPr>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("document.xml does not contain %q", want)
+		}
+	}
+}
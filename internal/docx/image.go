@@ -0,0 +1,83 @@
+package docx
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// emuPerPoint converts points to the English Metric Units DrawingML uses.
+const emuPerPoint = 12700
+
+// Image is a picture placed inline in its own paragraph.
+type Image struct {
+	Data []byte
+	// Format is the file extension of Data: "png", "jpeg" or "gif".
+	Format string
+	// Width and Height are the displayed size in points.
+	Width, Height float64
+
+	id int // 1-based, unique within the document
+}
+
+// AddImage appends the PNG, JPEG or GIF file at path, displayed at width x
+// height points. Images wider or taller than the text area are scaled down
+// keeping their aspect ratio; a zero size uses the text width and a 4:3 box.
+func (d *Document) AddImage(path string, width, height float64) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var format string
+	switch http.DetectContentType(data) {
+	case "image/png":
+		format = "png"
+	case "image/jpeg":
+		format = "jpeg"
+	case "image/gif":
+		format = "gif"
+	default:
+		return fmt.Errorf("%s: unsupported image format", path)
+	}
+
+	maxWidth := float64(textWidth) / 20
+	maxHeight := float64(pageHeight-2*pageMargin) / 20
+	if width <= 0 || height <= 0 {
+		width, height = maxWidth, maxWidth*3/4
+	}
+	if scale := min(maxWidth/width, maxHeight/height); scale < 1 {
+		width, height = width*scale, height*scale
+	}
+
+	img := &Image{Data: data, Format: format, Width: width, Height: height, id: len(d.images) + 1}
+	d.images = append(d.images, img)
+	d.body = append(d.body, img)
+	return nil
+}
+
+// relID is the relationship id; rId1 is taken by the styles part.
+func (img *Image) relID() string {
+	return fmt.Sprintf("rId%d", img.id+1)
+}
+
+// target is the part name relative to word/.
+func (img *Image) target() string {
+	return fmt.Sprintf("media/image%d.%s", img.id, img.Format)
+}
+
+func (img *Image) writeXML(b *strings.Builder) {
+	cx, cy := int64(img.Width*emuPerPoint), int64(img.Height*emuPerPoint)
+
+	b.WriteString(`<w:p><w:r><w:drawing>`)
+	fmt.Fprintf(b, `<wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%d" cy="%d"/>`, cx, cy)
+	fmt.Fprintf(b, `<wp:docPr id="%d" name="Picture %d"/>`, img.id, img.id)
+	b.WriteString(`<a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`)
+	b.WriteString(`<a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`)
+	b.WriteString(`<pic:pic xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">`)
+	fmt.Fprintf(b, `<pic:nvPicPr><pic:cNvPr id="%d" name="image%d.%s"/><pic:cNvPicPr/></pic:nvPicPr>`, img.id, img.id, img.Format)
+	fmt.Fprintf(b, `<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`, img.relID())
+	fmt.Fprintf(b, `<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`, cx, cy)
+	b.WriteString(`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r></w:p>`)
+}
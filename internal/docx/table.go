@@ -0,0 +1,61 @@
+package docx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Table is a grid of plain text cells with single line borders. Columns
+// share the text width equally.
+type Table struct {
+	Rows [][]string
+	// Header makes the first row bold and repeats it on every page.
+	Header bool
+}
+
+// AddTable appends a table. Short rows are padded with empty cells.
+func (d *Document) AddTable(rows [][]string, header bool) {
+	d.body = append(d.body, &Table{Rows: rows, Header: header})
+}
+
+func (t *Table) writeXML(b *strings.Builder) {
+	cols := 0
+	for _, row := range t.Rows {
+		cols = max(cols, len(row))
+	}
+	if cols == 0 {
+		return
+	}
+	colWidth := textWidth / cols
+
+	b.WriteString(`<w:tbl><w:tblPr><w:tblW w:w="0" w:type="auto"/><w:tblBorders>`)
+	for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		fmt.Fprintf(b, `<w:%s w:val="single" w:sz="4" w:space="0" w:color="auto"/>`, side)
+	}
+	b.WriteString(`</w:tblBorders><w:tblLayout w:type="fixed"/></w:tblPr><w:tblGrid>`)
+	for range cols {
+		fmt.Fprintf(b, `<w:gridCol w:w="%d"/>`, colWidth)
+	}
+	b.WriteString(`</w:tblGrid>`)
+
+	for i, row := range t.Rows {
+		header := t.Header && i == 0
+		b.WriteString(`<w:tr>`)
+		if header {
+			b.WriteString(`<w:trPr><w:tblHeader/></w:trPr>`)
+		}
+		for c := range cols {
+			var text string
+			if c < len(row) {
+				text = row[c]
+			}
+			fmt.Fprintf(b, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/></w:tcPr>`, colWidth)
+			// Every cell needs a paragraph, even an empty one
+			writeParagraph(b, Paragraph{Runs: []Run{{Text: text, Bold: header}}, SpaceAfter: 0})
+			b.WriteString(`</w:tc>`)
+		}
+		b.WriteString(`</w:tr>`)
+	}
+
+	b.WriteString(`</w:tbl>`)
+}
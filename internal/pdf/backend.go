@@ -0,0 +1,145 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Backend is one stage of the compression pipeline. Run reads in and writes
+// out; it must not modify in.
+type Backend interface {
+	// Name identifies the backend in reports, config and /capabilities.
+	Name() string
+	// Binary is the executable the backend shells out to.
+	Binary() string
+	// Available reports whether Binary can be found on PATH.
+	Available() bool
+	Run(ctx context.Context, in string, out string, opts CompressOptions) error
+}
+
+// knownBackends maps pipeline names (and their short aliases) to backends.
+var knownBackends = map[string]Backend{
+	"ghostscript": GhostscriptBackend{},
+	"gs":          GhostscriptBackend{},
+	"qpdf":        QPDFBackend{},
+	"mutool":      MutoolBackend{},
+}
+
+// Backends returns every backend the tool knows about, available or not.
+func Backends() []Backend {
+	return []Backend{GhostscriptBackend{}, QPDFBackend{}, MutoolBackend{}}
+}
+
+// LookupBackend returns the backend registered under name.
+func LookupBackend(name string) (Backend, error) {
+	b, ok := knownBackends[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression backend %q", name)
+	}
+	return b, nil
+}
+
+// BackendInfo is a snapshot of one backend's availability.
+type BackendInfo struct {
+	Name      string `json:"name"`
+	Binary    string `json:"binary"`
+	Available bool   `json:"available"`
+	Path      string `json:"path,omitempty"`
+}
+
+// DetectBackends looks every known backend up on PATH.
+func DetectBackends() []BackendInfo {
+	var infos []BackendInfo
+	for _, b := range Backends() {
+		info := BackendInfo{Name: b.Name(), Binary: b.Binary()}
+		if path, err := exec.LookPath(b.Binary()); err == nil {
+			info.Available = true
+			info.Path = path
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Pipeline is an ordered list of backends. Required stages must succeed;
+// optional ones are best effort: when one fails or is missing, the previous
+// output is passed on and the report records it.
+type Pipeline struct {
+	stages []pipelineStage
+}
+
+type pipelineStage struct {
+	backend  Backend
+	optional bool
+}
+
+// DefaultPipelineSpec is the Ghostscript → QPDF pipeline the tool always used.
+const DefaultPipelineSpec = "gs,qpdf"
+
+// NewPipeline builds a pipeline from backends in the given order. The first
+// backend does the real work and is required, the others are optional.
+func NewPipeline(backends ...Backend) (*Pipeline, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("pipeline needs at least one backend")
+	}
+	p := &Pipeline{}
+	for i, b := range backends {
+		p.stages = append(p.stages, pipelineStage{backend: b, optional: i > 0})
+	}
+	return p, nil
+}
+
+// ParsePipeline builds a pipeline from a comma separated list of backend
+// names such as "gs,qpdf", "qpdf" or "gs,mutool". An empty spec yields the
+// default pipeline.
+func ParsePipeline(spec string) (*Pipeline, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = DefaultPipelineSpec
+	}
+
+	var stages []Backend
+	for _, name := range strings.Split(spec, ",") {
+		b, err := LookupBackend(name)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, b)
+	}
+	return NewPipeline(stages...)
+}
+
+// DefaultPipeline returns the Ghostscript → QPDF pipeline.
+func DefaultPipeline() *Pipeline {
+	p, _ := NewPipeline(GhostscriptBackend{}, QPDFBackend{})
+	return p
+}
+
+// LosslessPipeline is used for LevelLossless regardless of the configured
+// pipeline: mutool merges duplicate objects when it is installed, then QPDF
+// builds object streams. Neither touches page content.
+func LosslessPipeline() *Pipeline {
+	return &Pipeline{stages: []pipelineStage{
+		{backend: MutoolBackend{}, optional: true},
+		{backend: QPDFBackend{}},
+	}}
+}
+
+// Stages returns the backends in execution order.
+func (p *Pipeline) Stages() []Backend {
+	backends := make([]Backend, len(p.stages))
+	for i, s := range p.stages {
+		backends[i] = s.backend
+	}
+	return backends
+}
+
+// String returns the pipeline in the format ParsePipeline accepts.
+func (p *Pipeline) String() string {
+	names := make([]string, len(p.stages))
+	for i, s := range p.stages {
+		names[i] = s.backend.Name()
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,80 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePipeline(t *testing.T) {
+	cases := map[string]string{
+		"":              "ghostscript,qpdf",
+		"gs,qpdf":       "ghostscript,qpdf",
+		"qpdf":          "qpdf",
+		" gs , mutool ": "ghostscript,mutool",
+		"mutool,qpdf":   "mutool,qpdf",
+	}
+	for spec, want := range cases {
+		p, err := ParsePipeline(spec)
+		if err != nil {
+			t.Errorf("ParsePipeline(%q) returned error: %v", spec, err)
+			continue
+		}
+		if p.String() != want {
+			t.Errorf("ParsePipeline(%q) = %s, want %s", spec, p, want)
+		}
+	}
+
+	if _, err := ParsePipeline("gs,pdftk"); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestDetectBackends(t *testing.T) {
+	infos := DetectBackends()
+	if len(infos) != len(Backends()) {
+		t.Fatalf("expected %d backends, got %d", len(Backends()), len(infos))
+	}
+	for _, info := range infos {
+		if info.Available && info.Path == "" {
+			t.Errorf("%s is available but has no path", info.Name)
+		}
+	}
+}
+
+func TestLosslessPipeline_NoGhostscript(t *testing.T) {
+	for _, b := range LosslessPipeline().Stages() {
+		if b.Name() == "ghostscript" {
+			t.Fatal("lossless pipeline must not re-render pages with Ghostscript")
+		}
+	}
+}
+
+func TestQPDFBackend_WarningExit(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found, skipping fake qpdf test")
+	}
+	// Writes the output like qpdf after repairing a damaged file, then
+	// exits with 3 (warnings) or the code in $QPDF_EXIT
+	bin := t.TempDir()
+	script := "#!/bin/sh\nfor arg; do last=\"$arg\"; done\necho repaired >&2\necho \"%PDF-1.4\" > \"$last\"\nexit ${QPDF_EXIT:-3}\n"
+	if err := os.WriteFile(filepath.Join(bin, "qpdf"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out.pdf")
+	if err := (QPDFBackend{}).Run(context.Background(), filepath.Join(dir, "in.pdf"), output, CompressOptions{Lossless: true}); err != nil {
+		t.Fatalf("warnings must not fail the stage: %v", err)
+	}
+
+	t.Setenv("QPDF_EXIT", "2")
+	err := (QPDFBackend{}).Run(context.Background(), filepath.Join(dir, "in.pdf"), output, CompressOptions{})
+	if err == nil || !strings.Contains(err.Error(), "repaired") {
+		t.Errorf("Run with exit 2 = %v, want an error with qpdf's message", err)
+	}
+}
@@ -0,0 +1,119 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// GhostscriptBackend re-renders the document with pdfwrite. It is the only
+// backend that applies the image settings of CompressOptions.
+type GhostscriptBackend struct{}
+
+func (GhostscriptBackend) Name() string   { return "ghostscript" }
+func (GhostscriptBackend) Binary() string { return "gs" }
+
+func (b GhostscriptBackend) Available() bool {
+	_, err := exec.LookPath(b.Binary())
+	return err == nil
+}
+
+func (GhostscriptBackend) Run(ctx context.Context, input string, output string, opts CompressOptions) error {
+	_, err := exec.LookPath("gs")
+	if err != nil {
+		return fmt.Errorf("ghostscript (gs) not found")
+	}
+
+	args := []string{
+		"gs",
+		"-sDEVICE=pdfwrite",
+		"-dNOPAUSE",
+		"-dQUIET",
+		"-dBATCH",
+		"-dDetectDuplicateImages=true",
+		"-dCompressFonts=true",
+		"-dSubsetFonts=true",
+		"-dRemoveUnusedResources=true",
+		"-dNumRenderingThreads=4",
+		"-dDiscardPageThumbnails=true", // Remove hidden images for viewing
+		"-r150",                        // Forces lower resolution rendering for vectors
+	}
+
+	args = append(args, opts.ghostscriptArgs()...)
+	args = append(args, fmt.Sprintf("-sOutputFile=%s", output))
+
+	if ps := opts.distillerParams(); ps != "" {
+		args = append(args, "-c", ps, "-f")
+	}
+	args = append(args, input)
+
+	cmd := commandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+
+	return runCommand(ctx, cmd)
+}
+
+// QPDFBackend rewrites the structure losslessly: object streams and
+// maximum Flate compression.
+type QPDFBackend struct{}
+
+func (QPDFBackend) Name() string   { return "qpdf" }
+func (QPDFBackend) Binary() string { return "qpdf" }
+
+func (b QPDFBackend) Available() bool {
+	_, err := exec.LookPath(b.Binary())
+	return err == nil
+}
+
+// Run treats qpdf's warning exit as success, like every other qpdf call:
+// the output is written, with the damage qpdf repaired.
+func (QPDFBackend) Run(ctx context.Context, input string, output string, opts CompressOptions) error {
+	args := []string{
+		"--recompress-flate",        // recompresses all text streams
+		"--object-streams=generate", // object grouping
+		"--stream-data=compress",    // guarantees data compression
+		"--compression-level=9",     // maximum compression
+	}
+
+	if opts.Lossless {
+		args = append(args,
+			"--decode-level=generalized",          // re-deflate LZW/ASCII encoded streams
+			"--remove-unreferenced-resources=yes", // drop fonts/images no page uses
+		)
+	}
+
+	args = append(args, input, output)
+	return runQPDF(ctx, args...)
+}
+
+// MutoolBackend runs MuPDF's "mutool clean", which garbage collects and
+// merges duplicate objects and deflates uncompressed streams.
+type MutoolBackend struct{}
+
+func (MutoolBackend) Name() string   { return "mutool" }
+func (MutoolBackend) Binary() string { return "mutool" }
+
+func (b MutoolBackend) Available() bool {
+	_, err := exec.LookPath(b.Binary())
+	return err == nil
+}
+
+func (MutoolBackend) Run(ctx context.Context, input string, output string, opts CompressOptions) error {
+	_, err := exec.LookPath("mutool")
+	if err != nil {
+		return fmt.Errorf("mutool not found")
+	}
+
+	args := []string{
+		"mutool", "clean",
+		"-gggg", // garbage collect, compact xref, merge duplicate objects
+		"-z",    // deflate uncompressed streams
+		input,
+		output,
+	}
+
+	cmd := commandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	return runCommand(ctx, cmd)
+}
@@ -0,0 +1,292 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1" // for old signatures
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+)
+
+// CMS (RFC 5652) SignedData, as much of it as PDF signatures use: detached
+// content, one signer, signed attributes.
+
+var (
+	oidData                 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidRSAPSS          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// cmsHashes maps the digest algorithms a signature may use to their hash.
+var cmsHashes = map[string]crypto.Hash{
+	oidSHA1.String():   crypto.SHA1,
+	oidSHA256.String(): crypto.SHA256,
+	oidSHA384.String(): crypto.SHA384,
+	oidSHA512.String(): crypto.SHA512,
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional,tag:0"` // [0] EXPLICIT, unwrapped by hand
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// essCertIDv2 is the signing certificate reference of PAdES (RFC 5035),
+// with the default hash algorithm, SHA-256, left out.
+type essCertIDv2 struct {
+	CertHash []byte
+}
+
+type signingCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+// signCMS returns a detached CMS SignedData over content with the given
+// SHA-256 digest, as PAdES baseline B requires: content type, message
+// digest and signing certificate as signed attributes, no signing time (the
+// signature dictionary's /M holds it).
+func signCMS(key crypto.Signer, certs []*x509.Certificate, digest []byte) ([]byte, error) {
+	cert := certs[0]
+
+	var sigAlg pkix.AlgorithmIdentifier
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		sigAlg = pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}
+	case *ecdsa.PublicKey:
+		sigAlg = pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or ECDSA", key.Public())
+	}
+
+	certHash := sha256.Sum256(cert.Raw)
+	attrs, err := marshalAttributes(
+		oidContentType, oidData,
+		oidMessageDigest, digest,
+		oidSigningCertificateV2, signingCertificateV2{Certs: []essCertIDv2{{CertHash: certHash[:]}}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature covers the attributes encoded as a SET, not with the
+	// [0] tag they carry in SignerInfo
+	attrsHash := sha256.Sum256(append([]byte{0x31}, attrs.FullBytes[1:]...))
+	signature, err := key.Sign(rand.Reader, attrsHash[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("signing: %w", err)
+	}
+
+	sid, err := asn1.Marshal(issuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, Serial: cert.SerialNumber})
+	if err != nil {
+		return nil, err
+	}
+	var rawCerts []byte
+	for _, c := range certs {
+		rawCerts = append(rawCerts, c.Raw...)
+	}
+
+	sha256Alg := pkix.AlgorithmIdentifier{Algorithm: oidSHA256}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Alg},
+		EncapContentInfo: encapContentInfo{EContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: rawCerts},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    sha256Alg,
+			SignedAttrs:        attrs,
+			SignatureAlgorithm: sigAlg,
+			Signature:          signature,
+		}},
+	}
+	inner, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+	})
+}
+
+// marshalAttributes encodes (type, value) pairs as the [0] IMPLICIT SET OF
+// Attribute of SignerInfo, each with one value, sorted as DER requires.
+func marshalAttributes(pairs ...any) (asn1.RawValue, error) {
+	var encoded [][]byte
+	for i := 0; i < len(pairs); i += 2 {
+		value, err := asn1.Marshal(pairs[i+1])
+		if err != nil {
+			return asn1.RawValue{}, err
+		}
+		attr, err := asn1.Marshal(attribute{
+			Type:   pairs[i].(asn1.ObjectIdentifier),
+			Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+		})
+		if err != nil {
+			return asn1.RawValue{}, err
+		}
+		encoded = append(encoded, attr)
+	}
+	slices.SortFunc(encoded, bytes.Compare)
+
+	raw, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: bytes.Join(encoded, nil)})
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	var attrs asn1.RawValue
+	_, err = asn1.Unmarshal(raw, &attrs)
+	return attrs, err
+}
+
+// cmsSignature is a parsed detached CMS signature.
+type cmsSignature struct {
+	certs  []*x509.Certificate
+	signer *x509.Certificate
+	info   signerInfo
+	hash   crypto.Hash
+}
+
+func parseCMS(der []byte) (*cmsSignature, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("not a CMS signature: %w", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("not a CMS SignedData but %v", ci.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("CMS SignedData: %w", err)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("CMS has %d signers, want 1", len(sd.SignerInfos))
+	}
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("CMS certificates: %w", err)
+	}
+	s := &cmsSignature{certs: certs, info: sd.SignerInfos[0]}
+
+	var ok bool
+	if s.hash, ok = cmsHashes[s.info.DigestAlgorithm.Algorithm.String()]; !ok {
+		return nil, fmt.Errorf("unsupported digest algorithm %v", s.info.DigestAlgorithm.Algorithm)
+	}
+
+	sid := s.info.SID
+	for _, c := range certs {
+		switch {
+		case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+			// subjectKeyIdentifier
+			if bytes.Equal(sid.Bytes, c.SubjectKeyId) {
+				s.signer = c
+			}
+		default:
+			var ias issuerAndSerial
+			if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err == nil &&
+				bytes.Equal(ias.Issuer.FullBytes, c.RawIssuer) && ias.Serial.Cmp(c.SerialNumber) == 0 {
+				s.signer = c
+			}
+		}
+	}
+	if s.signer == nil {
+		return nil, fmt.Errorf("the signer's certificate is not in the signature")
+	}
+	return s, nil
+}
+
+// verify checks the signature against the digest of the signed content.
+func (s *cmsSignature) verify(digest []byte) error {
+	message := digest
+	if len(s.info.SignedAttrs.Bytes) > 0 {
+		var attrs []attribute
+		if _, err := asn1.UnmarshalWithParams(s.info.SignedAttrs.FullBytes, &attrs, "set,tag:0"); err != nil {
+			return fmt.Errorf("signed attributes: %w", err)
+		}
+		var signedDigest []byte
+		for _, attr := range attrs {
+			if attr.Type.Equal(oidMessageDigest) {
+				asn1.Unmarshal(attr.Values.Bytes, &signedDigest)
+			}
+		}
+		if signedDigest == nil {
+			return fmt.Errorf("the message digest attribute is missing")
+		}
+		if !bytes.Equal(signedDigest, digest) {
+			return errors.New("the document was changed after signing")
+		}
+
+		h := s.hash.New()
+		h.Write(append([]byte{0x31}, s.info.SignedAttrs.FullBytes[1:]...))
+		message = h.Sum(nil)
+	}
+
+	switch pub := s.signer.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if s.info.SignatureAlgorithm.Algorithm.Equal(oidRSAPSS) {
+			return rsa.VerifyPSS(pub, s.hash, message, s.info.Signature, nil)
+		}
+		return rsa.VerifyPKCS1v15(pub, s.hash, message, s.info.Signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, message, s.info.Signature) {
+			return errors.New("the signature does not match")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported key type %T", s.signer.PublicKey)
+}
@@ -1,10 +1,13 @@
 package pdf
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"time"
 )
 
 type CompressionLevel string
@@ -14,138 +17,240 @@ const (
 	LevelEbook   CompressionLevel = "/ebook"   // 150 dpi
 	LevelPrinter CompressionLevel = "/printer" // 300 dpi
 	LevelExtreme CompressionLevel = "extreme"
+
+	// LevelLossless only rewrites the file structure; pages render identically.
+	LevelLossless CompressionLevel = "lossless"
 )
 
-type Compressor struct{}
+// ErrSigned is returned when a signed PDF reached the compressor already
+// rewritten, so its signatures are broken, and CompressOptions.Force is not
+// set.
+var ErrSigned = errors.New("the PDF is digitally signed")
+
+type Compressor struct {
+	// Logger receives progress messages. nil means slog.Default().
+	Logger *slog.Logger
+
+	// Pipeline lists the backends to run. nil means DefaultPipeline().
+	Pipeline *Pipeline
+}
 
 func NewCompressor() *Compressor {
 	return &Compressor{}
 }
 
-func (c *Compressor) Compress(inputPath string, outputPath string, level CompressionLevel) error {
-	gsOut := outputPath + ".gs.pdf"
-	qpdfOut := outputPath + ".qpdf.pdf"
-	defer os.Remove(gsOut)
-	defer os.Remove(qpdfOut)
+func (c *Compressor) Compress(inputPath string, outputPath string, level CompressionLevel) (*CompressionReport, error) {
+	return c.CompressContext(context.Background(), inputPath, outputPath, level)
+}
+
+// CompressContext is like Compress but stops the running tools when ctx is
+// done. In that case the partial output is removed and the returned error
+// wraps ErrCanceled or ErrTimeout.
+func (c *Compressor) CompressContext(ctx context.Context, inputPath string, outputPath string, level CompressionLevel) (*CompressionReport, error) {
+	return c.CompressWith(ctx, inputPath, outputPath, level.Options())
+}
+
+// CompressWith runs the pipeline with explicit options instead of a preset.
+// If the result is not smaller than the input, the input is copied to
+// outputPath and the report says so.
+func (c *Compressor) CompressWith(ctx context.Context, inputPath string, outputPath string, opts CompressOptions) (report *CompressionReport, err error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(outputPath)
+		}
+	}()
 
-	logSize("Original", inputPath)
+	logger := c.logger().With("input", filepath.Base(inputPath))
+	started := time.Now()
+	report = &CompressionReport{InputSize: fileSize(inputPath)}
+	kept, err := c.keepSigned(ctx, inputPath, outputPath, opts, report)
+	if err != nil {
+		return nil, err
+	}
+	if kept {
+		report.Duration = time.Since(started)
+		return report, nil
+	}
+	pipeline := c.pipeline()
+	if opts.Lossless {
+		pipeline = LosslessPipeline()
+	}
+	logger.Info("compression started", "size", report.InputSize, "pipeline", pipeline.String())
 
-	// --- Ghostscript (Images + Rendering) ---
-	log.Println("🔹 Step 1: Ghostscript (Image processing)...")
-	if err := c.runGhostscript(inputPath, gsOut, level); err != nil {
-		return fmt.Errorf("step 1 failed: %w", err)
+	current := inputPath
+	for i, stage := range pipeline.stages {
+		backend := stage.backend
+		stageOut := fmt.Sprintf("%s.%d.%s.pdf", outputPath, i, backend.Name())
+		defer os.Remove(stageOut)
+
+		stageStart := time.Now()
+		if !backend.Available() {
+			if !stage.optional {
+				return nil, fmt.Errorf("step %d failed: %s (%s) not found", i+1, backend.Name(), backend.Binary())
+			}
+			report.addStage(backend.Name(), StageSkipped, current, stageStart, nil)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s is not installed, stage skipped", backend.Name()))
+			logger.Warn("backend not installed, stage skipped", "backend", backend.Name())
+			continue
+		}
+
+		if err := backend.Run(ctx, current, stageOut, opts); err != nil {
+			// A required stage does the real work, without it there is nothing to pass on.
+			if !stage.optional || IsAborted(err) {
+				return nil, fmt.Errorf("step %d failed: %w", i+1, err)
+			}
+			// Fallback: keep the output of the previous stage
+			report.addStage(backend.Name(), StageFallback, current, stageStart, err)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s failed (%v), using the previous output", backend.Name(), err))
+			logger.Warn("backend failed, proceeding with previous output", "backend", backend.Name(), "err", err)
+			continue
+		}
+
+		report.addStage(backend.Name(), StageRan, stageOut, stageStart, nil)
+		stage := report.Stages[len(report.Stages)-1]
+		logger.Info("stage finished", "backend", backend.Name(), "size", stage.Size, "duration", stage.Duration)
+		current = stageOut
 	}
 
-	logSize("After Ghostscript", gsOut)
+	if opts.StripMetadata || len(opts.KeepMetadata) > 0 {
+		metaOut := outputPath + ".metadata.pdf"
+		defer os.Remove(metaOut)
+
+		stageStart := time.Now()
+		if err := applyMetadataOptions(ctx, inputPath, current, metaOut, opts); err != nil {
+			// Stripping is usually asked for privacy, a file that still has
+			// its metadata is no result
+			if IsAborted(err) || opts.StripMetadata {
+				return nil, fmt.Errorf("metadata: %w", err)
+			}
+			report.addStage("metadata", StageFallback, current, stageStart, err)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("the metadata could not be updated (%v), it is as the backends left it", err))
+			logger.Warn("metadata update failed", "err", err)
+		} else {
+			report.addStage("metadata", StageRan, metaOut, stageStart, nil)
+			current = metaOut
+		}
+	}
 
-	// --- QPDF (Structure, Objects and Metadata) ---
-	// QPDF is best at "Object Stream" compression
-	log.Println("🔹 Step 2: QPDF (Structural cleanup & Metadata removal)...")
-	if err := c.runQpdf(gsOut, outputPath); err != nil {
-		log.Printf("⚠️ QPDF failed: %v. Proceeding with GS output.", err)
-		// Fallback: copy the result from GS to the QPDF variable
-		copyFile(gsOut, outputPath)
+	if err := copyFile(current, outputPath); err != nil {
+		return nil, err
 	}
 
-	logSize("After QPDF (Final)", outputPath)
+	report.OutputSize = fileSize(outputPath)
+	if report.OutputSize >= report.InputSize {
+		// The metadata options still apply to the original. A signed one
+		// only gets here with Force, which accepts breaking the signatures.
+		kept := inputPath
+		if opts.StripMetadata || len(opts.KeepMetadata) > 0 {
+			original := outputPath + ".original.pdf"
+			defer os.Remove(original)
+			err := applyMetadataOptions(ctx, inputPath, inputPath, original, opts)
+			if IsAborted(err) || (err != nil && opts.StripMetadata) {
+				return nil, fmt.Errorf("metadata: %w", err)
+			}
+			if err == nil {
+				kept = original
+			}
+		}
+		if err := copyFile(kept, outputPath); err != nil {
+			return nil, err
+		}
+		report.OutputSize = fileSize(outputPath)
+		report.KeptOriginal = true
+		report.Warnings = append(report.Warnings, "the compressed file was not smaller, the original was kept")
+		if report.Signatures > 0 && opts.SourceSignatures == 0 && kept == inputPath {
+			report.SignatureStatus = SignaturePreserved
+		}
+	}
 
-	log.Println("✅ Compression pipeline finished.")
-	return nil
+	report.Duration = time.Since(started)
+	logger.Info("compression finished", "size", report.OutputSize, "saved_percent", report.SavedPercent(),
+		"kept_original", report.KeptOriginal, "duration", report.Duration)
+	return report, nil
 }
 
-func (c *Compressor) runGhostscript(input string, output string, level CompressionLevel) error {
-	_, err := exec.LookPath("gs")
-	if err != nil {
-		return fmt.Errorf("ghostscript (gs) not found")
-	}
-
-	args := []string{
-		"gs",
-		"-sDEVICE=pdfwrite",
-		"-dCompatibilityLevel=1.4",
-		"-dNOPAUSE",
-		"-dQUIET",
-		"-dBATCH",
-		"-dDetectDuplicateImages=true",
-		"-dCompressFonts=true",
-		"-dSubsetFonts=true",
-		"-dColorImageResolution=150",
-		"-dGrayImageResolution=150",
-		"-dMonoImageResolution=150",
-		"-dRemoveUnusedResources=true",
-		"-dNumRenderingThreads=4",
-		// We use Bicubic (better quality) or Subsample (worse quality but faster)
-		"-dColorImageDownsampleType=/Bicubic",
-		"-dDiscardPageThumbnails=true",  // Remove hidden images for viewing
-		"-dDiscardPageAnnotations=true", // Remove notes and forms
-		"-dPreserveAnnots=false",        // Forces annotation removal
-		"-r150",                         // Forces lower resolution rendering for vectors
-	}
-
-	switch level {
-	case LevelExtreme:
-		args = append(args,
-			"-dPDFSETTINGS=/screen",
-			// We prohibit GS from passing already compressed images.
-			// This causes GS to decode and re-encode them with our settings.
-			"-dPassThroughJPEGImages=false",
-			// Force Downsampling (resolution reduction)
-			"-dDownsampleColorImages=true",
-			"-dDownsampleGrayImages=true",
-			"-dDownsampleMonoImages=true",
-			// set 72 DPI
-			"-dColorImageResolution=72",
-			"-dGrayImageResolution=72",
-			"-dMonoImageResolution=72",
-			// Conversion to RGB (saves CMYK ink channels)
-			"-sColorConversionStrategy=RGB",
-			"-sProcessColorModel=DeviceRGB",
-			// Force JPEG compression (DCTEncode)
-			"-dAutoFilterColorImages=false",
-			"-dAutoFilterGrayImages=false",
-			"-dEncodeColorImages=true",
-			"-dColorImageFilter=/DCTEncode",
-			"-dGrayImageFilter=/DCTEncode",
-			"-dDiscardBookmarks=true",
-		)
-	case LevelScreen:
-		args = append(args,
-			"-dPDFSETTINGS=/screen",
-			"-dColorImageResolution=72",
-			"-dGrayImageResolution=72",
-			"-dMonoImageResolution=72",
-			"-dDiscardBookmarks=true",
-		)
-	default:
-		// allow PassThrough for better quality
-		args = append(args, fmt.Sprintf("-dPDFSETTINGS=%s", level))
-	}
-
-	args = append(args, fmt.Sprintf("-sOutputFile=%s", output), input)
-
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
+// keepSigned looks for digital signatures in the input. Unless opts.Force
+// is set, a signed input is copied to outputPath unchanged and kept is
+// true; with Force the report says the signatures will be invalidated. An
+// input rewritten from a signed original (opts.SourceSignatures) cannot be
+// kept, without Force it is an ErrSigned error.
+func (c *Compressor) keepSigned(ctx context.Context, inputPath string, outputPath string, opts CompressOptions, report *CompressionReport) (kept bool, err error) {
+	if n := opts.SourceSignatures; n > 0 {
+		report.Signatures = n
+		if !opts.Force {
+			return false, fmt.Errorf("%w: it was rewritten before compressing, which broke its %d signature(s) (force accepts that)", ErrSigned, n)
+		}
+		report.SignatureStatus = SignatureInvalidated
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s: the file was rewritten before compressing, which broke its %d digital signature(s)", SignatureInvalidated, n))
+		c.logger().Warn("compressing a rewritten signed PDF", "input", filepath.Base(inputPath), "signatures", n)
+		return false, nil
+	}
+
+	n, err := CountSignatures(ctx, inputPath)
+	if err != nil || n == 0 {
+		return false, err
+	}
+	report.Signatures = n
+
+	if opts.Force {
+		report.SignatureStatus = SignatureInvalidated
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s: compressing rewrote the file and broke its %d digital signature(s)", SignatureInvalidated, n))
+		c.logger().Warn("compressing a signed PDF", "input", filepath.Base(inputPath), "signatures", n)
+		return false, nil
+	}
+
+	if err := copyFile(inputPath, outputPath); err != nil {
+		return false, err
+	}
+	report.OutputSize = report.InputSize
+	report.KeptOriginal = true
+	report.SignatureStatus = SignaturePreserved
+	report.Warnings = append(report.Warnings, fmt.Sprintf("%s: the PDF has %d digital signature(s) and was left unchanged, compressing would break them (force compresses anyway)", SignaturePreserved, n))
+	c.logger().Info("signed PDF left unchanged", "input", filepath.Base(inputPath), "signatures", n)
+	return true, nil
 }
 
-func (c *Compressor) runQpdf(input string, output string) error {
-	_, err := exec.LookPath("qpdf")
-	if err != nil {
-		return fmt.Errorf("qpdf not found")
+// applyMetadataOptions writes current, the pipeline's result, to out with
+// the metadata opts ask for: none with StripMetadata, the input's values of
+// the KeepMetadata fields.
+func applyMetadataOptions(ctx context.Context, input string, current string, out string, opts CompressOptions) error {
+	var original *Metadata
+	if len(opts.KeepMetadata) > 0 {
+		var err error
+		if original, err = ReadMetadata(ctx, input); err != nil {
+			return err
+		}
 	}
 
-	args := []string{
-		"qpdf",
-		"--recompress-flate",        // recompresses all text streams
-		"--object-streams=generate", // object grouping
-		"--stream-data=compress",    // guarantees data compression
-		"--compression-level=9",     // maximum compression
-		input,
-		output,
+	if opts.StripMetadata {
+		meta := &Metadata{}
+		for _, name := range opts.KeepMetadata {
+			meta.SetField(name, original.Field(name))
+		}
+		return replaceMetadata(ctx, current, out, meta)
 	}
 
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	fields := map[string]string{}
+	for _, name := range opts.KeepMetadata {
+		fields[name] = original.Field(name)
+	}
+	return WriteMetadata(ctx, current, out, fields)
+}
+
+func (c *Compressor) pipeline() *Pipeline {
+	if c.Pipeline != nil {
+		return c.Pipeline
+	}
+	return DefaultPipeline()
+}
+
+func (c *Compressor) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
 }
@@ -1,6 +1,14 @@
 package pdf
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -18,7 +26,7 @@ func TestCompressor_Compress_Integration(t *testing.T) {
 	comp := NewCompressor()
 
 	t.Logf("🚀 Starting compression test on: %s", inputPath)
-	err := comp.Compress(inputPath, outputPath, LevelScreen)
+	report, err := comp.Compress(inputPath, outputPath, LevelScreen)
 	if err != nil {
 		t.Errorf("Compress function returned error: %v", err)
 	}
@@ -32,5 +40,189 @@ func TestCompressor_Compress_Integration(t *testing.T) {
 		t.Error("❌ Output file is empty (0 bytes)")
 	}
 
+	if report.OutputSize != info.Size() {
+		t.Errorf("❌ Report says %d bytes, file has %d bytes", report.OutputSize, info.Size())
+	}
+
+	if len(report.Stages) != 2 || report.Stages[0].Name != "ghostscript" {
+		t.Errorf("❌ Unexpected stages in report: %+v", report.Stages)
+	}
+
 	t.Logf("✅ Compression successful. Output size: %d bytes", info.Size())
 }
+
+// signedTestPDF returns testPDF with one signature.
+func signedTestPDF(t *testing.T) []byte {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, err := NewSigner(key, []*x509.Certificate{testIdentity(t, key, "Signer")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, doc, pages := testPDF()
+	signed, _, err := signer.sign(data, doc, pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestCompressor_SignedInputKept(t *testing.T) {
+	signed := signedTestPDF(t)
+
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "signed.pdf")
+	os.WriteFile(inputPath, signed, 0644)
+	if n, err := CountSignatures(context.Background(), inputPath); err != nil || n != 1 {
+		t.Fatalf("CountSignatures = %d, %v, want 1", n, err)
+	}
+
+	// Without force no backend runs, so this needs no Ghostscript
+	outputPath := filepath.Join(tempDir, "output_compressed.pdf")
+	report, err := NewCompressor().Compress(inputPath, outputPath, LevelScreen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Signatures != 1 || report.SignatureStatus != SignaturePreserved || !report.KeptOriginal || len(report.Stages) != 0 {
+		t.Errorf("report = %+v, want the signed file kept", report)
+	}
+	if out, _ := os.ReadFile(outputPath); !bytes.Equal(out, signed) {
+		t.Error("the signed file was changed")
+	}
+
+	// The test PDF has no signatures
+	if n, err := CountSignatures(context.Background(), testFileRelativePath); err != nil || n != 0 {
+		t.Errorf("CountSignatures(unsigned) = %d, %v", n, err)
+	}
+}
+
+func TestCompressor_RewrittenSignedInput(t *testing.T) {
+	tempDir := t.TempDir()
+	// Stands in for the decrypted copy of a signed upload
+	inputPath := filepath.Join(tempDir, "rewritten.pdf")
+	os.WriteFile(inputPath, signedTestPDF(t), 0644)
+	outputPath := filepath.Join(tempDir, "output_compressed.pdf")
+
+	opts := LevelScreen.Options()
+	opts.SourceSignatures = 1
+	if _, err := NewCompressor().CompressWith(context.Background(), inputPath, outputPath, opts); !errors.Is(err, ErrSigned) {
+		t.Errorf("CompressWith = %v, want ErrSigned", err)
+	}
+	if _, err := os.Stat(outputPath); err == nil {
+		t.Error("output written although compression was refused")
+	}
+
+	// Already under the target, but the copy no longer carries valid signatures
+	opts.Force = true
+	res, err := NewCompressor().CompressToSize(context.Background(), inputPath, outputPath, 1<<30, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Report.Signatures != 1 || res.Report.SignatureStatus != SignatureInvalidated {
+		t.Errorf("report = %+v, want the signature invalidated", res.Report)
+	}
+}
+
+func TestCompressor_EncryptedSignedInput_Integration(t *testing.T) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping encrypted signed input test")
+	}
+
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	signedPath := filepath.Join(tempDir, "signed.pdf")
+	os.WriteFile(signedPath, signedTestPDF(t), 0644)
+	inputPath := filepath.Join(tempDir, "encrypted.pdf")
+	if err := Encrypt(ctx, signedPath, inputPath, EncryptOptions{UserPassword: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// What the compress handler does: count, decrypt, compress
+	n, err := CountSignatures(ctx, inputPath)
+	if err != nil || n != 1 {
+		t.Fatalf("CountSignatures(encrypted) = %d, %v, want 1", n, err)
+	}
+	if done, err := DecryptInPlace(ctx, inputPath, "secret"); err != nil || !done {
+		t.Fatalf("DecryptInPlace = %v, %v", done, err)
+	}
+
+	opts := LevelScreen.Options()
+	opts.SourceSignatures = n
+	outputPath := filepath.Join(tempDir, "output_compressed.pdf")
+	if _, err := NewCompressor().CompressWith(ctx, inputPath, outputPath, opts); !errors.Is(err, ErrSigned) {
+		t.Errorf("CompressWith(decrypted) = %v, want ErrSigned", err)
+	}
+}
+
+func TestCountSignatures_Integration(t *testing.T) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping signature field test")
+	}
+
+	// Page text that looks like a signature dictionary is not one
+	w := newPDFWriter()
+	pagesID := w.reserve()
+	content := w.addStream("", []byte("BT /F1 12 Tf 72 720 Td (/ByteRange [0 10 20 30]) Tj ET"))
+	pageID := w.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 595 842] /Contents %d 0 R >>", pagesID, content))
+	w.addAt(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", pageID))
+	catalogID := w.add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "text.pdf")
+	os.WriteFile(path, w.finish(catalogID), 0644)
+	if n, err := CountSignatures(ctx, path); err != nil || n != 0 {
+		t.Errorf("CountSignatures(text) = %d, %v, want 0", n, err)
+	}
+
+	// A signature field rewritten into an object stream is still found
+	signedPath := filepath.Join(tempDir, "signed.pdf")
+	os.WriteFile(signedPath, signedTestPDF(t), 0644)
+	packed := filepath.Join(tempDir, "packed.pdf")
+	cmd := exec.Command("qpdf", "--object-streams=generate", signedPath, packed)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("qpdf: %v: %s", err, out)
+	}
+	if n, err := CountSignatures(ctx, packed); err != nil || n != 1 {
+		t.Errorf("CountSignatures(object streams) = %d, %v, want 1", n, err)
+	}
+}
+
+// copyBackend passes its input on unchanged.
+type copyBackend struct{}
+
+func (copyBackend) Name() string    { return "copy" }
+func (copyBackend) Binary() string  { return "cp" }
+func (copyBackend) Available() bool { return true }
+func (copyBackend) Run(_ context.Context, in string, out string, _ CompressOptions) error {
+	return copyFile(in, out)
+}
+
+func TestCompressor_MetadataFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "broken.pdf")
+	os.WriteFile(inputPath, []byte("%PDF-1.4\nno objects, so qpdf cannot read the metadata\n"), 0644)
+	outputPath := filepath.Join(tempDir, "output_compressed.pdf")
+
+	compressor := NewCompressor()
+	compressor.Pipeline, _ = NewPipeline(copyBackend{})
+
+	// Keeping fields is best effort
+	opts := LevelEbook.Options()
+	opts.KeepMetadata = []string{"title"}
+	report, err := compressor.CompressWith(context.Background(), inputPath, outputPath, opts)
+	if err != nil {
+		t.Fatalf("CompressWith(keep) = %v, want a warning only", err)
+	}
+	if len(report.Warnings) == 0 {
+		t.Error("no warning about the metadata")
+	}
+
+	// A file that still has the metadata it was meant to lose is no result
+	opts.StripMetadata = true
+	if _, err := compressor.CompressWith(context.Background(), inputPath, outputPath, opts); err == nil {
+		t.Error("CompressWith(strip) returned no error")
+	}
+	if _, err := os.Stat(outputPath); err == nil {
+		t.Error("output left behind after a failed strip")
+	}
+}
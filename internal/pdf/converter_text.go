@@ -0,0 +1,184 @@
+package pdf
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ToMarkdown converts like ToWordWith but writes <name>.md: headings as
+// "# ", paragraphs separated by blank lines, tables as pipe tables and kept
+// images inline as data URIs.
+func (c *Converter) ToMarkdown(ctx context.Context, inputPath string, outputDir string, opts ConvertOptions) (*ConvertResult, error) {
+	return c.convert(ctx, inputPath, outputDir, ".md", opts, writeRendered(renderMarkdown))
+}
+
+// ToHTML writes a standalone <name>.html with <h2> headings, <p>
+// paragraphs, <table>s and kept images embedded as data URIs.
+func (c *Converter) ToHTML(ctx context.Context, inputPath string, outputDir string, opts ConvertOptions) (*ConvertResult, error) {
+	return c.convert(ctx, inputPath, outputDir, ".html", opts, writeRendered(renderHTML))
+}
+
+// ToText writes <name>.txt: one paragraph per line with blank lines
+// between, tables as tab separated rows. Images are left out.
+func (c *Converter) ToText(ctx context.Context, inputPath string, outputDir string, opts ConvertOptions) (*ConvertResult, error) {
+	return c.convert(ctx, inputPath, outputDir, ".txt", opts, writeRendered(renderText))
+}
+
+// writeRendered saves what render returns. render skips images it cannot
+// inline and names them in its warnings.
+func writeRendered(render func([]TextElement) (string, []string)) elementWriter {
+	return func(path string, elements []TextElement) ([]string, error) {
+		out, warnings := render(elements)
+		return warnings, os.WriteFile(path, []byte(out), 0644)
+	}
+}
+
+// markdownBlockStart matches paragraph starts Markdown would read as a
+// heading, quote, list item or rule.
+var markdownBlockStart = regexp.MustCompile(`^(#|>|[-+*]\s|\d+[.)]\s|={3,}|-{3,})`)
+
+func renderMarkdown(elements []TextElement) (string, []string) {
+	var warnings []string
+	var b strings.Builder
+	for _, el := range elements {
+		switch el.Kind {
+		case ElementHeading:
+			fmt.Fprintf(&b, "# %s\n\n", el.Text)
+		case ElementImage:
+			uri, err := imageDataURI(el.Image.Path)
+			if err != nil {
+				warnings = append(warnings, imageWarning(el.Image.Path, err))
+				continue
+			}
+			fmt.Fprintf(&b, "![](%s)\n\n", uri)
+		case ElementTable:
+			writeMarkdownTable(&b, el.Rows)
+		default:
+			text := el.Text
+			if markdownBlockStart.MatchString(text) {
+				text = `\` + text
+			}
+			fmt.Fprintf(&b, "%s\n\n", text)
+		}
+	}
+	return b.String(), warnings
+}
+
+func writeMarkdownTable(b *strings.Builder, rows [][]string) {
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	if cols == 0 {
+		return
+	}
+
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+	for i, row := range rows {
+		b.WriteString("|")
+		for c := range cols {
+			var text string
+			if c < len(row) {
+				text = cell.Replace(row[c])
+			}
+			fmt.Fprintf(b, " %s |", text)
+		}
+		b.WriteString("\n")
+
+		// The first row doubles as the header, pipe tables require one
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+		}
+	}
+	b.WriteString("\n")
+}
+
+func renderHTML(elements []TextElement) (string, []string) {
+	var warnings []string
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<style>table{border-collapse:collapse}td{border:1px solid #999;padding:2px 6px}img{max-width:100%}</style>\n")
+	b.WriteString("</head>\n<body>\n")
+
+	for _, el := range elements {
+		switch el.Kind {
+		case ElementHeading:
+			fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(el.Text))
+		case ElementImage:
+			uri, err := imageDataURI(el.Image.Path)
+			if err != nil {
+				warnings = append(warnings, imageWarning(el.Image.Path, err))
+				continue
+			}
+			img := el.Image
+			fmt.Fprintf(&b, "<p><img src=\"%s\" width=\"%.0f\" alt=\"\"></p>\n", uri, img.X1-img.X0)
+		case ElementTable:
+			b.WriteString("<table>\n")
+			for _, row := range el.Rows {
+				b.WriteString("<tr>")
+				for _, cell := range row {
+					fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(cell))
+				}
+				b.WriteString("</tr>\n")
+			}
+			b.WriteString("</table>\n")
+		default:
+			fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(el.Text))
+		}
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	return b.String(), warnings
+}
+
+func renderText(elements []TextElement) (string, []string) {
+	var b strings.Builder
+	for _, el := range elements {
+		switch el.Kind {
+		case ElementImage:
+			continue
+		case ElementTable:
+			for _, row := range el.Rows {
+				b.WriteString(strings.Join(row, "\t") + "\n")
+			}
+			b.WriteString("\n")
+		default:
+			fmt.Fprintf(&b, "%s\n\n", el.Text)
+		}
+	}
+	return b.String(), nil
+}
+
+// imageWarning says that the image at path was left out, without the
+// work directory it was extracted to.
+func imageWarning(path string, err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return fmt.Sprintf("image %s was left out: %v", filepath.Base(path), err)
+}
+
+// imageDataURI inlines a PNG, JPEG or GIF file.
+func imageDataURI(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	mime := http.DetectContentType(data)
+	switch mime {
+	case "image/png", "image/jpeg", "image/gif":
+	default:
+		return "", fmt.Errorf("unsupported image format %s", mime)
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,91 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+var renderElements = []TextElement{
+	{Kind: ElementHeading, Text: "ЗАГЛАВИЕ & ДРУГИ"},
+	{Kind: ElementParagraph, Text: "First paragraph."},
+	{Kind: ElementParagraph, Text: "# not a heading <b>"},
+	{Kind: ElementTable, Rows: [][]string{{"Name", "Qty"}, {"A|B", "3"}, {"C"}}},
+	{Kind: ElementImage, Image: &ImageBlock{Path: "missing.png"}},
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	got, warnings := renderMarkdown(renderElements)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "missing.png") {
+		t.Errorf("warnings = %q, want one for the missing image", warnings)
+	}
+
+	want := "# ЗАГЛАВИЕ & ДРУГИ\n\n" +
+		"First paragraph.\n\n" +
+		"\\# not a heading <b>\n\n" +
+		"| Name | Qty |\n" +
+		"| --- | --- |\n" +
+		"| A\\|B | 3 |\n" +
+		"| C |  |\n\n"
+	if got != want {
+		t.Errorf("renderMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	got, warnings := renderHTML(renderElements)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "missing.png") {
+		t.Errorf("warnings = %q, want one for the missing image", warnings)
+	}
+
+	for _, want := range []string{
+		"<h2>ЗАГЛАВИЕ &amp; ДРУГИ</h2>\n",
+		"<p>First paragraph.</p>\n",
+		"<p># not a heading &lt;b&gt;</p>\n",
+		"<tr><td>A|B</td><td>3</td></tr>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderHTML() does not contain %q", want)
+		}
+	}
+	if strings.Contains(got, "<img") {
+		t.Error("unreadable image should be skipped")
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	got, warnings := renderText(renderElements)
+	if len(warnings) != 0 {
+		t.Errorf("plain text leaves images out without warnings, got %q", warnings)
+	}
+
+	want := "ЗАГЛАВИЕ & ДРУГИ\n\nFirst paragraph.\n\n# not a heading <b>\n\nName\tQty\nA|B\t3\nC\n\n"
+	if got != want {
+		t.Errorf("renderText() = %q, want %q", got, want)
+	}
+}
+
+func TestConverter_ToMarkdown_Integration(t *testing.T) {
+	if _, err := defaultExtractor(); err != nil {
+		t.Skipf("%v, skipping conversion test", err)
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+
+	res, err := NewConverter().ToMarkdown(context.Background(), inputPath, tempDir, ConvertOptions{Sort: true})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	mdPath := res.Path
+
+	data, err := os.ReadFile(mdPath)
+	if err != nil {
+		t.Fatalf("❌ Markdown file was not created: %v", err)
+	}
+	if !strings.Contains(string(data), "# ") {
+		t.Errorf("❌ no headings found in %s", mdPath)
+	}
+
+	t.Logf("✅ Conversion successful. Created: %s (%d bytes)", mdPath, len(data))
+}
@@ -1,85 +1,272 @@
 package pdf
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/vpramatarov/pdf-tools/internal/docx"
 )
 
-type Converter struct{}
+type Converter struct {
+	// Extractor pulls the text blocks out of the PDF. nil picks the first
+	// available one of Extractors().
+	Extractor TextExtractor
+}
 
 func NewConverter() *Converter {
 	return &Converter{}
 }
 
+// ConvertOptions controls how the text flow is rebuilt.
+type ConvertOptions struct {
+	// Sort orders blocks by position (columns) instead of extraction order.
+	Sort bool
+
+	// Filters drops noise such as cross-references and running headers.
+	// nil keeps every block.
+	Filters *FilterRules
+
+	// KeepImages places the raster images of the PDF in the flow instead
+	// of stripping them before extraction. The extractor must implement
+	// ImageExtractor.
+	KeepImages bool
+
+	// Tables turns grids of short, aligned text blocks into real tables.
+	Tables bool
+
+	// OCR renders pages without a text layer and reads them with
+	// tesseract in OCRLanguage (e.g. "bul+eng", default
+	// DefaultOCRLanguage).
+	OCR         bool
+	OCRLanguage string
+}
+
+// ConvertResult describes a finished conversion.
+type ConvertResult struct {
+	Path string
+
+	// OCRPages lists the 1-based pages that had no text layer and were read
+	// with OCR.
+	OCRPages []int
+
+	// Warnings are things the user should know, e.g. pages that came out
+	// empty because OCR was off.
+	Warnings []string
+}
+
 func (c *Converter) ToWord(inputPath string, outputDir string, sort bool) (string, error) {
-	scriptPath, err := c.findScriptPath()
+	return c.ToWordContext(context.Background(), inputPath, outputDir, sort)
+}
+
+// ToWordContext is like ToWord but stops Ghostscript and the text extractor
+// when ctx is done. In that case the partial DOCX is removed and the
+// returned error wraps ErrCanceled or ErrTimeout.
+func (c *Converter) ToWordContext(ctx context.Context, inputPath string, outputDir string, sort bool) (string, error) {
+	res, err := c.ToWordWith(ctx, inputPath, outputDir, ConvertOptions{Sort: sort})
 	if err != nil {
 		return "", err
 	}
+	return res.Path, nil
+}
+
+// ToWordWith converts with explicit options and writes <name>.docx.
+func (c *Converter) ToWordWith(ctx context.Context, inputPath string, outputDir string, opts ConvertOptions) (*ConvertResult, error) {
+	return c.convert(ctx, inputPath, outputDir, ".docx", opts, writeDocx)
+}
+
+// elementWriter saves linearized elements to path in one output format. The
+// warnings name elements it had to leave out.
+type elementWriter func(path string, elements []TextElement) (warnings []string, err error)
+
+// convert extracts and linearizes inputPath and writes the result to
+// outputDir/<name><ext>. A partial output is removed on error.
+func (c *Converter) convert(ctx context.Context, inputPath string, outputDir string, ext string, opts ConvertOptions, write elementWriter) (*ConvertResult, error) {
+	if opts.OCR {
+		if opts.OCRLanguage == "" {
+			opts.OCRLanguage = DefaultOCRLanguage
+		}
+		if err := CheckOCRLanguage(ctx, opts.OCRLanguage); err != nil {
+			return nil, fmt.Errorf("OCR: %w", err)
+		}
+	}
+
+	// Kept images and OCR renderings live here until the output is written
+	workDir, err := os.MkdirTemp(filepath.Dir(inputPath), "convert_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	result := &ConvertResult{}
+	elements, err := c.extractElements(ctx, inputPath, workDir, opts, result)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := filepath.Base(inputPath)
+	baseName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	outputPath := filepath.Join(outputDir, baseName+ext)
+
+	warnings, err := write(outputPath, elements)
+	if err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("writing %s: %w", strings.TrimPrefix(ext, "."), err)
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+
+	result.Path = outputPath
+	return result, nil
+}
+
+func writeDocx(path string, elements []TextElement) ([]string, error) {
+	var warnings []string
+	doc := docx.New()
+	for _, el := range elements {
+		switch el.Kind {
+		case ElementHeading:
+			doc.AddText(el.Text, true, 12, 12)
+		case ElementImage:
+			img := el.Image
+			if err := doc.AddImage(img.Path, img.X1-img.X0, img.Y1-img.Y0); err != nil {
+				warnings = append(warnings, imageWarning(img.Path, err))
+			}
+		case ElementTable:
+			doc.AddTable(el.Rows, false)
+		default:
+			doc.AddText(el.Text, false, 11, 6)
+		}
+	}
+	return warnings, doc.Save(path)
+}
+
+// extractElements strips vectors (and, unless opts.KeepImages, images),
+// extracts the text blocks, OCRs pages without text if asked to and
+// linearizes everything into headings and paragraphs. Kept images and page
+// renderings are saved to workDir; OCR'd pages and warnings go to result.
+func (c *Converter) extractElements(ctx context.Context, inputPath string, workDir string, opts ConvertOptions, result *ConvertResult) ([]TextElement, error) {
+	extractor, err := c.extractor()
+	if err != nil {
+		return nil, err
+	}
+
+	var imageExtractor ImageExtractor
+	if opts.KeepImages {
+		var ok bool
+		if imageExtractor, ok = extractor.(ImageExtractor); !ok {
+			return nil, fmt.Errorf("text extractor %s cannot keep images", extractor.Name())
+		}
+	}
 
 	textOnlyPath := filepath.Join(filepath.Dir(inputPath), "clean_"+filepath.Base(inputPath))
 	defer os.Remove(textOnlyPath)
 
-	if err := c.removeImages(inputPath, textOnlyPath); err != nil {
+	if err := c.removeGraphics(ctx, inputPath, textOnlyPath, !opts.KeepImages); err != nil {
+		if IsAborted(err) {
+			return nil, err
+		}
 		fmt.Printf("⚠️ GS cleanup failed: %v. Using original.\n", err)
 		copyFile(inputPath, textOnlyPath)
 	}
 
-	// Python conversion
-	fileName := filepath.Base(inputPath)
-	baseName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
-	docxPath := filepath.Join(outputDir, baseName+".docx")
-
-	sortArg := "true"
-	if !sort {
-		sortArg = "false"
+	var pages []PageText
+	if imageExtractor != nil {
+		pages, err = imageExtractor.ExtractWithImages(ctx, textOnlyPath, workDir)
+	} else {
+		pages, err = extractor.Extract(ctx, textOnlyPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("text extraction (%s) failed: %w", extractor.Name(), err)
 	}
 
-	cmd := exec.Command("python3", scriptPath, textOnlyPath, docxPath, sortArg)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if err := ocrTextlessPages(ctx, inputPath, pages, workDir, opts, result); err != nil {
+		return nil, err
+	}
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("linearization error: %w", err)
+	if pages, err = opts.Filters.apply(pages); err != nil {
+		return nil, err
 	}
 
-	return docxPath, nil
+	return linearize(pages, opts), nil
 }
 
-func (c *Converter) removeImages(input string, output string) error {
-	cmd := exec.Command("gs",
-		"-o", output,
-		"-sDEVICE=pdfwrite",
-		"-dCompatibilityLevel=1.4",
-		"-dFILTERIMAGE",  // Removes images
-		"-dFILTERVECTOR", // Removes vectors
-		input,
-	)
-	return cmd.Run()
-}
+// ocrTextlessPages replaces the blocks of every page without a text layer
+// with what tesseract reads from the original (unstripped) PDF. Without
+// opts.OCR it only warns about such pages.
+func ocrTextlessPages(ctx context.Context, inputPath string, pages []PageText, workDir string, opts ConvertOptions, result *ConvertResult) error {
+	var textless []int
+	for i, page := range pages {
+		if !hasTextLayer(page) {
+			textless = append(textless, i)
+		}
+	}
+	if len(textless) == 0 {
+		return nil
+	}
 
-func (c *Converter) findScriptPath() (string, error) {
-	const scriptName = "convert_word.py"
+	if !opts.OCR {
+		numbers := make([]int, len(textless))
+		for i, idx := range textless {
+			numbers[i] = pages[idx].Number
+		}
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s no text layer, enable OCR to read scanned pages", pageList(numbers)))
+		return nil
+	}
 
-	possiblePaths := []string{
-		// Production: when run from root (./main)
-		filepath.Join("internal", "pdf", "scripts", scriptName),
+	for _, idx := range textless {
+		page := &pages[idx]
+		blocks, err := ocrPage(ctx, inputPath, page.Number, opts.OCRLanguage, workDir)
+		if err != nil {
+			if IsAborted(err) {
+				return err
+			}
+			result.Warnings = append(result.Warnings, fmt.Sprintf("OCR failed on page %d: %v", page.Number, err))
+			continue
+		}
 
-		// Testing: when run from internal/pdf (go test)
-		filepath.Join("scripts", scriptName),
+		page.Blocks = blocks
+		// The scan itself is now text; drop it unless it is a small picture
+		page.Images = slices.DeleteFunc(page.Images, func(img ImageBlock) bool {
+			return (img.X1-img.X0)*(img.Y1-img.Y0) >= page.Width*page.Height/2
+		})
+		result.OCRPages = append(result.OCRPages, page.Number)
+	}
+	return nil
+}
 
-		// Absolute path (Docker Safe)
-		"/app/internal/pdf/scripts/" + scriptName,
+// pageList formats page numbers for messages: "page 3 has", "pages 1, 4 have".
+func pageList(numbers []int) string {
+	parts := make([]string, len(numbers))
+	for i, n := range numbers {
+		parts[i] = strconv.Itoa(n)
+	}
+	if len(numbers) == 1 {
+		return "page " + parts[0] + " has"
 	}
+	return "pages " + strings.Join(parts, ", ") + " have"
+}
 
-	for _, path := range possiblePaths {
-		if _, err := os.Stat(path); err == nil {
-			return path, nil
-		}
+func (c *Converter) extractor() (TextExtractor, error) {
+	if c.Extractor != nil {
+		return c.Extractor, nil
 	}
+	return defaultExtractor()
+}
 
-	return "", fmt.Errorf("python script '%s' not found in any expected location. Checked: %v", scriptName, possiblePaths)
+func (c *Converter) removeGraphics(ctx context.Context, input string, output string, images bool) error {
+	args := []string{
+		"-o", output,
+		"-sDEVICE=pdfwrite",
+		"-dCompatibilityLevel=1.4",
+		"-dFILTERVECTOR", // Removes vectors
+	}
+	if images {
+		args = append(args, "-dFILTERIMAGE") // Removes images
+	}
+	cmd := commandContext(ctx, "gs", append(args, input)...)
+	return runCommand(ctx, cmd)
 }
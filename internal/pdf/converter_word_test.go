@@ -2,18 +2,14 @@ package pdf
 
 import (
 	"os"
-	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func TestConverter_ToWord_Integration(t *testing.T) {
-	if _, err := exec.LookPath("python3"); err != nil {
-		t.Skip("python3 not found, skipping conversion test")
-	}
-
-	scriptPath := "./scripts/convert_word.py"
-	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		t.Skipf("Script not found at %s, skipping test", scriptPath)
+	if _, err := defaultExtractor(); err != nil {
+		t.Skipf("%v, skipping conversion test", err)
 	}
 
 	tempDir, inputPath := setupTestFile(t)
@@ -38,3 +34,19 @@ func TestConverter_ToWord_Integration(t *testing.T) {
 
 	t.Logf("✅ Conversion successful. Created: %s (%d bytes)", docxPath, info.Size())
 }
+
+func TestWriteDocx_BrokenImage(t *testing.T) {
+	dir := t.TempDir()
+	elements := []TextElement{
+		{Kind: ElementParagraph, Text: "Before"},
+		{Kind: ElementImage, Image: &ImageBlock{Path: filepath.Join(dir, "missing.png"), X1: 100, Y1: 50}},
+	}
+
+	warnings, err := writeDocx(filepath.Join(dir, "out.docx"), elements)
+	if err != nil {
+		t.Fatalf("writeDocx returned error: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "image") {
+		t.Errorf("warnings = %q, want one about the image", warnings)
+	}
+}
@@ -0,0 +1,155 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Permissions are what a user who opened the PDF with the user password
+// may do. The owner password always allows everything.
+type Permissions struct {
+	Print    bool
+	Copy     bool // copy text and images
+	Modify   bool // edit, insert and rotate pages, fill forms, annotate
+	Annotate bool // comment and fill forms
+}
+
+// EncryptOptions configure Encrypt. Files are always encrypted with
+// AES-256.
+type EncryptOptions struct {
+	// UserPassword opens the file; empty means anyone can open it and only
+	// the permissions apply.
+	UserPassword string
+	// OwnerPassword lifts the permissions; empty means a random one, so
+	// nobody can.
+	OwnerPassword string
+
+	Permissions Permissions
+}
+
+// Encrypt writes inputPath, encrypted with AES-256, to outputPath.
+func Encrypt(ctx context.Context, inputPath string, outputPath string, opts EncryptOptions) error {
+	if opts.UserPassword == "" && opts.OwnerPassword == "" {
+		return fmt.Errorf("give a user or an owner password")
+	}
+	owner := opts.OwnerPassword
+	if owner == "" {
+		var raw [16]byte
+		rand.Read(raw[:])
+		owner = hex.EncodeToString(raw[:])
+	}
+
+	p := opts.Permissions
+	args := []string{"--encrypt", opts.UserPassword, owner, "256",
+		"--print=" + choose(p.Print, "full", "none"),
+		"--extract=" + choose(p.Copy, "y", "n"),
+		"--modify=" + choose(p.Modify, "all", choose(p.Annotate, "annotate", "none")),
+		"--", inputPath, outputPath,
+	}
+	if err := runQPDFSecret(ctx, filepath.Dir(outputPath), args...); err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+	return nil
+}
+
+// Decrypt writes inputPath without encryption to outputPath. password may
+// be the user or the owner password; files with only an owner password open
+// with an empty one. A wrong password gives ErrPassword.
+func Decrypt(ctx context.Context, inputPath string, outputPath string, password string) error {
+	err := runQPDFSecret(ctx, filepath.Dir(outputPath), "--password="+password, "--decrypt", inputPath, outputPath)
+	if err != nil {
+		os.Remove(outputPath)
+		if strings.Contains(err.Error(), "invalid password") {
+			return ErrPassword
+		}
+		return err
+	}
+	return nil
+}
+
+// IsEncrypted reports whether path is an encrypted PDF.
+func IsEncrypted(ctx context.Context, path string) (bool, error) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		return false, fmt.Errorf("qpdf not found")
+	}
+
+	var stderr bytes.Buffer
+	cmd := commandContext(ctx, "qpdf", "--is-encrypted", path)
+	cmd.Stderr = &stderr
+	err := runCommand(ctx, cmd)
+
+	// Exit status 0 means encrypted, 2 not encrypted
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 2:
+		return false, nil
+	default:
+		return false, fmt.Errorf("qpdf --is-encrypted: %w: %s", err, lastLine(stderr.String()))
+	}
+}
+
+// DecryptInPlace replaces path with its decrypted version when it is
+// encrypted and reports whether it was. Run it on uploads before tools such
+// as Ghostscript, which fail on encrypted input. Without qpdf and without a
+// password the file is left as it is.
+func DecryptInPlace(ctx context.Context, path string, password string) (bool, error) {
+	if _, err := exec.LookPath("qpdf"); err != nil && password == "" {
+		return false, nil
+	}
+	encrypted, err := IsEncrypted(ctx, path)
+	if err != nil || !encrypted {
+		return false, err
+	}
+
+	decrypted := path + ".decrypted.pdf"
+	if err := Decrypt(ctx, path, decrypted, password); err != nil {
+		return false, err
+	}
+	if err := os.Rename(decrypted, path); err != nil {
+		os.Remove(decrypted)
+		return false, err
+	}
+	return true, nil
+}
+
+// runQPDFSecret runs qpdf with args read from a private argument file in
+// dir, so passwords do not show up in the process list.
+func runQPDFSecret(ctx context.Context, dir string, args ...string) error {
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "\r\n") {
+			return fmt.Errorf("passwords must not contain line breaks")
+		}
+	}
+
+	f, err := os.CreateTemp(dir, "qpdf_args_*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(strings.Join(args, "\n") + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return runQPDF(ctx, "@"+f.Name())
+}
+
+func choose(cond bool, yes, no string) string {
+	if cond {
+		return yes
+	}
+	return no
+}
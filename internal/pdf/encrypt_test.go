@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncrypt_NeedsPassword(t *testing.T) {
+	err := Encrypt(context.Background(), "in.pdf", filepath.Join(t.TempDir(), "out.pdf"), EncryptOptions{})
+	if err == nil {
+		t.Error("expected an error without passwords")
+	}
+}
+
+func TestRunQPDFSecret_RejectsLineBreaks(t *testing.T) {
+	err := runQPDFSecret(context.Background(), t.TempDir(), "--password=a\nb", "--decrypt", "in.pdf", "out.pdf")
+	if err == nil {
+		t.Error("expected an error for a password with a line break")
+	}
+}
+
+func TestEncryptDecrypt_Integration(t *testing.T) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping encryption test")
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	ctx := context.Background()
+	encrypted := filepath.Join(tempDir, "encrypted.pdf")
+
+	t.Logf("🔒 Encrypting: %s", inputPath)
+	opts := EncryptOptions{UserPassword: "open sesame", OwnerPassword: "owner", Permissions: Permissions{Print: true}}
+	if err := Encrypt(ctx, inputPath, encrypted, opts); err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if ok, err := IsEncrypted(ctx, encrypted); err != nil || !ok {
+		t.Fatalf("IsEncrypted = %v, %v; want true", ok, err)
+	}
+	if ok, err := IsEncrypted(ctx, inputPath); err != nil || ok {
+		t.Errorf("IsEncrypted(original) = %v, %v; want false", ok, err)
+	}
+
+	decrypted := filepath.Join(tempDir, "decrypted.pdf")
+	if err := Decrypt(ctx, encrypted, decrypted, "wrong"); !errors.Is(err, ErrPassword) {
+		t.Errorf("Decrypt with a wrong password = %v, want ErrPassword", err)
+	}
+	if _, err := DecryptInPlace(ctx, encrypted, ""); !errors.Is(err, ErrPassword) {
+		t.Errorf("DecryptInPlace without a password = %v, want ErrPassword", err)
+	}
+
+	done, err := DecryptInPlace(ctx, encrypted, "open sesame")
+	if err != nil || !done {
+		t.Fatalf("DecryptInPlace = %v, %v", done, err)
+	}
+	if ok, _ := IsEncrypted(ctx, encrypted); ok {
+		t.Error("file is still encrypted after DecryptInPlace")
+	}
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrCanceled is returned when the caller's context was canceled while an
+	// external tool was still running.
+	ErrCanceled = errors.New("pdf: operation canceled")
+
+	// ErrTimeout is returned when the caller's context deadline expired while an
+	// external tool was still running.
+	ErrTimeout = errors.New("pdf: operation timed out")
+
+	// ErrPassword is returned when an encrypted PDF cannot be opened with
+	// the given password.
+	ErrPassword = errors.New("pdf: wrong or missing password")
+
+	// ErrInvalidOCRLanguage is returned for a language spec that is not of
+	// the form "eng" or "bul+eng".
+	ErrInvalidOCRLanguage = errors.New("pdf: invalid OCR language")
+
+	// ErrOCRLanguageMissing is returned when tesseract has no trained data
+	// for a language.
+	ErrOCRLanguageMissing = errors.New("pdf: OCR language not installed")
+)
+
+// contextError translates ctx.Err() into ErrCanceled or ErrTimeout. The
+// original context error stays in the chain, so errors.Is(err,
+// context.DeadlineExceeded) keeps working for callers that check for it.
+func contextError(ctx context.Context) error {
+	switch err := ctx.Err(); {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	}
+}
+
+// IsAborted reports whether err was caused by cancellation or a timeout
+// rather than by a failure of the underlying tool.
+func IsAborted(err error) bool {
+	return errors.Is(err, ErrCanceled) || errors.Is(err, ErrTimeout)
+}
@@ -0,0 +1,31 @@
+package pdf
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// waitDelay bounds how long Wait blocks on the child's stdio pipes after the
+// process group was killed.
+const waitDelay = 5 * time.Second
+
+// commandContext builds an *exec.Cmd bound to ctx. The child is started in
+// its own process group and cancellation kills the whole group, so helpers
+// forked by gs or python3 do not outlive the request.
+func commandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// runCommand runs cmd and reports ErrCanceled/ErrTimeout instead of the
+// "signal: killed" error when ctx ended first.
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	err := cmd.Run()
+	if ctxErr := contextError(ctx); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunCommand_TimeoutKillsProcessGroup(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found, skipping process group test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// The background sleep keeps stdout open; only a group kill lets Run return quickly.
+	cmd := commandContext(ctx, "sh", "-c", "sleep 30 & sleep 30")
+	cmd.Stdout = io.Discard
+
+	start := time.Now()
+	err := runCommand(ctx, cmd)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to wrap context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed > 3*time.Second {
+		t.Errorf("command was not killed in time (took %v)", elapsed)
+	}
+}
+
+func TestCompressor_CompressContext_Canceled(t *testing.T) {
+	if _, err := exec.LookPath("gs"); err != nil {
+		t.Skip("Ghostscript (gs) not found, skipping cancellation test")
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	outputPath := filepath.Join(tempDir, "canceled.pdf")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewCompressor().CompressContext(ctx, inputPath, outputPath, LevelScreen)
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Error("❌ Partial output was not removed")
+	}
+}
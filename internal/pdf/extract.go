@@ -0,0 +1,98 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+)
+
+// TextBlock is a block of text as laid out on the page. Lines inside the
+// block are separated by "\n". Coordinates are in points with the origin at
+// the top left corner of the page.
+type TextBlock struct {
+	Text           string
+	X0, Y0, X1, Y1 float64
+}
+
+// ImageBlock is a raster image saved from the page, placed like a TextBlock.
+type ImageBlock struct {
+	Path           string // PNG or JPEG file
+	X0, Y0, X1, Y1 float64
+}
+
+// PageText holds the text blocks of one page in extraction order. Images is
+// only filled by ImageExtractor.
+type PageText struct {
+	Number        int // 1-based
+	Width, Height float64
+	Blocks        []TextBlock
+	Images        []ImageBlock
+}
+
+// TextExtractor pulls positioned text blocks out of a PDF. Implementations
+// shell out to an external tool; Available reports whether it is installed.
+type TextExtractor interface {
+	Name() string
+	Available() bool
+	Extract(ctx context.Context, pdfPath string) ([]PageText, error)
+}
+
+// ImageExtractor is implemented by extractors that can also save the raster
+// images of every page. The files are written to imageDir, which the caller
+// owns and removes.
+type ImageExtractor interface {
+	ExtractWithImages(ctx context.Context, pdfPath string, imageDir string) ([]PageText, error)
+}
+
+// minImageSize drops rules, bullets and other decorations (in points).
+const minImageSize = 16
+
+// defaultExtractors are shared so PyMuPDFExtractor's import check runs once.
+var defaultExtractors = []TextExtractor{PdftotextExtractor{}, &PyMuPDFExtractor{}}
+
+// Extractors returns every known extractor in order of preference. The
+// PyMuPDF extractor uses python3 from PATH.
+func Extractors() []TextExtractor {
+	return append([]TextExtractor(nil), defaultExtractors...)
+}
+
+// ResolveExtractor returns the extractor called name, or the first
+// available one when name is empty. python is the interpreter for the
+// PyMuPDF extractor ("" means python3). Unlike Available, the error says
+// why an extractor cannot be used.
+func ResolveExtractor(ctx context.Context, name string, python string) (TextExtractor, error) {
+	candidates := []TextExtractor{PdftotextExtractor{}, &PyMuPDFExtractor{Python: python}}
+
+	if name == "" {
+		for _, e := range candidates {
+			if e.Available() {
+				return e, nil
+			}
+		}
+		return nil, fmt.Errorf("no text extractor available: install poppler-utils (pdftotext) or PyMuPDF")
+	}
+
+	for _, e := range candidates {
+		if e.Name() != name {
+			continue
+		}
+		if py, ok := e.(*PyMuPDFExtractor); ok {
+			if err := py.Check(ctx); err != nil {
+				return nil, err
+			}
+		} else if !e.Available() {
+			return nil, fmt.Errorf("text extractor %s is not installed", name)
+		}
+		return e, nil
+	}
+	return nil, fmt.Errorf("unknown text extractor %q", name)
+}
+
+// defaultExtractor returns the first available extractor.
+func defaultExtractor() (TextExtractor, error) {
+	for _, e := range defaultExtractors {
+		if e.Available() {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("no text extractor available: install poppler-utils (pdftotext) or PyMuPDF")
+}
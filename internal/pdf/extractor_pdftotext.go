@@ -0,0 +1,165 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PdftotextExtractor uses poppler's `pdftotext -bbox-layout`, which reports
+// blocks, lines and words with their bounding boxes.
+type PdftotextExtractor struct{}
+
+func (PdftotextExtractor) Name() string { return "pdftotext" }
+
+func (PdftotextExtractor) Available() bool {
+	_, err := exec.LookPath("pdftotext")
+	return err == nil
+}
+
+func (PdftotextExtractor) Extract(ctx context.Context, pdfPath string) ([]PageText, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := commandContext(ctx, "pdftotext", "-bbox-layout", "-enc", "UTF-8", pdfPath, "-")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := runCommand(ctx, cmd); err != nil {
+		return nil, fmt.Errorf("pdftotext: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseBBoxLayout(stdout.Bytes())
+}
+
+// ExtractWithImages adds the images poppler's pdftohtml saves from each page
+// to the text of Extract.
+func (e PdftotextExtractor) ExtractWithImages(ctx context.Context, pdfPath string, imageDir string) ([]PageText, error) {
+	if _, err := exec.LookPath("pdftohtml"); err != nil {
+		return nil, fmt.Errorf("pdftohtml (poppler-utils) is required to keep images")
+	}
+
+	pages, err := e.Extract(ctx, pdfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// -zoom 1 keeps coordinates in points; -fmt png converts every image
+	// (including CMYK and JBIG2 ones) to something Word understands.
+	prefix := filepath.Join(imageDir, "page")
+	var stderr bytes.Buffer
+	cmd := commandContext(ctx, "pdftohtml", "-xml", "-q", "-zoom", "1", "-fmt", "png", "-nodrm", pdfPath, prefix)
+	cmd.Stderr = &stderr
+	if err := runCommand(ctx, cmd); err != nil {
+		return nil, fmt.Errorf("pdftohtml: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	data, err := os.ReadFile(prefix + ".xml")
+	if err != nil {
+		return nil, fmt.Errorf("pdftohtml output: %w", err)
+	}
+	images, err := parsePdfToHTMLImages(data, imageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pages {
+		pages[i].Images = images[pages[i].Number]
+	}
+	return pages, nil
+}
+
+// pdf2xml mirrors the images of `pdftohtml -xml`.
+type pdf2xml struct {
+	Pages []struct {
+		Number int `xml:"number,attr"`
+		Images []struct {
+			Top    float64 `xml:"top,attr"`
+			Left   float64 `xml:"left,attr"`
+			Width  float64 `xml:"width,attr"`
+			Height float64 `xml:"height,attr"`
+			Src    string  `xml:"src,attr"`
+		} `xml:"image"`
+	} `xml:"page"`
+}
+
+// parsePdfToHTMLImages returns the images of each page by page number.
+// Relative src paths are resolved against dir.
+func parsePdfToHTMLImages(data []byte, dir string) (map[int][]ImageBlock, error) {
+	var doc pdf2xml
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("pdftohtml output: %w", err)
+	}
+
+	images := map[int][]ImageBlock{}
+	for _, p := range doc.Pages {
+		for _, img := range p.Images {
+			if img.Width < minImageSize || img.Height < minImageSize {
+				continue
+			}
+			path := img.Src
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, filepath.Base(path))
+			}
+			images[p.Number] = append(images[p.Number], ImageBlock{
+				Path: path,
+				X0:   img.Left, Y0: img.Top, X1: img.Left + img.Width, Y1: img.Top + img.Height,
+			})
+		}
+	}
+	return images, nil
+}
+
+// bboxDoc mirrors the XHTML pdftotext writes with -bbox-layout.
+type bboxDoc struct {
+	Pages []struct {
+		Width  float64 `xml:"width,attr"`
+		Height float64 `xml:"height,attr"`
+		Flows  []struct {
+			Blocks []struct {
+				XMin  float64 `xml:"xMin,attr"`
+				YMin  float64 `xml:"yMin,attr"`
+				XMax  float64 `xml:"xMax,attr"`
+				YMax  float64 `xml:"yMax,attr"`
+				Lines []struct {
+					Words []string `xml:"word"`
+				} `xml:"line"`
+			} `xml:"block"`
+		} `xml:"flow"`
+	} `xml:"body>doc>page"`
+}
+
+func parseBBoxLayout(data []byte) ([]PageText, error) {
+	var doc bboxDoc
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("pdftotext output: %w", err)
+	}
+
+	pages := make([]PageText, 0, len(doc.Pages))
+	for i, p := range doc.Pages {
+		page := PageText{Number: i + 1, Width: p.Width, Height: p.Height}
+		for _, flow := range p.Flows {
+			for _, b := range flow.Blocks {
+				lines := make([]string, 0, len(b.Lines))
+				for _, l := range b.Lines {
+					lines = append(lines, strings.Join(l.Words, " "))
+				}
+				page.Blocks = append(page.Blocks, TextBlock{
+					Text: strings.Join(lines, "\n"),
+					X0:   b.XMin, Y0: b.YMin, X1: b.XMax, Y1: b.YMax,
+				})
+			}
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
@@ -0,0 +1,143 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pymupdfScript dumps PyMuPDF's text blocks as JSON. It is fed to the
+// interpreter on stdin, so no script file has to exist next to the binary.
+//
+//go:embed scripts/convert_word.py
+var pymupdfScript []byte
+
+// pythonModules are the modules pymupdfScript imports.
+var pythonModules = []string{"fitz"}
+
+// PyMuPDFExtractor runs the embedded convert_word.py with a Python
+// interpreter that has PyMuPDF (the fitz module) installed.
+type PyMuPDFExtractor struct {
+	// Python is the interpreter, e.g. a virtualenv's bin/python. Empty
+	// means "python3" from PATH.
+	Python string
+
+	checkOnce sync.Once
+	checkErr  error
+}
+
+func (*PyMuPDFExtractor) Name() string { return "pymupdf" }
+
+// Available reports whether Check succeeds. The result is cached.
+func (e *PyMuPDFExtractor) Available() bool {
+	e.checkOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		e.checkErr = e.Check(ctx)
+	})
+	return e.checkErr == nil
+}
+
+// Check verifies that the interpreter exists and can import every module
+// the script needs, and explains how to fix it if not.
+func (e *PyMuPDFExtractor) Check(ctx context.Context) error {
+	python := e.python()
+	if _, err := exec.LookPath(python); err != nil {
+		return fmt.Errorf("python interpreter %q not found (set PYTHON_BIN)", python)
+	}
+
+	for _, module := range pythonModules {
+		var stderr bytes.Buffer
+		cmd := commandContext(ctx, python, "-c", "import "+module)
+		cmd.Stderr = &stderr
+		if err := runCommand(ctx, cmd); err != nil {
+			if IsAborted(err) {
+				return err
+			}
+			return fmt.Errorf("%s cannot import %q: %s (install PyMuPDF with `pip install pymupdf` or point PYTHON_BIN at a virtualenv that has it)",
+				python, module, lastLine(stderr.String()))
+		}
+	}
+	return nil
+}
+
+func (e *PyMuPDFExtractor) Extract(ctx context.Context, pdfPath string) ([]PageText, error) {
+	return e.run(ctx, pdfPath)
+}
+
+// ExtractWithImages also saves every image block as PNG or JPEG in imageDir.
+func (e *PyMuPDFExtractor) ExtractWithImages(ctx context.Context, pdfPath string, imageDir string) ([]PageText, error) {
+	return e.run(ctx, pdfPath, imageDir)
+}
+
+func (e *PyMuPDFExtractor) run(ctx context.Context, pdfPath string, imageDir ...string) ([]PageText, error) {
+	var stdout, stderr bytes.Buffer
+	// "-" makes python read the program from stdin, pdfPath becomes sys.argv[1]
+	// and the optional image directory sys.argv[2]
+	args := append([]string{"-", pdfPath}, imageDir...)
+	cmd := commandContext(ctx, e.python(), args...)
+	cmd.Stdin = bytes.NewReader(pymupdfScript)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := runCommand(ctx, cmd); err != nil {
+		return nil, fmt.Errorf("pymupdf: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var raw []struct {
+		Number int     `json:"number"`
+		Width  float64 `json:"width"`
+		Height float64 `json:"height"`
+		Blocks []struct {
+			Text string     `json:"text"`
+			BBox [4]float64 `json:"bbox"`
+		} `json:"blocks"`
+		Images []struct {
+			Path string     `json:"path"`
+			BBox [4]float64 `json:"bbox"`
+		} `json:"images"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &raw); err != nil {
+		return nil, fmt.Errorf("pymupdf output: %w", err)
+	}
+
+	pages := make([]PageText, 0, len(raw))
+	for _, p := range raw {
+		page := PageText{Number: p.Number, Width: p.Width, Height: p.Height}
+		for _, b := range p.Blocks {
+			page.Blocks = append(page.Blocks, TextBlock{
+				Text: b.Text,
+				X0:   b.BBox[0], Y0: b.BBox[1], X1: b.BBox[2], Y1: b.BBox[3],
+			})
+		}
+		for _, img := range p.Images {
+			if img.BBox[2]-img.BBox[0] < minImageSize || img.BBox[3]-img.BBox[1] < minImageSize {
+				continue
+			}
+			page.Images = append(page.Images, ImageBlock{
+				Path: img.Path,
+				X0:   img.BBox[0], Y0: img.BBox[1], X1: img.BBox[2], Y1: img.BBox[3],
+			})
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+func (e *PyMuPDFExtractor) python() string {
+	if e.Python != "" {
+		return e.Python
+	}
+	return "python3"
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
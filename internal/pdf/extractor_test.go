@@ -0,0 +1,36 @@
+package pdf
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestPyMuPDFExtractor_Check(t *testing.T) {
+	missing := &PyMuPDFExtractor{Python: "python-does-not-exist"}
+	if err := missing.Check(context.Background()); err == nil || !strings.Contains(err.Error(), "PYTHON_BIN") {
+		t.Errorf("expected a PYTHON_BIN hint for a missing interpreter, got %v", err)
+	}
+	if missing.Available() {
+		t.Error("extractor with a missing interpreter must not be available")
+	}
+
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found, skipping module check")
+	}
+
+	err := (&PyMuPDFExtractor{}).Check(context.Background())
+	if err == nil {
+		t.Skip("PyMuPDF is installed, nothing to report")
+	}
+	if !strings.Contains(err.Error(), `"fitz"`) {
+		t.Errorf("expected the error to name the missing module, got %v", err)
+	}
+}
+
+func TestResolveExtractor_Unknown(t *testing.T) {
+	if _, err := ResolveExtractor(context.Background(), "tika", ""); err == nil {
+		t.Error("expected error for unknown extractor")
+	}
+}
@@ -0,0 +1,259 @@
+package pdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FilterRules decides which text blocks the converters drop before
+// linearization. The zero value drops nothing.
+type FilterRules struct {
+	Name string `json:"name"`
+
+	// Drop holds regular expressions (Go syntax, use (?i) for case
+	// insensitive matching). A block whose text matches any of them is
+	// skipped.
+	Drop []string `json:"drop"`
+
+	// HeaderFooter, when set, drops running headers and footers: blocks
+	// near the top or bottom edge whose text (digits ignored) repeats at
+	// the same height on several pages.
+	HeaderFooter *HeaderFooterRule `json:"header_footer,omitempty"`
+
+	compiled []*regexp.Regexp
+}
+
+// HeaderFooterRule configures running header/footer detection.
+type HeaderFooterRule struct {
+	// Band is the fraction of the page height at the top and at the bottom
+	// that is searched. Default 0.12.
+	Band float64 `json:"band"`
+	// MinPages is how many pages a block must repeat on. Default 3, but
+	// never more than the page count.
+	MinPages int `json:"min_pages"`
+	// Tolerance is how far (in points) the repeats may drift vertically.
+	// Default 6.
+	Tolerance float64 `json:"tolerance"`
+}
+
+// filterProfiles are the built-in named rule sets.
+var filterProfiles = map[string]FilterRules{
+	// The cross-references and rubric names the converter used to drop
+	// unconditionally, written for one Bulgarian newspaper.
+	"newspaper-bg": {
+		Name: "newspaper-bg",
+		Drop: []string{
+			`(?i)на стр\.`,
+			`(?i)от стр\.`,
+			`(?i)квантов`,
+			`(?i)преход`,
+			`(?i)^източник:$`,
+		},
+		HeaderFooter: &HeaderFooterRule{},
+	},
+	// Only running headers, footers and page numbers.
+	"headers-footers": {
+		Name:         "headers-footers",
+		HeaderFooter: &HeaderFooterRule{},
+	},
+}
+
+// FilterProfiles returns the names of the built-in profiles and of the
+// *.json files in dir (dir may be empty).
+func FilterProfiles(dir string) []string {
+	names := make([]string, 0, len(filterProfiles))
+	for name := range filterProfiles {
+		names = append(names, name)
+	}
+	if dir != "" {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, file := range files {
+			name := strings.TrimSuffix(filepath.Base(file), ".json")
+			if _, builtin := filterProfiles[name]; !builtin {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FilterProfile returns the profile called name: a built-in one or
+// dir/<name>.json. Names containing path separators are rejected, so the
+// name can come straight from a form field.
+func FilterProfile(name, dir string) (*FilterRules, error) {
+	if rules, ok := filterProfiles[name]; ok {
+		rules.Drop = append([]string(nil), rules.Drop...)
+		if err := rules.Compile(); err != nil {
+			return nil, err
+		}
+		return &rules, nil
+	}
+
+	if dir != "" && name != "" && name == filepath.Base(name) && !strings.HasPrefix(name, ".") {
+		path := filepath.Join(dir, name+".json")
+		if _, err := os.Stat(path); err == nil {
+			return LoadFilterRules(path)
+		}
+	}
+
+	return nil, fmt.Errorf("unknown filter profile %q (available: %s)", name, strings.Join(FilterProfiles(dir), ", "))
+}
+
+// LoadFilterRules reads rules from a JSON file such as
+//
+//	{"name": "report", "drop": ["(?i)^confidential$"], "header_footer": {"min_pages": 2}}
+func LoadFilterRules(path string) (*FilterRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules FilterRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("filter rules %s: %w", path, err)
+	}
+	if rules.Name == "" {
+		rules.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := rules.Compile(); err != nil {
+		return nil, fmt.Errorf("filter rules %s: %w", path, err)
+	}
+	return &rules, nil
+}
+
+// ResolveFilterRules accepts a profile name (see FilterProfile) or a path to
+// a JSON file, recognised by its .json extension. Empty means no filtering.
+func ResolveFilterRules(nameOrPath, dir string) (*FilterRules, error) {
+	switch {
+	case nameOrPath == "":
+		return nil, nil
+	case strings.HasSuffix(strings.ToLower(nameOrPath), ".json"):
+		return LoadFilterRules(nameOrPath)
+	default:
+		return FilterProfile(nameOrPath, dir)
+	}
+}
+
+// Compile checks the Drop expressions. It is called by the loaders; rules
+// built in code are compiled on first use.
+func (r *FilterRules) Compile() error {
+	r.compiled = r.compiled[:0]
+	for _, expr := range r.Drop {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid drop rule %q: %w", expr, err)
+		}
+		r.compiled = append(r.compiled, re)
+	}
+	return nil
+}
+
+// drops reports whether a Drop rule matches text.
+func (r *FilterRules) drops(text string) bool {
+	for _, re := range r.compiled {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply removes the dropped blocks from pages and returns the filtered copy.
+func (r *FilterRules) apply(pages []PageText) ([]PageText, error) {
+	if r == nil {
+		return pages, nil
+	}
+	if len(r.compiled) != len(r.Drop) {
+		if err := r.Compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	var repeated map[[2]int]bool
+	if r.HeaderFooter != nil {
+		repeated = r.HeaderFooter.detect(pages)
+	}
+
+	filtered := make([]PageText, len(pages))
+	for p, page := range pages {
+		filtered[p] = page
+		filtered[p].Blocks = nil
+		for b, block := range page.Blocks {
+			if repeated[[2]int{p, b}] || r.drops(strings.TrimSpace(block.Text)) {
+				continue
+			}
+			filtered[p].Blocks = append(filtered[p].Blocks, block)
+		}
+	}
+	return filtered, nil
+}
+
+// detect returns the (page, block) indexes of running headers and footers.
+func (h *HeaderFooterRule) detect(pages []PageText) map[[2]int]bool {
+	band, minPages, tolerance := h.Band, h.MinPages, h.Tolerance
+	if band <= 0 {
+		band = 0.12
+	}
+	if minPages <= 0 {
+		minPages = 3
+	}
+	if tolerance <= 0 {
+		tolerance = 6
+	}
+	minPages = min(minPages, len(pages))
+	if minPages < 2 {
+		return nil
+	}
+
+	type occurrence struct{ page, block int }
+	groups := map[string][]occurrence{}
+
+	for p, page := range pages {
+		for b, block := range page.Blocks {
+			var edge string
+			switch {
+			case page.Height > 0 && block.Y1 <= page.Height*band:
+				edge = "top"
+			case page.Height > 0 && block.Y0 >= page.Height*(1-band):
+				edge = "bottom"
+			default:
+				continue
+			}
+			// Page numbers differ on every page, so digits are ignored.
+			key := fmt.Sprintf("%s|%.0f|%s", edge, math.Round(block.Y0/tolerance), normalizeRepeated(block.Text))
+			groups[key] = append(groups[key], occurrence{p, b})
+		}
+	}
+
+	repeated := map[[2]int]bool{}
+	for _, occ := range groups {
+		seen := map[int]bool{}
+		for _, o := range occ {
+			seen[o.page] = true
+		}
+		if len(seen) < minPages {
+			continue
+		}
+		for _, o := range occ {
+			repeated[[2]int{o.page, o.block}] = true
+		}
+	}
+	return repeated
+}
+
+func normalizeRepeated(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return '#'
+		}
+		return unicode.ToLower(r)
+	}, collapseSpaces(text))
+}
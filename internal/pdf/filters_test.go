@@ -0,0 +1,135 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func blockTexts(pages []PageText) [][]string {
+	var out [][]string
+	for _, page := range pages {
+		var texts []string
+		for _, block := range page.Blocks {
+			texts = append(texts, block.Text)
+		}
+		out = append(out, texts)
+	}
+	return out
+}
+
+func TestFilterRules_Drop(t *testing.T) {
+	rules, err := FilterProfile("newspaper-bg", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages := []PageText{{
+		Height: 842,
+		Blocks: []TextBlock{
+			{Text: "Продължава на стр. 5", Y0: 400, Y1: 410},
+			{Text: "Квантови компютри", Y0: 300, Y1: 320},
+			{Text: "Източник:\n", Y0: 500, Y1: 510},
+			{Text: "Източник: БТА", Y0: 520, Y1: 530},
+			{Text: "Обикновен текст.", Y0: 600, Y1: 610},
+		},
+	}}
+
+	got, err := rules.apply(pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"Източник: БТА", "Обикновен текст."}}
+	if !reflect.DeepEqual(blockTexts(got), want) {
+		t.Errorf("apply() = %q, want %q", blockTexts(got), want)
+	}
+}
+
+func TestFilterRules_NilKeepsEverything(t *testing.T) {
+	var rules *FilterRules
+	pages := []PageText{{Blocks: []TextBlock{{Text: "на стр. 3"}}}}
+
+	got, err := rules.apply(pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, pages) {
+		t.Errorf("nil rules changed the pages: %v", got)
+	}
+}
+
+func TestHeaderFooterRule(t *testing.T) {
+	var pages []PageText
+	for i, number := range []string{"1", "2", "3"} {
+		pages = append(pages, PageText{
+			Number: i + 1,
+			Height: 842,
+			Blocks: []TextBlock{
+				{Text: "Annual Report 2024", Y0: 20, Y1: 35},
+				{Text: "Body text on page " + number, Y0: 100, Y1: 700},
+				{Text: "Page " + number + " of 3", Y0: 810, Y1: 822},
+			},
+		})
+	}
+	// Same text in the body of one page is not a header.
+	pages[1].Blocks = append(pages[1].Blocks, TextBlock{Text: "Annual Report 2024", Y0: 400, Y1: 415})
+
+	rules := &FilterRules{HeaderFooter: &HeaderFooterRule{}}
+	got, err := rules.apply(pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"Body text on page 1"},
+		{"Body text on page 2", "Annual Report 2024"},
+		{"Body text on page 3"},
+	}
+	if !reflect.DeepEqual(blockTexts(got), want) {
+		t.Errorf("apply() = %q, want %q", blockTexts(got), want)
+	}
+
+	// A single page has nothing to compare against.
+	if got := (&HeaderFooterRule{}).detect(pages[:1]); len(got) != 0 {
+		t.Errorf("detect() on one page = %v, want nothing", got)
+	}
+}
+
+func TestResolveFilterRules(t *testing.T) {
+	dir := t.TempDir()
+	custom := `{"drop": ["(?i)^confidential$"], "header_footer": {"min_pages": 2}}`
+	if err := os.WriteFile(filepath.Join(dir, "report.json"), []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if rules, err := ResolveFilterRules("", dir); err != nil || rules != nil {
+		t.Errorf(`ResolveFilterRules("") = %v, %v; want nil, nil`, rules, err)
+	}
+
+	rules, err := ResolveFilterRules("report", dir)
+	if err != nil {
+		t.Fatalf("profile from dir: %v", err)
+	}
+	if rules.Name != "report" || !rules.drops("CONFIDENTIAL") || rules.HeaderFooter.MinPages != 2 {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	if _, err := ResolveFilterRules(filepath.Join(dir, "report.json"), ""); err != nil {
+		t.Errorf("path: %v", err)
+	}
+
+	for _, name := range []string{"missing", "../report", ".hidden"} {
+		if _, err := FilterProfile(name, dir); err == nil {
+			t.Errorf("FilterProfile(%q) should fail", name)
+		}
+	}
+
+	if err := (&FilterRules{Drop: []string{"("}}).Compile(); err == nil {
+		t.Error("invalid regexp should fail to compile")
+	}
+
+	if got := FilterProfiles(dir); !reflect.DeepEqual(got, []string{"headers-footers", "newspaper-bg", "report"}) {
+		t.Errorf("FilterProfiles() = %v", got)
+	}
+}
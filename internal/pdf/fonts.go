@@ -0,0 +1,169 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// standardFont is one of the standard 14 PDF fonts, which every viewer has
+// and which therefore need not be embedded.
+type standardFont struct {
+	base   string // /BaseFont
+	widths []int  // advance widths of ASCII 32-126 in 1/1000 em, nil for monospaced
+}
+
+// fonts are the text fonts, by the name users pick them with. Widths are
+// from the Adobe font metrics.
+var fonts = map[string]standardFont{
+	"helvetica": {base: "Helvetica", widths: []int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556,
+		278, 278, 584, 584, 584, 556, 1015,
+		667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833,
+		722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611,
+		278, 278, 278, 469, 556, 333,
+		556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833,
+		556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500,
+		334, 260, 334, 584,
+	}},
+	"helvetica-bold": {base: "Helvetica-Bold", widths: []int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556,
+		333, 333, 584, 584, 584, 611, 975,
+		722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833,
+		722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611,
+		333, 278, 333, 584, 556, 333,
+		556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889,
+		611, 611, 611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500,
+		389, 280, 389, 584,
+	}},
+	"times": {base: "Times-Roman", widths: []int{
+		250, 333, 408, 500, 500, 833, 778, 180, 333, 333, 500, 564, 250, 333, 250, 278,
+		500, 500, 500, 500, 500, 500, 500, 500, 500, 500,
+		278, 278, 564, 564, 564, 444, 921,
+		722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889,
+		722, 722, 556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611,
+		333, 278, 333, 469, 500, 333,
+		444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778,
+		500, 500, 500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444,
+		480, 200, 480, 541,
+	}},
+	"times-bold": {base: "Times-Bold", widths: []int{
+		250, 333, 555, 500, 500, 1000, 833, 278, 333, 333, 500, 570, 250, 333, 250, 278,
+		500, 500, 500, 500, 500, 500, 500, 500, 500, 500,
+		333, 333, 570, 570, 570, 500, 930,
+		722, 667, 722, 722, 667, 611, 778, 778, 389, 500, 778, 667, 944,
+		722, 778, 611, 778, 722, 556, 667, 722, 722, 1000, 722, 722, 667,
+		333, 278, 333, 581, 500, 333,
+		500, 556, 444, 556, 444, 333, 500, 556, 278, 333, 556, 278, 833,
+		556, 500, 556, 556, 444, 389, 333, 556, 500, 722, 500, 500, 444,
+		394, 220, 394, 520,
+	}},
+	"courier":      {base: "Courier"},
+	"courier-bold": {base: "Courier-Bold"},
+}
+
+// FontNames lists the fonts text can be written in.
+var FontNames = []string{"helvetica", "helvetica-bold", "times", "times-bold", "courier", "courier-bold"}
+
+// lookupFont returns the font called name; empty means Helvetica.
+func lookupFont(name string) (standardFont, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = "helvetica"
+	}
+	font, ok := fonts[name]
+	if !ok {
+		return standardFont{}, fmt.Errorf("unknown font %q (use %s)", name, strings.Join(FontNames, ", "))
+	}
+	return font, nil
+}
+
+// textWidth returns the width of text, encoded with winAnsi, at size points.
+func (f standardFont) textWidth(text []byte, size float64) float64 {
+	total := 0
+	for _, c := range text {
+		switch {
+		case f.widths == nil:
+			total += 600
+		case c >= 32 && c <= 126:
+			total += f.widths[c-32]
+		default:
+			// Accented letters are about as wide as an "o"
+			total += f.widths['o'-32]
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// fontObject is the font dictionary, with Latin-1 text as WinAnsiEncoding.
+func (f standardFont) fontObject() string {
+	return fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.base)
+}
+
+// winAnsi encodes text for the standard fonts. Only Latin-1 characters can
+// be written without embedding a font.
+func winAnsi(text string) ([]byte, error) {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			out = append(out, ' ')
+		case r >= 32 && r <= 126, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		default:
+			return nil, fmt.Errorf("character %q is not supported by the standard fonts (Latin-1 only)", r)
+		}
+	}
+	return out, nil
+}
+
+// latin1 replaces the characters winAnsi rejects with "?", for text that
+// comes from file names rather than from the user.
+func latin1(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 32 && r <= 126 || r >= 0xa0 && r <= 0xff || r == '\t' || r == '\n' || r == '\r' {
+			return r
+		}
+		return '?'
+	}, text)
+}
+
+// pdfString writes encoded text as a PDF literal string.
+func pdfString(text []byte) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range text {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c > 126:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// ParseColor reads a hex color, "#rrggbb" or "#rgb", into RGB components
+// between 0 and 1. Empty means black.
+func ParseColor(s string) ([3]float64, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if hex == "" {
+		return [3]float64{}, nil
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	var r, g, b uint8
+	if len(hex) != 6 {
+		return [3]float64{}, fmt.Errorf("invalid color %q (use #rrggbb)", s)
+	}
+	if _, err := fmt.Sscanf(hex, "%02x%02x%02x", &r, &g, &b); err != nil {
+		return [3]float64{}, fmt.Errorf("invalid color %q (use #rrggbb)", s)
+	}
+	return [3]float64{float64(r) / 255, float64(g) / 255, float64(b) / 255}, nil
+}
@@ -0,0 +1,41 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// magickBinary returns ImageMagick 7's "magick" or, failing that, the
+// version 6 "convert".
+func magickBinary() (string, error) {
+	for _, name := range []string{"magick", "convert"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("ImageMagick (magick or convert) is not installed")
+}
+
+// ImageMagickAvailable reports whether ImageMagick is on PATH.
+func ImageMagickAvailable() bool {
+	_, err := magickBinary()
+	return err == nil
+}
+
+// runMagick runs ImageMagick with args.
+func runMagick(ctx context.Context, args ...string) error {
+	bin, err := magickBinary()
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := commandContext(ctx, bin, args...)
+	cmd.Stderr = &stderr
+	if err := runCommand(ctx, cmd); err != nil {
+		return fmt.Errorf("%s: %w: %s", bin, err, lastLine(stderr.String()))
+	}
+	return nil
+}
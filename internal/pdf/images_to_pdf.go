@@ -0,0 +1,442 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PageSize is the paper of ImagesToPDF.
+type PageSize string
+
+const (
+	PageA4     PageSize = "a4"
+	PageLetter PageSize = "letter"
+	// PageFit makes every page the size of its image plus the margins.
+	PageFit PageSize = "fit"
+)
+
+// ParsePageSize accepts a4, letter and fit; empty means A4.
+func ParsePageSize(s string) (PageSize, error) {
+	switch size := PageSize(strings.ToLower(strings.TrimSpace(s))); size {
+	case "":
+		return PageA4, nil
+	case PageA4, PageLetter, PageFit:
+		return size, nil
+	default:
+		return "", fmt.Errorf("unknown page size %q (use a4, letter or fit)", s)
+	}
+}
+
+// points returns the portrait width and height in points.
+func (s PageSize) points() (float64, float64) {
+	if s == PageLetter {
+		return 612, 792
+	}
+	return 595.28, 841.89
+}
+
+// Orientation of A4 and Letter pages.
+type Orientation string
+
+const (
+	// OrientationAuto turns the page landscape for images wider than tall.
+	OrientationAuto      Orientation = "auto"
+	OrientationPortrait  Orientation = "portrait"
+	OrientationLandscape Orientation = "landscape"
+)
+
+// ParseOrientation accepts auto, portrait and landscape; empty means auto.
+func ParseOrientation(s string) (Orientation, error) {
+	switch o := Orientation(strings.ToLower(strings.TrimSpace(s))); o {
+	case "":
+		return OrientationAuto, nil
+	case OrientationAuto, OrientationPortrait, OrientationLandscape:
+		return o, nil
+	default:
+		return "", fmt.Errorf("unknown orientation %q (use auto, portrait or landscape)", s)
+	}
+}
+
+// defaultImageDPI sizes images without a resolution in their metadata on
+// PageFit pages.
+const defaultImageDPI = 96
+
+// ImagesToPDF puts images on PDF pages, one image per page. JPEG files are
+// embedded as they are; PNG and GIF are decoded and deflated. TIFF (also
+// multi-page), WebP and other formats are converted to PNG with ImageMagick
+// first.
+type ImagesToPDF struct {
+	PageSize    PageSize    // "" means PageA4
+	Orientation Orientation // "" means OrientationAuto
+
+	// Margin around the image in millimetres.
+	Margin float64
+
+	// AutoRotate turns images upright according to their EXIF orientation.
+	AutoRotate bool
+}
+
+func NewImagesToPDF() *ImagesToPDF {
+	return &ImagesToPDF{PageSize: PageA4, Orientation: OrientationAuto, AutoRotate: true}
+}
+
+// ImagesToPDFResult describes a written PDF.
+type ImagesToPDFResult struct {
+	PageCount int
+}
+
+// Convert writes images, in order, to outputPath.
+func (c *ImagesToPDF) Convert(ctx context.Context, images []string, outputPath string) (*ImagesToPDFResult, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no images given")
+	}
+	// NaN passes any range check and would end up in the MediaBox
+	if math.IsNaN(c.Margin) || c.Margin < 0 || c.Margin > 100 {
+		return nil, fmt.Errorf("margin must be between 0 and 100 mm, got %g", c.Margin)
+	}
+
+	workDir, err := os.MkdirTemp(filepath.Dir(outputPath), "images_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	w := newPDFWriter()
+	// The page tree is written once all pages are known
+	pagesID := w.reserve()
+	catalogID := w.add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	var kids []string
+	for i, path := range images {
+		if err := ctx.Err(); err != nil {
+			return nil, contextError(ctx)
+		}
+
+		pictures, err := c.load(ctx, path, filepath.Join(workDir, fmt.Sprint(i)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		for _, pic := range pictures {
+			kids = append(kids, fmt.Sprintf("%d 0 R", c.addPage(w, pagesID, pic)))
+		}
+	}
+
+	w.addAt(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	if err := os.WriteFile(outputPath, w.finish(catalogID), 0644); err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+	return &ImagesToPDFResult{PageCount: len(kids)}, nil
+}
+
+// picture is an image ready to be embedded as an XObject.
+type picture struct {
+	dict        string // XObject entries besides /Type, /Subtype and /Length
+	data        []byte
+	width       int // pixels, as stored
+	height      int
+	dpi         float64
+	orientation int // EXIF orientation 1-8
+
+	// mask is the deflated 8-bit alpha channel, nil for opaque images
+	mask []byte
+}
+
+// load reads one input file into one or more pictures.
+func (c *ImagesToPDF) load(ctx context.Context, path string, workPrefix string) ([]picture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		pic, err := jpegPicture(data)
+		if err != nil {
+			return nil, err
+		}
+		if !c.AutoRotate {
+			pic.orientation = 1
+		}
+		return []picture{pic}, nil
+	case bytes.HasPrefix(data, []byte("\x89PNG")), bytes.HasPrefix(data, []byte("GIF8")):
+		pic, err := decodedPicture(data)
+		if err != nil {
+			return nil, err
+		}
+		return []picture{pic}, nil
+	}
+
+	// TIFF pages and anything else ImageMagick reads become PNG files
+	args := []string{path}
+	if c.AutoRotate {
+		args = append(args, "-auto-orient")
+	}
+	args = append(args, workPrefix+"-%04d.png")
+	if err := runMagick(ctx, args...); err != nil {
+		return nil, fmt.Errorf("unsupported image: %w", err)
+	}
+
+	pages, _ := filepath.Glob(workPrefix + "-*.png")
+	var pictures []picture
+	for _, page := range pages {
+		data, err := os.ReadFile(page)
+		if err != nil {
+			return nil, err
+		}
+		pic, err := decodedPicture(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(page), err)
+		}
+		pictures = append(pictures, pic)
+	}
+	if len(pictures) == 0 {
+		return nil, fmt.Errorf("no images found")
+	}
+	return pictures, nil
+}
+
+// addPage writes the image, its content stream and the page and returns
+// the page's object number.
+func (c *ImagesToPDF) addPage(w *pdfWriter, pagesID int, pic picture) int {
+	dpi := pic.dpi
+	if dpi <= 0 {
+		dpi = defaultImageDPI
+	}
+
+	// Displayed size in points, after the EXIF rotation
+	imgW, imgH := float64(pic.width)*72/dpi, float64(pic.height)*72/dpi
+	if pic.orientation >= 5 {
+		imgW, imgH = imgH, imgW
+	}
+
+	margin := c.Margin * 72 / 25.4
+	var pageW, pageH float64
+	if c.PageSize == PageFit {
+		pageW, pageH = imgW+2*margin, imgH+2*margin
+	} else {
+		pageW, pageH = c.PageSize.points()
+		landscape := c.Orientation == OrientationLandscape || (c.Orientation != OrientationPortrait && imgW > imgH)
+		if landscape {
+			pageW, pageH = pageH, pageW
+		}
+		scale := min((pageW-2*margin)/imgW, (pageH-2*margin)/imgH)
+		imgW, imgH = imgW*scale, imgH*scale
+	}
+
+	x, y := (pageW-imgW)/2, (pageH-imgH)/2
+	m := orientationMatrix(pic.orientation, x, y, imgW, imgH)
+	content := fmt.Sprintf("q %.4f %.4f %.4f %.4f %.4f %.4f cm /Im0 Do Q", m[0], m[1], m[2], m[3], m[4], m[5])
+
+	imageID := w.addStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d %s", pic.width, pic.height, pic.dict), pic.data)
+	contentID := w.addStream("", []byte(content))
+	return w.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Contents %d 0 R /Resources << /XObject << /Im0 %d 0 R >> >> >>",
+		pagesID, pageW, pageH, contentID, imageID))
+}
+
+// orientationMatrix returns the cm operands that draw an image stored with
+// EXIF orientation o upright into the box x, y, w, h (the displayed size).
+// PDF draws images into the unit square, row 0 at the top.
+func orientationMatrix(o int, x, y, w, h float64) [6]float64 {
+	switch o {
+	case 2: // mirrored horizontally
+		return [6]float64{-w, 0, 0, h, x + w, y}
+	case 3: // rotated 180°
+		return [6]float64{-w, 0, 0, -h, x + w, y + h}
+	case 4: // mirrored vertically
+		return [6]float64{w, 0, 0, -h, x, y + h}
+	case 5: // transposed
+		return [6]float64{0, -h, -w, 0, x + w, y + h}
+	case 6: // needs 90° clockwise
+		return [6]float64{0, -h, w, 0, x, y + h}
+	case 7: // transversed
+		return [6]float64{0, h, w, 0, x, y}
+	case 8: // needs 90° counter-clockwise
+		return [6]float64{0, h, -w, 0, x + w, y}
+	default:
+		return [6]float64{w, 0, 0, h, x, y}
+	}
+}
+
+// jpegPicture embeds a JPEG file unchanged (DCTDecode).
+func jpegPicture(data []byte) (picture, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return picture{}, err
+	}
+
+	colorSpace := "/DeviceRGB"
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		colorSpace = "/DeviceGray"
+	case color.CMYKModel:
+		// Adobe writes CMYK JPEGs inverted
+		colorSpace = "/DeviceCMYK /Decode [1 0 1 0 1 0 1 0]"
+	}
+
+	orientation, dpi := jpegMetadata(data)
+	return picture{
+		dict:        fmt.Sprintf("/ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode", colorSpace),
+		data:        data,
+		width:       cfg.Width,
+		height:      cfg.Height,
+		dpi:         dpi,
+		orientation: orientation,
+	}, nil
+}
+
+// decodedPicture decodes a PNG or GIF and deflates its pixels as 8-bit gray
+// or RGB. Transparency is flattened onto white.
+func decodedPicture(data []byte) (picture, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return picture{}, err
+	}
+	b := img.Bounds()
+
+	gray := false
+	switch img.(type) {
+	case *image.Gray, *image.Gray16:
+		gray = true
+	}
+
+	var raw bytes.Buffer
+	raw.Grow(b.Dx() * b.Dy() * 3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			// Composite onto white: c + (1-a) with 16-bit channels
+			white := 0xffff - a
+			r, g, bl = (r+white)>>8, (g+white)>>8, (bl+white)>>8
+			if gray {
+				raw.WriteByte(byte(r))
+			} else {
+				raw.Write([]byte{byte(r), byte(g), byte(bl)})
+			}
+		}
+	}
+
+	colorSpace := "/DeviceRGB"
+	if gray {
+		colorSpace = "/DeviceGray"
+	}
+	return picture{
+		dict:        fmt.Sprintf("/ColorSpace %s /BitsPerComponent 8 /Filter /FlateDecode", colorSpace),
+		data:        deflate(raw.Bytes()),
+		width:       b.Dx(),
+		height:      b.Dy(),
+		dpi:         pngDPI(data),
+		orientation: 1,
+	}, nil
+}
+
+// deflate compresses data for /FlateDecode.
+func deflate(data []byte) []byte {
+	var deflated bytes.Buffer
+	zw, _ := zlib.NewWriterLevel(&deflated, zlib.BestCompression)
+	zw.Write(data)
+	zw.Close()
+	return deflated.Bytes()
+}
+
+// jpegMetadata reads the EXIF orientation (1 when missing) and the JFIF
+// resolution (0 when unknown) from the JPEG headers.
+func jpegMetadata(data []byte) (orientation int, dpi float64) {
+	orientation = 1
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			break
+		}
+		marker := data[i+1]
+		if marker == 0xda || marker == 0xd9 { // image data starts
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+		segment := data[i+4 : end]
+
+		switch {
+		case marker == 0xe0 && bytes.HasPrefix(segment, []byte("JFIF\x00")) && len(segment) >= 12:
+			unit, xDensity := segment[7], float64(binary.BigEndian.Uint16(segment[8:]))
+			switch unit {
+			case 1:
+				dpi = xDensity
+			case 2: // dots per cm
+				dpi = xDensity * 2.54
+			}
+		case marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")):
+			if o := exifOrientation(segment[6:]); o >= 1 && o <= 8 {
+				orientation = o
+			}
+		}
+		i = end
+	}
+	return orientation, dpi
+}
+
+// exifOrientation returns tag 0x0112 of the first IFD of a TIFF structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := range count {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 0
+}
+
+// pngDPI reads the pHYs chunk of a PNG (0 when missing or not in metres).
+func pngDPI(data []byte) float64 {
+	if !bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		return 0
+	}
+	for i := 8; i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		kind := string(data[i+4 : i+8])
+		if kind == "IDAT" || i+12+length > len(data) {
+			break
+		}
+		if kind == "pHYs" && length == 9 && data[i+16] == 1 {
+			return float64(binary.BigEndian.Uint32(data[i+8:])) * 0.0254
+		}
+		i += 12 + length
+	}
+	return 0
+}
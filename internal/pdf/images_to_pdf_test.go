@@ -0,0 +1,146 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// withEXIFOrientation inserts an APP1 segment with orientation o after the
+// SOI marker of a JPEG.
+func withEXIFOrientation(jpg []byte, o byte) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // big endian header, IFD at 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, o, 0, 0, // orientation, SHORT
+		0, 0, 0, 0, // no next IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	segment = append(segment, payload...)
+	return append(append(append([]byte{}, jpg[:2]...), segment...), jpg[2:]...)
+}
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+func TestJPEGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, testImage(8, 4), nil)
+
+	if o, _ := jpegMetadata(buf.Bytes()); o != 1 {
+		t.Errorf("orientation without EXIF = %d, want 1", o)
+	}
+	if o, _ := jpegMetadata(withEXIFOrientation(buf.Bytes(), 6)); o != 6 {
+		t.Errorf("orientation = %d, want 6", o)
+	}
+}
+
+func TestOrientationMatrix(t *testing.T) {
+	// Where the stored top-left pixel (unit square 0,1) ends up in a 10x20
+	// box at the origin.
+	want := map[int][2]float64{
+		1: {0, 20}, 2: {10, 20}, 3: {10, 0}, 4: {0, 0},
+		5: {0, 20}, 6: {10, 20}, 7: {10, 0}, 8: {0, 0},
+	}
+	for o, corner := range want {
+		m := orientationMatrix(o, 0, 0, 10, 20)
+		x, y := m[2]+m[4], m[3]+m[5] // u=0, v=1
+		if x != corner[0] || y != corner[1] {
+			t.Errorf("orientation %d: top-left pixel at %v,%v, want %v", o, x, y, corner)
+		}
+	}
+}
+
+func TestImagesToPDF_Convert(t *testing.T) {
+	dir := t.TempDir()
+
+	var jpg bytes.Buffer
+	jpeg.Encode(&jpg, testImage(40, 20), nil)
+	jpgPath := filepath.Join(dir, "photo.jpg")
+	os.WriteFile(jpgPath, withEXIFOrientation(jpg.Bytes(), 6), 0644)
+
+	pngPath := filepath.Join(dir, "scan.png")
+	f, _ := os.Create(pngPath)
+	png.Encode(f, testImage(20, 40))
+	f.Close()
+
+	out := filepath.Join(dir, "out.pdf")
+	c := NewImagesToPDF()
+	c.Margin = 10
+
+	res, err := c.Convert(context.Background(), []string{jpgPath, pngPath}, out)
+	if err != nil {
+		t.Fatalf("Convert returned error: %v", err)
+	}
+	if res.PageCount != 2 {
+		t.Errorf("PageCount = %d, want 2", res.PageCount)
+	}
+
+	data, _ := os.ReadFile(out)
+	// The rotated photo is taller than wide, so both pages are portrait A4
+	if n := len(regexp.MustCompile(`/MediaBox \[0 0 595\.28 841\.89\]`).FindAll(data, -1)); n != 2 {
+		t.Errorf("found %d portrait A4 pages, want 2", n)
+	}
+	if !bytes.Contains(data, jpg.Bytes()[2:]) {
+		t.Error("JPEG was not embedded unchanged")
+	}
+
+	if _, err := exec.LookPath("qpdf"); err == nil {
+		if out, err := exec.Command("qpdf", "--check", out).CombinedOutput(); err != nil {
+			t.Errorf("qpdf --check failed: %v\n%s", err, out)
+		}
+	}
+}
+
+func TestImagesToPDF_Fit(t *testing.T) {
+	dir := t.TempDir()
+	pngPath := filepath.Join(dir, "a.png")
+	f, _ := os.Create(pngPath)
+	png.Encode(f, testImage(96, 192)) // 72x144 pt at the default 96 dpi
+	f.Close()
+
+	c := NewImagesToPDF()
+	c.PageSize = PageFit
+	out := filepath.Join(dir, "out.pdf")
+	if _, err := c.Convert(context.Background(), []string{pngPath}, out); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(out)
+	if !bytes.Contains(data, []byte("/MediaBox [0 0 72.00 144.00]")) {
+		t.Error("fit page does not match the image size")
+	}
+}
+
+func TestImagesToPDF_InvalidMargin(t *testing.T) {
+	dir := t.TempDir()
+	pngPath := filepath.Join(dir, "a.png")
+	f, _ := os.Create(pngPath)
+	png.Encode(f, testImage(10, 10))
+	f.Close()
+
+	for _, margin := range []float64{-1, 101, math.NaN(), math.Inf(1)} {
+		c := NewImagesToPDF()
+		c.Margin = margin
+		if _, err := c.Convert(context.Background(), []string{pngPath}, filepath.Join(dir, "out.pdf")); err == nil {
+			t.Errorf("margin %g: expected an error", margin)
+		}
+	}
+}
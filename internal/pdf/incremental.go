@@ -0,0 +1,261 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// startxrefPattern finds the offset of the last cross-reference section.
+var startxrefPattern = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF`)
+
+// incrementalUpdate appends objects to an existing PDF without touching its
+// bytes, as an incremental update (ISO 32000-1, 7.5.6). Signatures over the
+// earlier revisions stay valid. Objects are numbered on from the file's
+// highest object number; rewritten objects keep their number.
+type incrementalUpdate struct {
+	base    []byte
+	buf     bytes.Buffer
+	offsets map[int]int // object number -> offset in the whole file
+	gens    map[int]int
+	nextID  int
+}
+
+func newIncrementalUpdate(base []byte, maxObjectID int) *incrementalUpdate {
+	u := &incrementalUpdate{base: base, offsets: map[int]int{}, gens: map[int]int{}, nextID: maxObjectID + 1}
+	if !bytes.HasSuffix(base, []byte("\n")) {
+		u.buf.WriteByte('\n')
+	}
+	return u
+}
+
+// offset returns the offset in the whole file of the next byte written.
+func (u *incrementalUpdate) offset() int {
+	return len(u.base) + u.buf.Len()
+}
+
+// newRef allocates an object number and returns its "N 0 R" reference.
+func (u *incrementalUpdate) newRef() string {
+	ref := fmt.Sprintf("%d 0 R", u.nextID)
+	u.nextID++
+	return ref
+}
+
+// write writes obj, in PDF syntax, as the object ref ("N G R").
+func (u *incrementalUpdate) write(ref string, obj string) {
+	var id, gen int
+	fmt.Sscanf(ref, "%d %d R", &id, &gen)
+	u.offsets[id], u.gens[id] = u.offset(), gen
+	fmt.Fprintf(&u.buf, "%d %d obj\n%s\nendobj\n", id, gen, obj)
+}
+
+// writeStream writes a stream object; dict holds the entries besides
+// /Length.
+func (u *incrementalUpdate) writeStream(ref string, dict string, data []byte) {
+	var id, gen int
+	fmt.Sscanf(ref, "%d %d R", &id, &gen)
+	u.offsets[id], u.gens[id] = u.offset(), gen
+	fmt.Fprintf(&u.buf, "%d %d obj\n<< %s /Length %d >>\nstream\n", id, gen, dict, len(data))
+	u.buf.Write(data)
+	u.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// finish writes the cross-reference section and the trailer, chained to
+// the previous one, and returns the whole file. trailer holds the previous
+// trailer's entries (qpdf JSON values); /Size and /Prev are set here. The
+// section is a cross-reference stream when the previous one was, as some
+// readers reject a file that mixes both kinds.
+func (u *incrementalUpdate) finish(trailer map[string]any) ([]byte, error) {
+	m := startxrefPattern.FindAllSubmatch(u.base[max(0, len(u.base)-4096):], -1)
+	if m == nil {
+		return nil, fmt.Errorf("startxref not found, the file is damaged")
+	}
+	prev, _ := strconv.Atoi(string(m[len(m)-1][1]))
+
+	entries := map[string]any{}
+	for _, key := range []string{"/Root", "/Info", "/ID"} {
+		if v, ok := trailer[key]; ok {
+			entries[key] = v
+		}
+	}
+	entries["/Prev"] = prev
+
+	if prev < len(u.base) && xrefStreamPattern.Match(u.base[prev:]) {
+		u.finishXRefStream(trailer, entries)
+	} else {
+		u.finishXRefTable(trailer, entries)
+	}
+	return append(slices.Clip(u.base), u.buf.Bytes()...), nil
+}
+
+// xrefStreamPattern matches the start of an object, which is where a
+// cross-reference stream begins; a table begins with "xref".
+var xrefStreamPattern = regexp.MustCompile(`^\s*\d+\s+\d+\s+obj\b`)
+
+func (u *incrementalUpdate) finishXRefTable(trailer map[string]any, entries map[string]any) {
+	xref := u.offset()
+	ids := slices.Sorted(maps.Keys(u.offsets))
+	u.buf.WriteString("xref\n")
+	for _, sub := range xrefSubsections(ids) {
+		fmt.Fprintf(&u.buf, "%d %d\n", sub[0], len(sub))
+		for _, id := range sub {
+			fmt.Fprintf(&u.buf, "%010d %05d n \n", u.offsets[id], u.gens[id])
+		}
+	}
+
+	entries["/Size"] = u.size(trailer)
+	fmt.Fprintf(&u.buf, "trailer\n%s\nstartxref\n%d\n%%%%EOF\n", pdfValue(entries), xref)
+}
+
+// finishXRefStream writes the section as a cross-reference stream object
+// (ISO 32000-1, 7.5.8), which lists itself too.
+func (u *incrementalUpdate) finishXRefStream(trailer map[string]any, entries map[string]any) {
+	ref := u.newRef()
+	var id int
+	fmt.Sscanf(ref, "%d", &id)
+	xref := u.offset()
+	u.offsets[id], u.gens[id] = xref, 0
+
+	// Type 1 entries: offset and generation, big endian, as wide as needed
+	width := 1
+	for xref>>(8*width) > 0 {
+		width++
+	}
+	ids := slices.Sorted(maps.Keys(u.offsets))
+	var index []any
+	var data []byte
+	for _, sub := range xrefSubsections(ids) {
+		index = append(index, sub[0], len(sub))
+		for _, id := range sub {
+			data = append(data, 1)
+			for i := width - 1; i >= 0; i-- {
+				data = append(data, byte(u.offsets[id]>>(8*i)))
+			}
+			data = append(data, byte(u.gens[id]>>8), byte(u.gens[id]))
+		}
+	}
+
+	entries["/Type"] = "/XRef"
+	entries["/Size"] = u.size(trailer)
+	entries["/Index"] = index
+	entries["/W"] = []any{1, width, 2}
+	dict := strings.TrimSuffix(strings.TrimPrefix(pdfValue(entries), "<< "), " >>")
+	u.writeStream(ref, dict, data)
+	fmt.Fprintf(&u.buf, "startxref\n%d\n%%%%EOF\n", xref)
+}
+
+// size returns the /Size of the new trailer: one past the highest object
+// number in either revision.
+func (u *incrementalUpdate) size(trailer map[string]any) int {
+	size := u.nextID
+	if n, ok := trailer["/Size"].(float64); ok {
+		size = max(size, int(n))
+	}
+	return size
+}
+
+// xrefSubsections splits sorted object numbers into runs of consecutive
+// numbers, one cross-reference subsection each.
+func xrefSubsections(ids []int) [][]int {
+	var subs [][]int
+	for i := 0; i < len(ids); {
+		j := i + 1
+		for j < len(ids) && ids[j] == ids[j-1]+1 {
+			j++
+		}
+		subs = append(subs, ids[i:j])
+		i = j
+	}
+	return subs
+}
+
+// pdfValue writes a value decoded from qpdf's JSON (see qpdfDocument) in
+// PDF syntax. Dictionary keys are sorted so the output is stable.
+func pdfValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		if v == float64(int64(v)) {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case string:
+		switch {
+		case strings.HasPrefix(v, "/"):
+			return pdfName(v[1:])
+		case strings.HasPrefix(v, "n:/"):
+			// qpdf's form for names that are not UTF-8, already #-escaped
+			return v[2:]
+		case strings.HasPrefix(v, "u:"):
+			return pdfTextString(v[2:])
+		case strings.HasPrefix(v, "b:"):
+			return "<" + v[2:] + ">"
+		default:
+			// "N G R" references
+			return v
+		}
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = pdfValue(item)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	case map[string]any:
+		var b strings.Builder
+		b.WriteString("<<")
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			b.WriteString(" " + pdfValue(key) + " " + pdfValue(v[key]))
+		}
+		b.WriteString(" >>")
+		return b.String()
+	}
+	return "null"
+}
+
+// pdfName escapes the characters a name cannot hold literally.
+func pdfName(name string) string {
+	var b strings.Builder
+	b.WriteByte('/')
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < '!' || c > '~' || strings.IndexByte("()<>[]{}/%", c) >= 0 {
+			fmt.Fprintf(&b, "#%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// pdfTextString encodes text as a literal string when it is ASCII or
+// Latin-1, as UTF-16BE otherwise.
+func pdfTextString(text string) string {
+	latin := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r >= 0x80 && r < 0xa0 || r > 0xff {
+			latin = nil
+			break
+		}
+		latin = append(latin, byte(r))
+	}
+	if latin != nil || text == "" {
+		return pdfString(latin)
+	}
+
+	data := []byte{0xfe, 0xff}
+	for _, r := range utf16.Encode([]rune(text)) {
+		data = append(data, byte(r>>8), byte(r))
+	}
+	return "<" + hex.EncodeToString(data) + ">"
+}
@@ -0,0 +1,256 @@
+package pdf
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ElementKind tells headings from body paragraphs in linearized text.
+type ElementKind int
+
+const (
+	ElementParagraph ElementKind = iota
+	ElementHeading
+	ElementImage
+	ElementTable
+)
+
+// TextElement is one heading, paragraph, image or table of the single
+// column reading flow.
+type TextElement struct {
+	Kind ElementKind
+	Text string
+
+	Image *ImageBlock // ElementImage
+	Rows  [][]string  // ElementTable
+}
+
+// flowItem is a text block, image or table waiting to be placed in the flow.
+type flowItem struct {
+	TextBlock // the bounding box; Text is only set for text blocks
+	image     *ImageBlock
+	table     *Table
+}
+
+// pageItems returns the text blocks, images and, if detectTablesOnPage,
+// tables of page in reading order. Sorted means top to bottom, then left to
+// right; otherwise text keeps extraction order and images and tables are
+// inserted before the first block below them.
+func pageItems(page PageText, sortByPosition bool, detectTablesOnPage bool) []flowItem {
+	blocks := page.Blocks
+	var tables []Table
+	if detectTablesOnPage {
+		blocks, tables = detectTables(blocks)
+	}
+
+	items := make([]flowItem, 0, len(blocks)+len(page.Images)+len(tables))
+	for _, b := range blocks {
+		items = append(items, flowItem{TextBlock: b})
+	}
+
+	var extra []flowItem
+	for i := range page.Images {
+		img := &page.Images[i]
+		extra = append(extra, flowItem{TextBlock: TextBlock{X0: img.X0, Y0: img.Y0, X1: img.X1, Y1: img.Y1}, image: img})
+	}
+	for i := range tables {
+		t := &tables[i]
+		extra = append(extra, flowItem{TextBlock: TextBlock{X0: t.X0, Y0: t.Y0, X1: t.X1, Y1: t.Y1}, table: t})
+	}
+
+	if sortByPosition {
+		items = append(items, extra...)
+		sortItems(items)
+		return items
+	}
+
+	for _, e := range extra {
+		at := len(items)
+		for i, it := range items {
+			if it.image == nil && it.table == nil && it.Y0 >= e.Y0 {
+				at = i
+				break
+			}
+		}
+		items = slices.Insert(items, at, e)
+	}
+	return items
+}
+
+// sortItems orders items top to bottom, then left to right, the same order
+// PyMuPDF's sort=True produces.
+func sortItems(items []flowItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Y1 != items[j].Y1 {
+			return items[i].Y1 < items[j].Y1
+		}
+		return items[i].X0 < items[j].X0
+	})
+}
+
+// linearize turns positioned blocks into a single top-to-bottom flow.
+// Short lines without closing punctuation and ALL CAPS blocks become
+// headings; body text is dehyphenated, joined and split into paragraphs at
+// sentence ends followed by a capital letter. Document specific noise is
+// removed beforehand by FilterRules. Images and tables are placed between
+// the paragraphs around them.
+func linearize(pages []PageText, opts ConvertOptions) []TextElement {
+	var elements []TextElement
+	var body []string
+
+	flush := func() {
+		if len(body) == 0 {
+			return
+		}
+		text := dehyphenate(strings.Join(body, "\n"))
+		text = collapseSpaces(strings.ReplaceAll(text, "\n", " "))
+		body = body[:0]
+
+		for _, para := range splitSentences(text) {
+			if para = strings.TrimSpace(para); para != "" {
+				elements = append(elements, TextElement{Kind: ElementParagraph, Text: para})
+			}
+		}
+	}
+
+	for _, page := range pages {
+		for _, item := range pageItems(page, opts.Sort, opts.Tables) {
+			switch {
+			case item.image != nil:
+				flush()
+				elements = append(elements, TextElement{Kind: ElementImage, Image: item.image})
+				continue
+			case item.table != nil:
+				flush()
+				elements = append(elements, TextElement{Kind: ElementTable, Rows: item.table.Rows})
+				continue
+			}
+
+			block := item.TextBlock
+			text := removeControlCharacters(strings.TrimSpace(block.Text))
+			if text == "" {
+				continue
+			}
+
+			// Bare page numbers
+			if isDigits(text) {
+				continue
+			}
+
+			isAllCaps := isUpper(text) && utf8.RuneCountInString(text) > 2
+			endsWithSentencePunct := strings.ContainsAny(lastRune(text), ".,:;!?")
+			endsWithHyphen := strings.HasSuffix(text, "-")
+			isTitleCandidate := utf8.RuneCountInString(text) < 150 && !endsWithSentencePunct && !endsWithHyphen
+
+			if isAllCaps || isTitleCandidate {
+				flush()
+				title := strings.ReplaceAll(text, "\n", " ")
+				title = strings.ReplaceAll(title, "- ", "")
+				title = removeControlCharacters(collapseSpaces(title))
+				elements = append(elements, TextElement{Kind: ElementHeading, Text: title})
+			} else {
+				body = append(body, text)
+			}
+		}
+	}
+
+	flush()
+	return elements
+}
+
+// removeControlCharacters drops C0 controls except tab, newline and CR.
+func removeControlCharacters(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r < 0x20 && r != '\t' && r != '\n' && r != '\r') || r == 0x7F {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// dehyphenate joins words broken across lines: "ком-\nпютър" → "компютър".
+func dehyphenate(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '-' && i > 0 && unicode.IsLetter(runes[i-1]) {
+			j := i + 1
+			sawNewline := false
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				sawNewline = sawNewline || runes[j] == '\n'
+				j++
+			}
+			if sawNewline && j < len(runes) && unicode.IsLetter(runes[j]) {
+				i = j - 1
+				continue
+			}
+		}
+		b.WriteRune(runes[i])
+	}
+	return b.String()
+}
+
+// splitSentences splits at whitespace that follows '.', '!' or '?' and
+// precedes an upper case letter.
+func splitSentences(s string) []string {
+	var parts []string
+	runes := []rune(s)
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsSpace(runes[i]) || !strings.ContainsRune(".!?", runes[i-1]) {
+			continue
+		}
+		j := i
+		for j < len(runes) && unicode.IsSpace(runes[j]) {
+			j++
+		}
+		if j < len(runes) && unicode.IsUpper(runes[j]) {
+			parts = append(parts, string(runes[start:i]))
+			start = j
+			i = j
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// isUpper mirrors Python's str.isupper: at least one cased letter and no
+// lower case ones.
+func isUpper(s string) bool {
+	cased := false
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsUpper(r) {
+			cased = true
+		}
+	}
+	return cased
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func lastRune(s string) string {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return string(r)
+}
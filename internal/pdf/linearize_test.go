@@ -0,0 +1,142 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinearize(t *testing.T) {
+	pages := []PageText{{
+		Number: 1,
+		Blocks: []TextBlock{
+			{Text: "Body of the second col-\numn continues here.", X0: 300, Y0: 100, X1: 500, Y1: 140},
+			{Text: "ГОЛЯМО ЗАГЛАВИЕ", X0: 50, Y0: 20, X1: 500, Y1: 40},
+			{Text: "First paragraph ends here. Second one starts\nwith a capital letter.", X0: 50, Y0: 100, X1: 250, Y1: 140},
+			{Text: "12", X0: 280, Y0: 800, X1: 290, Y1: 810},
+		},
+	}}
+
+	got := linearize(pages, ConvertOptions{Sort: true})
+	want := []TextElement{
+		{Kind: ElementHeading, Text: "ГОЛЯМО ЗАГЛАВИЕ"},
+		{Kind: ElementParagraph, Text: "First paragraph ends here."},
+		{Kind: ElementParagraph, Text: "Second one starts with a capital letter."},
+		{Kind: ElementParagraph, Text: "Body of the second column continues here."},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("linearize() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestDehyphenate(t *testing.T) {
+	cases := map[string]string{
+		"ком-\nпютър":    "компютър",
+		"ком- \n  пютър": "компютър",
+		"well-known":     "well-known",
+		"2019-\n2020":    "2019-\n2020",
+		"end -\nstart":   "end -\nstart",
+	}
+	for in, want := range cases {
+		if got := dehyphenate(in); got != want {
+			t.Errorf("dehyphenate(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseBBoxLayout(t *testing.T) {
+	const out = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title></title></head>
+<body>
+<doc>
+  <page width="595.000000" height="842.000000">
+    <flow>
+      <block xMin="56.8" yMin="57.1" xMax="200.4" yMax="90.0">
+        <line xMin="56.8" yMin="57.1" xMax="200.4" yMax="70.0">
+          <word xMin="56.8" yMin="57.1" xMax="100.0" yMax="70.0">Hello</word>
+          <word xMin="101.0" yMin="57.1" xMax="200.4" yMax="70.0">&amp;world</word>
+        </line>
+        <line xMin="56.8" yMin="72.0" xMax="120.0" yMax="90.0">
+          <word xMin="56.8" yMin="72.0" xMax="120.0" yMax="90.0">Здравей</word>
+        </line>
+      </block>
+    </flow>
+  </page>
+</doc>
+</body>
+</html>`
+
+	pages, err := parseBBoxLayout([]byte(out))
+	if err != nil {
+		t.Fatalf("parseBBoxLayout returned error: %v", err)
+	}
+	if len(pages) != 1 || len(pages[0].Blocks) != 1 {
+		t.Fatalf("unexpected structure: %+v", pages)
+	}
+
+	block := pages[0].Blocks[0]
+	if block.Text != "Hello &world\nЗдравей" {
+		t.Errorf("block text = %q", block.Text)
+	}
+	if pages[0].Width != 595 || block.Y1 != 90 {
+		t.Errorf("unexpected geometry: page %+v block %+v", pages[0], block)
+	}
+}
+
+func TestLinearize_ImagesAndTables(t *testing.T) {
+	img := ImageBlock{Path: "chart.png", X0: 50, Y0: 150, X1: 300, Y1: 300}
+	pages := []PageText{{
+		Number: 1,
+		Blocks: []TextBlock{
+			{Text: "Sales grew in every region this year.", X0: 50, Y0: 100, X1: 500, Y1: 140},
+			{Text: "Region", X0: 50, Y0: 320, X1: 100, Y1: 332},
+			{Text: "Total", X0: 300, Y0: 320, X1: 340, Y1: 332},
+			{Text: "North", X0: 50, Y0: 338, X1: 100, Y1: 350},
+			{Text: "120", X0: 300, Y0: 338, X1: 330, Y1: 350},
+			{Text: "The chart and table above summarise it.", X0: 50, Y0: 380, X1: 500, Y1: 400},
+		},
+		Images: []ImageBlock{img},
+	}}
+
+	want := []TextElement{
+		{Kind: ElementParagraph, Text: "Sales grew in every region this year."},
+		{Kind: ElementImage, Image: &pages[0].Images[0]},
+		{Kind: ElementTable, Rows: [][]string{{"Region", "Total"}, {"North", "120"}}},
+		{Kind: ElementParagraph, Text: "The chart and table above summarise it."},
+	}
+
+	for _, sort := range []bool{true, false} {
+		got := linearize(pages, ConvertOptions{Sort: sort, Tables: true})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("linearize(sort=%v) =\n%#v\nwant\n%#v", sort, got, want)
+		}
+	}
+}
+
+func TestParsePdfToHTMLImages(t *testing.T) {
+	const out = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE pdf2xml SYSTEM "pdf2xml.dtd">
+<pdf2xml producer="poppler" version="24.02.0">
+<page number="1" position="absolute" top="0" left="0" height="842" width="595">
+<image top="100" left="50" width="200" height="150" src="/tmp/x/page-1_1.png"/>
+<image top="10" left="10" width="4" height="300" src="page-1_2.png"/>
+<text top="60" left="50" width="100" height="12" font="0">Hello</text>
+</page>
+<page number="2" position="absolute" top="0" left="0" height="842" width="595">
+<image top="20" left="30" width="100" height="100" src="out/page-2_1.png"/>
+</page>
+</pdf2xml>`
+
+	got, err := parsePdfToHTMLImages([]byte(out), "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int][]ImageBlock{
+		1: {{Path: "/tmp/x/page-1_1.png", X0: 50, Y0: 100, X1: 250, Y1: 250}},
+		2: {{Path: "/work/page-2_1.png", X0: 30, Y0: 20, X1: 130, Y1: 120}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePdfToHTMLImages() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressor_Lossless_RendersIdentically(t *testing.T) {
+	for _, bin := range []string{"gs", "qpdf"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not found, skipping lossless test", bin)
+		}
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	outputPath := filepath.Join(tempDir, "lossless.pdf")
+
+	report, err := NewCompressor().Compress(inputPath, outputPath, LevelLossless)
+	if err != nil {
+		t.Fatalf("Compress returned error: %v", err)
+	}
+	for _, stage := range report.Stages {
+		if stage.Name == "ghostscript" {
+			t.Errorf("❌ Lossless pipeline ran Ghostscript")
+		}
+	}
+
+	before := renderPages(t, inputPath, filepath.Join(tempDir, "before"))
+	after := renderPages(t, outputPath, filepath.Join(tempDir, "after"))
+
+	if len(before) != len(after) {
+		t.Fatalf("❌ Page count changed: %d -> %d", len(before), len(after))
+	}
+	for i := range before {
+		if !bytes.Equal(before[i], after[i]) {
+			t.Errorf("❌ Page %d renders differently after lossless compression", i+1)
+		}
+	}
+
+	t.Logf("✅ %d pages identical, %d -> %d bytes", len(before), report.InputSize, report.OutputSize)
+}
+
+// renderPages rasterizes every page of path at 72 dpi and returns the raw
+// PNG bytes per page.
+func renderPages(t *testing.T, path string, dir string) [][]byte {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("gs", "-q", "-dNOPAUSE", "-dBATCH", "-dSAFER",
+		"-sDEVICE=png16m", "-r72",
+		"-sOutputFile="+filepath.Join(dir, "page_%04d.png"), path)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("rendering %s failed: %v\n%s", path, err, out)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "page_*.png"))
+	pages := make([][]byte, len(files))
+	for i, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages[i] = data
+	}
+	return pages
+}
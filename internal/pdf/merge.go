@@ -0,0 +1,173 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OutlineMode says what the merged document's bookmarks look like.
+type OutlineMode string
+
+const (
+	// OutlineFiles adds one bookmark per source file.
+	OutlineFiles OutlineMode = "files"
+	// OutlineKeep adds one bookmark per file with that file's own outline
+	// nested under it.
+	OutlineKeep OutlineMode = "keep"
+	// OutlineNone writes no outline.
+	OutlineNone OutlineMode = "none"
+)
+
+// ParseOutlineMode accepts "files", "keep" and "none"; empty means files.
+func ParseOutlineMode(s string) (OutlineMode, error) {
+	switch mode := OutlineMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return OutlineFiles, nil
+	case OutlineFiles, OutlineKeep, OutlineNone:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown outline mode %q (use files, keep or none)", s)
+	}
+}
+
+// MergeInput is one document to merge.
+type MergeInput struct {
+	Path string
+	// Title is the file's bookmark. Empty means the file name without
+	// extension.
+	Title string
+}
+
+// MergedFile tells where a source ended up in the merged document.
+type MergedFile struct {
+	Title     string
+	FirstPage int // 1-based
+	Pages     int
+}
+
+// MergeResult describes a merged document.
+type MergeResult struct {
+	PageCount int
+	Files     []MergedFile
+}
+
+// Merger concatenates PDFs with qpdf.
+type Merger struct {
+	Outline OutlineMode // "" means OutlineFiles
+
+	// BlankPages inserts an empty A4 page between documents, e.g. so every
+	// document starts on a new sheet when printing duplex.
+	BlankPages bool
+}
+
+func NewMerger() *Merger {
+	return &Merger{Outline: OutlineFiles}
+}
+
+// Merge writes inputs, in order, to outputPath.
+func (m *Merger) Merge(ctx context.Context, inputs []MergeInput, outputPath string) (result *MergeResult, err error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("nothing to merge")
+	}
+	mode := m.Outline
+	if mode == "" {
+		mode = OutlineFiles
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(outputPath)
+		}
+	}()
+
+	workDir, err := os.MkdirTemp(filepath.Dir(outputPath), "merge_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	var blank string
+	if m.BlankPages && len(inputs) > 1 {
+		blank = filepath.Join(workDir, "blank.pdf")
+		if err := os.WriteFile(blank, blankPDF(595, 842), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	result = &MergeResult{}
+	var outline []Bookmark
+	args := []string{"--empty", "--pages"}
+
+	for i, in := range inputs {
+		n, err := PageCount(ctx, in.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(in.Path), err)
+		}
+
+		if i > 0 && blank != "" {
+			args = append(args, blank, "1")
+			result.PageCount++
+		}
+
+		title := in.Title
+		if title == "" {
+			base := filepath.Base(in.Path)
+			title = strings.TrimSuffix(base, filepath.Ext(base))
+		}
+		file := MergedFile{Title: title, FirstPage: result.PageCount + 1, Pages: n}
+		result.Files = append(result.Files, file)
+
+		bookmark := Bookmark{Title: title, Page: file.FirstPage}
+		if mode == OutlineKeep {
+			own, err := ReadOutline(ctx, in.Path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filepath.Base(in.Path), err)
+			}
+			bookmark.Children = offsetOutline(own, result.PageCount)
+		}
+		outline = append(outline, bookmark)
+
+		args = append(args, in.Path, "1-z")
+		result.PageCount += n
+	}
+
+	if mode == OutlineNone {
+		args = append(args, "--", outputPath)
+		return result, runQPDF(ctx, args...)
+	}
+
+	merged := filepath.Join(workDir, "merged.pdf")
+	args = append(args, "--", merged)
+	if err := runQPDF(ctx, args...); err != nil {
+		return nil, err
+	}
+	if err := writeOutline(ctx, merged, outputPath, outline); err != nil {
+		return nil, fmt.Errorf("writing bookmarks: %w", err)
+	}
+	return result, nil
+}
+
+// offsetOutline shifts the pages of bookmarks by offset. Unresolved
+// destinations stay 0 and are dropped by writeOutline.
+func offsetOutline(bookmarks []Bookmark, offset int) []Bookmark {
+	shifted := make([]Bookmark, len(bookmarks))
+	for i, b := range bookmarks {
+		shifted[i] = Bookmark{Title: b.Title, Children: offsetOutline(b.Children, offset)}
+		if b.Page > 0 {
+			shifted[i].Page = b.Page + offset
+		}
+	}
+	return shifted
+}
+
+// blankPDF returns a one page PDF of the given size in points.
+func blankPDF(width, height float64) []byte {
+	w := newPDFWriter()
+	root := w.add("<< /Type /Catalog /Pages 2 0 R >>")
+	w.add("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	w.add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Resources << >> >>", width, height))
+	return w.finish(root)
+}
@@ -0,0 +1,117 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutlineBuilder(t *testing.T) {
+	b := outlineBuilder{pages: []string{"3 0 R", "4 0 R", "5 0 R"}, nextID: 10, objects: map[string]qpdfObject{}}
+	first, last, count := b.build("9 0 R", []Bookmark{
+		{Title: "a", Page: 1, Children: []Bookmark{{Title: "a.1", Page: 2}}},
+		{Title: "lost", Page: 7},
+		{Title: "b", Page: 3},
+	})
+
+	if first != "10 0 R" || last != "12 0 R" || count != 2 {
+		t.Fatalf("build = %q, %q, %d; want 10 0 R, 12 0 R, 2", first, last, count)
+	}
+
+	a := b.objects["obj:10 0 R"].dict()
+	if a["/Next"] != "12 0 R" || a["/First"] != "11 0 R" || a["/Count"] != -1 {
+		t.Errorf("bookmark a = %v", a)
+	}
+	if dest := a["/Dest"].([]any); dest[0] != "3 0 R" {
+		t.Errorf("bookmark a points at %v, want page 1 (3 0 R)", dest[0])
+	}
+	if kid := b.objects["obj:11 0 R"].dict(); kid["/Parent"] != "10 0 R" || kid["/Title"] != "u:a.1" {
+		t.Errorf("bookmark a.1 = %v", kid)
+	}
+	if last := b.objects["obj:12 0 R"].dict(); last["/Prev"] != "10 0 R" || last["/Next"] != nil {
+		t.Errorf("bookmark b = %v", last)
+	}
+	if len(b.objects) != 3 {
+		t.Errorf("wrote %d objects, want 3 (out of range entry dropped)", len(b.objects))
+	}
+}
+
+func TestOffsetOutline(t *testing.T) {
+	got := offsetOutline([]Bookmark{{Title: "x", Page: 2, Children: []Bookmark{{Title: "y"}}}}, 5)
+	if got[0].Page != 7 || got[0].Children[0].Page != 0 {
+		t.Errorf("offsetOutline = %+v, want page 7 and an unresolved child", got)
+	}
+}
+
+func TestParseOutlineMode(t *testing.T) {
+	for in, want := range map[string]OutlineMode{"": OutlineFiles, "Keep": OutlineKeep, "none": OutlineNone} {
+		if got, err := ParseOutlineMode(in); err != nil || got != want {
+			t.Errorf("ParseOutlineMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseOutlineMode("chapters"); err == nil {
+		t.Error("ParseOutlineMode(chapters) returned no error")
+	}
+}
+
+func TestBlankPDF(t *testing.T) {
+	data := blankPDF(595, 842)
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("blankPDF is not a PDF file")
+	}
+	if !bytes.Contains(data, []byte("/MediaBox [0 0 595 842]")) {
+		t.Error("blankPDF has no A4 media box")
+	}
+
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping validation")
+	}
+	path := filepath.Join(t.TempDir(), "blank.pdf")
+	os.WriteFile(path, data, 0644)
+	if out, err := exec.Command("qpdf", "--check", path).CombinedOutput(); err != nil {
+		t.Errorf("qpdf --check failed: %v\n%s", err, out)
+	}
+}
+
+func TestMerger_Merge_Integration(t *testing.T) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping merge test")
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	ctx := context.Background()
+	pages, err := PageCount(ctx, inputPath)
+	if err != nil {
+		t.Fatalf("pageCount: %v", err)
+	}
+
+	merger := NewMerger()
+	merger.BlankPages = true
+	outputPath := filepath.Join(tempDir, "merged.pdf")
+
+	t.Logf("🚀 Merging two copies of: %s", inputPath)
+	res, err := merger.Merge(ctx, []MergeInput{{Path: inputPath}, {Path: inputPath, Title: "Second"}}, outputPath)
+	if err != nil {
+		t.Fatalf("Merge returned error: %v", err)
+	}
+
+	if want := 2*pages + 1; res.PageCount != want {
+		t.Errorf("❌ PageCount = %d, want %d", res.PageCount, want)
+	}
+	if n, _ := PageCount(ctx, outputPath); n != res.PageCount {
+		t.Errorf("❌ output has %d pages, want %d", n, res.PageCount)
+	}
+
+	outline, err := ReadOutline(ctx, outputPath)
+	if err != nil {
+		t.Fatalf("ReadOutline: %v", err)
+	}
+	if len(outline) != 2 || outline[1].Title != "Second" || outline[1].Page != pages+2 {
+		t.Errorf("❌ outline = %+v, want one bookmark per file", outline)
+	}
+
+	t.Logf("✅ Merged %d pages", res.PageCount)
+}
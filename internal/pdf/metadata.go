@@ -0,0 +1,611 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MetadataFields lists the fields of Metadata by the names Field, SetField
+// and WriteMetadata take.
+var MetadataFields = []string{"title", "author", "subject", "keywords", "creator", "producer", "created", "modified"}
+
+// infoKeys maps MetadataFields to the keys of the Info dictionary.
+var infoKeys = map[string]string{
+	"title":    "/Title",
+	"author":   "/Author",
+	"subject":  "/Subject",
+	"keywords": "/Keywords",
+	"creator":  "/Creator",
+	"producer": "/Producer",
+	"created":  "/CreationDate",
+	"modified": "/ModDate",
+}
+
+// Metadata is the document information of a PDF. It is kept twice: in the
+// Info dictionary and in the catalog's XMP packet, which PDF 2.0 prefers.
+// ReadMetadata takes the Info values and fills in missing ones from XMP;
+// WriteMetadata writes both.
+type Metadata struct {
+	Title    string    `json:"title,omitempty"`
+	Author   string    `json:"author,omitempty"`
+	Subject  string    `json:"subject,omitempty"`
+	Keywords string    `json:"keywords,omitempty"`
+	Creator  string    `json:"creator,omitempty"`  // application the original document was made with
+	Producer string    `json:"producer,omitempty"` // application that wrote the PDF
+	Created  time.Time `json:"created,omitzero"`
+	Modified time.Time `json:"modified,omitzero"`
+
+	XMP string `json:"xmp,omitempty"` // the raw XMP packet, empty when there is none
+}
+
+// Field returns a field of MetadataFields as text, dates in RFC 3339; ""
+// when it is not set.
+func (m *Metadata) Field(name string) string {
+	if t := m.date(name); t != nil {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	if s := m.text(name); s != nil {
+		return *s
+	}
+	return ""
+}
+
+// SetField sets a field of MetadataFields; "" clears it. Dates are read
+// by ParseMetadataDate.
+func (m *Metadata) SetField(name string, value string) error {
+	value = strings.TrimSpace(value)
+	if t := m.date(name); t != nil {
+		if value == "" {
+			*t = time.Time{}
+			return nil
+		}
+		parsed, err := ParseMetadataDate(value)
+		if err != nil {
+			return err
+		}
+		*t = parsed
+		return nil
+	}
+	if s := m.text(name); s != nil {
+		*s = value
+		return nil
+	}
+	return fmt.Errorf("unknown metadata field %q (use %s)", name, strings.Join(MetadataFields, ", "))
+}
+
+// IsEmpty reports whether no field is set.
+func (m *Metadata) IsEmpty() bool {
+	return !slices.ContainsFunc(MetadataFields, func(name string) bool { return m.Field(name) != "" })
+}
+
+func (m *Metadata) text(name string) *string {
+	switch name {
+	case "title":
+		return &m.Title
+	case "author":
+		return &m.Author
+	case "subject":
+		return &m.Subject
+	case "keywords":
+		return &m.Keywords
+	case "creator":
+		return &m.Creator
+	case "producer":
+		return &m.Producer
+	}
+	return nil
+}
+
+func (m *Metadata) date(name string) *time.Time {
+	switch name {
+	case "created":
+		return &m.Created
+	case "modified":
+		return &m.Modified
+	}
+	return nil
+}
+
+// ParseMetadataDate accepts RFC 3339 ("2024-05-01T14:30:00+03:00"),
+// "2024-05-01 14:30", "2024-05-01" (both in local time) and PDF dates
+// ("D:20240501143000+03'00'").
+func ParseMetadataDate(s string) (time.Time, error) {
+	if strings.HasPrefix(s, "D:") {
+		if t := parsePDFDate(s); !t.IsZero() {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD, YYYY-MM-DD HH:MM or RFC 3339", s)
+}
+
+// ReadMetadata returns the document information of path.
+func ReadMetadata(ctx context.Context, path string) (*Metadata, error) {
+	objs, err := readMetadataObjects(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return objs.metadata(), nil
+}
+
+// WriteMetadata copies in to out with the given fields of MetadataFields
+// changed; "" removes a field, fields left out keep their value. Info and
+// XMP are both rewritten. A document left without any field loses its Info
+// dictionary and XMP packet altogether.
+func WriteMetadata(ctx context.Context, in string, out string, fields map[string]string) error {
+	for name := range fields {
+		if _, ok := infoKeys[name]; !ok {
+			return fmt.Errorf("unknown metadata field %q (use %s)", name, strings.Join(MetadataFields, ", "))
+		}
+	}
+
+	objs, err := readMetadataObjects(ctx, in)
+	if err != nil {
+		return err
+	}
+	meta := objs.metadata()
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if err := meta.SetField(name, fields[name]); err != nil {
+			return err
+		}
+	}
+	return objs.write(ctx, in, out, meta, false)
+}
+
+// replaceMetadata copies in to out with meta as its only document
+// information: other Info entries and XMP properties are dropped.
+func replaceMetadata(ctx context.Context, in string, out string, meta *Metadata) error {
+	objs, err := readMetadataObjects(ctx, in)
+	if err != nil {
+		return err
+	}
+	return objs.write(ctx, in, out, meta, true)
+}
+
+// metadataObjects holds the objects of a file that carry its metadata.
+type metadataObjects struct {
+	doc     *qpdfDocument // header and trailer
+	rootKey string
+	catalog map[string]any
+	infoRef string         // "" when the Info dictionary is inline or missing
+	info    map[string]any // nil when missing
+	xmpRef  string
+	xmp     []byte
+}
+
+func readMetadataObjects(ctx context.Context, path string) (*metadataObjects, error) {
+	doc, err := readQPDFJSON(ctx, path, "trailer")
+	if err != nil {
+		return nil, err
+	}
+	trailer := doc.trailer()
+	o := &metadataObjects{doc: doc, rootKey: objectKey(trailer["/Root"])}
+
+	keys := []string{o.rootKey}
+	switch info := trailer["/Info"].(type) {
+	case map[string]any:
+		o.info = info
+	case string:
+		o.infoRef = info
+		keys = append(keys, objectKey(info))
+	}
+	objs, err := readQPDFJSON(ctx, path, keys...)
+	if err != nil {
+		return nil, err
+	}
+	if o.catalog = objs.Objects[o.rootKey].dict(); o.catalog == nil {
+		return nil, fmt.Errorf("catalog %s not found", o.rootKey)
+	}
+	if o.infoRef != "" {
+		o.info = objs.Objects[objectKey(o.infoRef)].dict()
+	}
+
+	if ref, ok := o.catalog["/Metadata"].(string); ok && objectRefPattern.MatchString(ref) {
+		o.xmpRef = ref
+		if o.xmp, err = readStreamData(ctx, path, ref); err != nil {
+			return nil, fmt.Errorf("reading the XMP packet: %w", err)
+		}
+	}
+	return o, nil
+}
+
+// metadata returns the Info values, with the missing ones taken from XMP.
+func (o *metadataObjects) metadata() *Metadata {
+	meta := &Metadata{XMP: string(o.xmp)}
+	xmp := parseXMP(o.xmp)
+	for _, name := range MetadataFields {
+		value := qpdfText(o.info[infoKeys[name]])
+		t := meta.date(name)
+		switch {
+		case t == nil && value != "":
+			*meta.text(name) = value
+		case t == nil:
+			*meta.text(name) = xmp[name]
+		case value != "":
+			*t = parsePDFDate(value)
+		default:
+			*t = parseXMPDate(xmp[name])
+		}
+	}
+	return meta
+}
+
+// write copies in to out with meta in the Info dictionary and the XMP
+// packet. The mirrored properties are patched into the old packet, so the
+// rest of it (document IDs, PDF/A and PDF/UA identification, custom
+// schemas) is kept. With replace the packet is rebuilt from meta alone and
+// entries of the old Info dictionary that meta does not cover are dropped
+// too.
+func (o *metadataObjects) write(ctx context.Context, in string, out string, meta *Metadata, replace bool) error {
+	trailer := o.doc.trailer()
+	patch := map[string]qpdfObject{}
+	nextID := o.doc.maxObjectID() + 1
+	newRef := func() string {
+		ref := fmt.Sprintf("%d 0 R", nextID)
+		nextID++
+		return ref
+	}
+
+	if meta.IsEmpty() {
+		delete(trailer, "/Info")
+		delete(o.catalog, "/Metadata")
+		patch["trailer"] = qpdfObject{Value: trailer}
+		patch[o.rootKey] = qpdfObject{Value: o.catalog}
+		return updateWithJSON(ctx, in, out, o.doc.Header, patch)
+	}
+
+	info := map[string]any{}
+	if !replace {
+		maps.Copy(info, o.info)
+	}
+	for _, name := range MetadataFields {
+		key := infoKeys[name]
+		switch t := meta.date(name); {
+		case t != nil && !t.IsZero():
+			info[key] = "u:" + pdfDate(*t)
+		case t == nil && meta.Field(name) != "":
+			info[key] = "u:" + meta.Field(name)
+		default:
+			delete(info, key)
+		}
+	}
+	infoRef := o.infoRef
+	if infoRef == "" {
+		infoRef = newRef()
+	}
+	patch[objectKey(infoRef)] = qpdfObject{Value: info}
+	trailer["/Info"] = infoRef
+	patch["trailer"] = qpdfObject{Value: trailer}
+
+	xmpRef := o.xmpRef
+	if xmpRef == "" {
+		xmpRef = newRef()
+	}
+	var packet, keep []byte
+	if !replace {
+		// A packet that cannot be patched is rebuilt, keeping at least
+		// its PDF/A identification
+		keep = o.xmp
+		packet, _ = patchXMP(o.xmp, meta)
+	}
+	if packet == nil {
+		packet = buildXMP(meta, keep)
+	}
+	patch[objectKey(xmpRef)] = qpdfObject{Stream: &qpdfStream{
+		Dict: map[string]any{"/Type": "/Metadata", "/Subtype": "/XML"},
+		Data: base64.StdEncoding.EncodeToString(packet),
+	}}
+	o.catalog["/Metadata"] = xmpRef
+	patch[o.rootKey] = qpdfObject{Value: o.catalog}
+
+	return updateWithJSON(ctx, in, out, o.doc.Header, patch)
+}
+
+// qpdfText decodes a string value of qpdf's JSON.
+func qpdfText(v any) string {
+	s, _ := v.(string)
+	switch {
+	case strings.HasPrefix(s, "u:"):
+		return s[2:]
+	case strings.HasPrefix(s, "b:"):
+		return decodePDFString([]byte("<" + s[2:] + ">"))
+	}
+	return ""
+}
+
+// XMP namespaces of the properties that mirror the Info dictionary.
+const (
+	nsDC     = "http://purl.org/dc/elements/1.1/"
+	nsXMP    = "http://ns.adobe.com/xap/1.0/"
+	nsPDF    = "http://ns.adobe.com/pdf/1.3/"
+	nsRDF    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsPDFAID = "http://www.aiim.org/pdfa/ns/id/"
+)
+
+// xmpProperties maps XMP properties to MetadataFields.
+var xmpProperties = map[xml.Name]string{
+	{Space: nsDC, Local: "title"}:        "title",
+	{Space: nsDC, Local: "creator"}:      "author",
+	{Space: nsDC, Local: "description"}:  "subject",
+	{Space: nsPDF, Local: "Keywords"}:    "keywords",
+	{Space: nsXMP, Local: "CreatorTool"}: "creator",
+	{Space: nsPDF, Local: "Producer"}:    "producer",
+	{Space: nsXMP, Local: "CreateDate"}:  "created",
+	{Space: nsXMP, Local: "ModifyDate"}:  "modified",
+}
+
+// parseXMP returns the values of the properties in xmpProperties, written
+// as elements or as attributes of rdf:Description. Dates are left as
+// text; several authors are joined with "; ".
+func parseXMP(data []byte) map[string]string {
+	values := map[string]string{}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var field string // property being read, "" outside of one
+	var items []string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if name, ok := xmpProperties[t.Name]; ok && field == "" {
+				field, items = name, nil
+			}
+			if t.Name.Space == nsRDF && t.Name.Local == "Description" {
+				for _, attr := range t.Attr {
+					if name, ok := xmpProperties[attr.Name]; ok && values[name] == "" {
+						values[name] = strings.TrimSpace(attr.Value)
+					}
+				}
+			}
+		case xml.CharData:
+			if text := strings.TrimSpace(string(t)); field != "" && text != "" {
+				items = append(items, text)
+			}
+		case xml.EndElement:
+			if name, ok := xmpProperties[t.Name]; ok && name == field {
+				if values[field] == "" && len(items) > 0 {
+					if field == "author" {
+						values[field] = strings.Join(items, "; ")
+					} else {
+						// Language alternatives: the first is x-default
+						values[field] = items[0]
+					}
+				}
+				field = ""
+			}
+		}
+	}
+	return values
+}
+
+// parseXMPDate reads an XMP date: an ISO 8601 date, optionally with the
+// time (minutes or seconds) and a zone. It returns the zero time when s is
+// not a date.
+func parseXMPDate(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02T15:04:05", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// pdfaPattern finds the PDF/A identification of an XMP packet, as
+// attributes or as elements.
+var pdfaPattern = regexp.MustCompile(`pdfaid:(part|conformance)(?:="|>)([0-9A-Za-z]+)`)
+
+// buildXMP returns an XMP packet with meta's fields. The PDF/A
+// identification of keep, the old packet, is carried over so PDF/A files
+// stay valid.
+func buildXMP(meta *Metadata, keep []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">` + "\n")
+	b.WriteString(` <rdf:RDF xmlns:rdf="` + nsRDF + `">` + "\n")
+	b.WriteString(`  <rdf:Description rdf:about="" xmlns:dc="` + nsDC + `" xmlns:xmp="` + nsXMP + `" xmlns:pdf="` + nsPDF + `"`)
+	pdfa := pdfaPattern.FindAllSubmatch(keep, -1)
+	if pdfa != nil {
+		b.WriteString(` xmlns:pdfaid="` + nsPDFAID + `"`)
+	}
+	b.WriteString(">\n")
+
+	b.WriteString("   <dc:format>application/pdf</dc:format>\n")
+	writeXMPFields(&b, meta)
+	seen := map[string]bool{}
+	for _, m := range pdfa {
+		if name := string(m[1]); !seen[name] {
+			seen[name] = true
+			fmt.Fprintf(&b, "   <pdfaid:%s>%s</pdfaid:%s>\n", name, m[2], name)
+		}
+	}
+
+	b.WriteString("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n")
+	b.WriteString(`<?xpacket end="w"?>`)
+	return b.Bytes()
+}
+
+// writeXMPFields writes meta's fields as properties of an rdf:Description
+// that declares the dc, xmp and pdf prefixes.
+func writeXMPFields(b *bytes.Buffer, meta *Metadata) {
+	esc := func(s string) string {
+		var e bytes.Buffer
+		xml.EscapeText(&e, []byte(s))
+		return e.String()
+	}
+	xmpDate := func(t time.Time) string { return t.Format(time.RFC3339) }
+
+	if meta.Title != "" {
+		fmt.Fprintf(b, "   <dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", esc(meta.Title))
+	}
+	if meta.Author != "" {
+		fmt.Fprintf(b, "   <dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", esc(meta.Author))
+	}
+	if meta.Subject != "" {
+		fmt.Fprintf(b, "   <dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", esc(meta.Subject))
+	}
+	if meta.Keywords != "" {
+		fmt.Fprintf(b, "   <pdf:Keywords>%s</pdf:Keywords>\n", esc(meta.Keywords))
+	}
+	if meta.Creator != "" {
+		fmt.Fprintf(b, "   <xmp:CreatorTool>%s</xmp:CreatorTool>\n", esc(meta.Creator))
+	}
+	if meta.Producer != "" {
+		fmt.Fprintf(b, "   <pdf:Producer>%s</pdf:Producer>\n", esc(meta.Producer))
+	}
+	if !meta.Created.IsZero() {
+		fmt.Fprintf(b, "   <xmp:CreateDate>%s</xmp:CreateDate>\n", xmpDate(meta.Created))
+	}
+	if !meta.Modified.IsZero() {
+		fmt.Fprintf(b, "   <xmp:ModifyDate>%s</xmp:ModifyDate>\n", xmpDate(meta.Modified))
+		fmt.Fprintf(b, "   <xmp:MetadataDate>%s</xmp:MetadataDate>\n", xmpDate(meta.Modified))
+	}
+}
+
+// patchXMP returns packet with the properties that mirror the Info
+// dictionary (and xmp:MetadataDate) replaced by meta's fields, which go
+// into a new rdf:Description at the end of rdf:RDF. Everything else is
+// left byte for byte. It returns nil for an empty packet and an error for
+// one that is not XMP.
+func patchXMP(packet []byte, meta *Metadata) ([]byte, error) {
+	if len(bytes.TrimSpace(packet)) == 0 {
+		return nil, nil
+	}
+	mirrored := func(name xml.Name) bool {
+		_, ok := xmpProperties[name]
+		return ok || name == xml.Name{Space: nsXMP, Local: "MetadataDate"}
+	}
+
+	type span struct{ from, to int }
+	var cut []span
+	insertAt := -1
+
+	// RawToken keeps the prefixes, which the attributes are cut by, so
+	// the namespaces are resolved here
+	type element struct {
+		name   xml.Name
+		scope  map[string]string
+		offset int // where a mirrored property starts, -1 otherwise
+	}
+	var stack []element
+	resolve := func(prefix string) string {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if url, ok := stack[i].scope[prefix]; ok {
+				return url
+			}
+		}
+		return prefix
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(packet))
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := element{scope: map[string]string{}, offset: -1}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" {
+					el.scope[attr.Name.Local] = attr.Value
+				}
+			}
+			stack = append(stack, el)
+			name := xml.Name{Space: resolve(t.Name.Space), Local: t.Name.Local}
+			stack[len(stack)-1].name = name
+
+			parent := xml.Name{}
+			if len(stack) > 1 {
+				parent = stack[len(stack)-2].name
+			}
+			if parent == (xml.Name{Space: nsRDF, Local: "Description"}) && mirrored(name) {
+				stack[len(stack)-1].offset = start
+			}
+			if name == (xml.Name{Space: nsRDF, Local: "Description"}) {
+				// Properties written as attributes of the start tag
+				tag := packet[start:dec.InputOffset()]
+				for _, attr := range t.Attr {
+					if attr.Name.Space == "" || attr.Name.Space == "xmlns" || !mirrored(xml.Name{Space: resolve(attr.Name.Space), Local: attr.Name.Local}) {
+						continue
+					}
+					pattern := regexp.MustCompile(`\s+` + regexp.QuoteMeta(attr.Name.Space+":"+attr.Name.Local) + `\s*=\s*("[^"]*"|'[^']*')`)
+					if m := pattern.FindIndex(tag); m != nil {
+						cut = append(cut, span{start + m[0], start + m[1]})
+					}
+				}
+			}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("unbalanced XMP packet")
+			}
+			el := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if from := el.offset; from >= 0 {
+				// The property's line goes with it
+				for from > 0 && (packet[from-1] == ' ' || packet[from-1] == '\t') {
+					from--
+				}
+				if from > 0 && packet[from-1] == '\n' {
+					from--
+				}
+				cut = append(cut, span{from, int(dec.InputOffset())})
+			}
+			if el.name == (xml.Name{Space: nsRDF, Local: "RDF"}) && insertAt < 0 {
+				insertAt = start
+			}
+		}
+	}
+	if insertAt < 0 {
+		return nil, fmt.Errorf("XMP packet without rdf:RDF")
+	}
+	for insertAt > 0 && (packet[insertAt-1] == ' ' || packet[insertAt-1] == '\t') {
+		insertAt--
+	}
+
+	var fields bytes.Buffer
+	writeXMPFields(&fields, meta)
+
+	var b bytes.Buffer
+	pos := 0
+	slices.SortFunc(cut, func(a, b span) int { return a.from - b.from })
+	for _, c := range cut {
+		if c.to > insertAt {
+			break
+		}
+		b.Write(packet[pos:c.from])
+		pos = c.to
+	}
+	b.Write(packet[pos:insertAt])
+	if fields.Len() > 0 {
+		b.WriteString(`  <rdf:Description rdf:about="" xmlns:rdf="` + nsRDF + `" xmlns:dc="` + nsDC + `" xmlns:xmp="` + nsXMP + `" xmlns:pdf="` + nsPDF + `">` + "\n")
+		b.Write(fields.Bytes())
+		b.WriteString("  </rdf:Description>\n")
+	}
+	b.Write(packet[insertAt:])
+	return b.Bytes(), nil
+}
@@ -0,0 +1,185 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuildAndParseXMP(t *testing.T) {
+	meta := &Metadata{
+		Title:    "Договор <№ 5>",
+		Author:   "Jane Doe",
+		Subject:  "Lease",
+		Keywords: "lease, 2024",
+		Creator:  "Writer",
+		Producer: "pdf-tools",
+		Created:  time.Date(2024, 5, 1, 14, 30, 0, 0, time.FixedZone("", 3*3600)),
+	}
+	old := []byte(`<rdf:Description xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/" pdfaid:part="2" pdfaid:conformance="B"/>`)
+	packet := buildXMP(meta, old)
+
+	got := parseXMP(packet)
+	want := map[string]string{
+		"title":    meta.Title,
+		"author":   meta.Author,
+		"subject":  meta.Subject,
+		"keywords": meta.Keywords,
+		"creator":  meta.Creator,
+		"producer": meta.Producer,
+		"created":  "2024-05-01T14:30:00+03:00",
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %q, want %q", name, got[name], value)
+		}
+	}
+	if _, ok := got["modified"]; ok {
+		t.Error("modified should be missing")
+	}
+	if !parseXMPDate(got["created"]).Equal(meta.Created) {
+		t.Errorf("created = %v", parseXMPDate(got["created"]))
+	}
+
+	// The PDF/A identification survives, as elements
+	if !bytes.Contains(packet, []byte("<pdfaid:part>2</pdfaid:part>")) || !bytes.Contains(packet, []byte("<pdfaid:conformance>B</pdfaid:conformance>")) {
+		t.Errorf("PDF/A identification lost:\n%s", packet)
+	}
+}
+
+func TestParseXMP_Attributes(t *testing.T) {
+	packet := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+		<rdf:Description rdf:about="" xmlns:pdf="http://ns.adobe.com/pdf/1.3/" xmlns:xmp="http://ns.adobe.com/xap/1.0/"
+			pdf:Producer="GPL Ghostscript" xmp:ModifyDate="2024-05-01T10:00Z"/>
+		<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+			<dc:creator><rdf:Seq><rdf:li>Ann</rdf:li><rdf:li>Bob</rdf:li></rdf:Seq></dc:creator>
+		</rdf:Description></rdf:RDF></x:xmpmeta>`)
+
+	got := parseXMP(packet)
+	if got["producer"] != "GPL Ghostscript" || got["author"] != "Ann; Bob" {
+		t.Errorf("parseXMP = %v", got)
+	}
+	if d := parseXMPDate(got["modified"]); !d.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("modified = %v", d)
+	}
+}
+
+func TestPatchXMP(t *testing.T) {
+	packet := []byte(`<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:pdf="http://ns.adobe.com/pdf/1.3/" xmlns:xmpMM="http://ns.adobe.com/xap/1.0/mm/"
+    pdf:Producer="GPL Ghostscript" xmpMM:DocumentID="uuid:1234"/>
+  <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:pdfuaid="http://www.aiim.org/pdfua/ns/id/">
+   <dc:title><rdf:Alt><rdf:li xml:lang="x-default">Old title</rdf:li></rdf:Alt></dc:title>
+   <pdfuaid:part>1</pdfuaid:part>
+  </rdf:Description>
+  <rdf:Description rdf:about="" xmlns:acme="http://example.com/acme/">
+   <acme:Project>Lease</acme:Project>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`)
+
+	meta := &Metadata{Title: "New title", Author: "Jane Doe"}
+	patched, err := patchXMP(packet, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := parseXMP(patched)
+	if got["title"] != "New title" || got["author"] != "Jane Doe" || got["producer"] != "" {
+		t.Errorf("parseXMP = %v", got)
+	}
+	for _, kept := range []string{`xmpMM:DocumentID="uuid:1234"`, "<pdfuaid:part>1</pdfuaid:part>", "<acme:Project>Lease</acme:Project>", `<?xpacket end="w"?>`} {
+		if !bytes.Contains(patched, []byte(kept)) {
+			t.Errorf("%s was lost:\n%s", kept, patched)
+		}
+	}
+	if bytes.Contains(patched, []byte("Old title")) || bytes.Contains(patched, []byte("Ghostscript")) {
+		t.Errorf("old values were kept:\n%s", patched)
+	}
+	dec := xml.NewDecoder(bytes.NewReader(patched))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("patched packet is not well formed: %v\n%s", err, patched)
+		}
+	}
+
+	if _, err := patchXMP([]byte("<x:xmpmeta"), meta); err == nil {
+		t.Error("expected an error for a broken packet")
+	}
+}
+
+func TestMetadata_SetField(t *testing.T) {
+	var meta Metadata
+	if err := meta.SetField("title", " Report "); err != nil || meta.Title != "Report" {
+		t.Errorf("SetField(title) = %v, %q", err, meta.Title)
+	}
+	if err := meta.SetField("created", "2024-05-01"); err != nil || meta.Field("created") == "" {
+		t.Errorf("SetField(created) = %v, %v", err, meta.Created)
+	}
+	if err := meta.SetField("created", "yesterday"); err == nil {
+		t.Error("expected an error for an invalid date")
+	}
+	if err := meta.SetField("colour", "red"); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+	if meta.IsEmpty() {
+		t.Error("IsEmpty = true with a title")
+	}
+	meta.SetField("title", "")
+	meta.SetField("created", "")
+	if !meta.IsEmpty() {
+		t.Errorf("IsEmpty = false for %+v", meta)
+	}
+}
+
+func TestParseMetadataDate(t *testing.T) {
+	want := time.Date(2024, 5, 1, 14, 30, 0, 0, time.FixedZone("", 3*3600))
+	for _, s := range []string{"2024-05-01T14:30:00+03:00", "D:20240501143000+03'00'"} {
+		if got, err := ParseMetadataDate(s); err != nil || !got.Equal(want) {
+			t.Errorf("ParseMetadataDate(%q) = %v, %v", s, got, err)
+		}
+	}
+	if got, err := ParseMetadataDate("2024-05-01 14:30"); err != nil || got.Hour() != 14 || got.Location() != time.Local {
+		t.Errorf("ParseMetadataDate(local) = %v, %v", got, err)
+	}
+}
+
+func TestWriteMetadata_Integration(t *testing.T) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping metadata test")
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	ctx := context.Background()
+
+	output := filepath.Join(tempDir, "meta.pdf")
+	fields := map[string]string{"title": "Новини", "author": "Jane Doe", "created": "2024-05-01", "producer": ""}
+	if err := WriteMetadata(ctx, inputPath, output, fields); err != nil {
+		t.Fatalf("WriteMetadata: %v", err)
+	}
+	meta, err := ReadMetadata(ctx, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "Новини" || meta.Author != "Jane Doe" || meta.Producer != "" || meta.Created.Day() != 1 || meta.XMP == "" {
+		t.Errorf("ReadMetadata = %+v", meta)
+	}
+
+	stripped := filepath.Join(tempDir, "stripped.pdf")
+	if err := replaceMetadata(ctx, output, stripped, &Metadata{}); err != nil {
+		t.Fatal(err)
+	}
+	if meta, err := ReadMetadata(ctx, stripped); err != nil || !meta.IsEmpty() || meta.XMP != "" {
+		t.Errorf("after stripping: %+v, %v", meta, err)
+	}
+}
@@ -0,0 +1,192 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultOCRLanguage is used when no language is configured.
+	DefaultOCRLanguage = "eng"
+
+	// ocrDPI is the resolution pages are rendered at for tesseract.
+	ocrDPI = 300
+
+	// minTextRunes is how much text a page needs to count as having a text
+	// layer; scans often carry a stray page number or stamp.
+	minTextRunes = 16
+)
+
+// ocrLanguagePattern matches tesseract language specs such as "bul+eng".
+var ocrLanguagePattern = regexp.MustCompile(`^[A-Za-z_]+(\+[A-Za-z_]+)*$`)
+
+// TesseractAvailable reports whether the tesseract binary is on PATH.
+func TesseractAvailable() bool {
+	_, err := exec.LookPath("tesseract")
+	return err == nil
+}
+
+// CheckOCRLanguage verifies that tesseract is installed and has the trained
+// data for every language in lang ("" means DefaultOCRLanguage). A malformed
+// lang is an ErrInvalidOCRLanguage error, a language without trained data
+// an ErrOCRLanguageMissing one.
+func CheckOCRLanguage(ctx context.Context, lang string) error {
+	if lang == "" {
+		lang = DefaultOCRLanguage
+	}
+	if !ocrLanguagePattern.MatchString(lang) {
+		return fmt.Errorf("%w %q, expected e.g. eng or bul+eng", ErrInvalidOCRLanguage, lang)
+	}
+	if !TesseractAvailable() {
+		return fmt.Errorf("tesseract is not installed")
+	}
+
+	var stdout bytes.Buffer
+	cmd := commandContext(ctx, "tesseract", "--list-langs")
+	cmd.Stdout = &stdout
+	if err := runCommand(ctx, cmd); err != nil {
+		return fmt.Errorf("tesseract --list-langs: %w", err)
+	}
+
+	// The first line is a header: List of available languages in "..." (N):
+	installed := strings.Fields(stdout.String())
+	for _, l := range strings.Split(lang, "+") {
+		if !slices.Contains(installed, l) {
+			return fmt.Errorf("%w: tesseract has no trained data for %q (install tesseract-ocr-%s)", ErrOCRLanguageMissing, l, l)
+		}
+	}
+	return nil
+}
+
+// hasTextLayer reports whether page has enough extracted text.
+func hasTextLayer(page PageText) bool {
+	n := 0
+	for _, b := range page.Blocks {
+		for _, r := range b.Text {
+			if !unicode.IsSpace(r) {
+				n++
+			}
+		}
+	}
+	return n >= minTextRunes
+}
+
+// ocrPage renders page (1-based) of pdfPath with Ghostscript and returns
+// the paragraphs tesseract finds, in points like the extractors' blocks.
+func ocrPage(ctx context.Context, pdfPath string, page int, lang string, workDir string) ([]TextBlock, error) {
+	image := filepath.Join(workDir, fmt.Sprintf("ocr-%d.png", page))
+	if err := renderPage(ctx, pdfPath, page, ocrDPI, "pnggray", image); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := commandContext(ctx, "tesseract", image, "stdout", "-l", lang, "--dpi", strconv.Itoa(ocrDPI), "tsv")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := runCommand(ctx, cmd); err != nil {
+		return nil, fmt.Errorf("tesseract: %w: %s", err, lastLine(stderr.String()))
+	}
+
+	return parseTesseractTSV(stdout.Bytes(), 72.0/ocrDPI)
+}
+
+// renderPage rasterizes one page with the given Ghostscript device.
+// extraArgs are device options such as -dJPEGQ=85.
+func renderPage(ctx context.Context, pdfPath string, page int, dpi int, device string, out string, extraArgs ...string) error {
+	args := []string{
+		"-dSAFER", "-dBATCH", "-dNOPAUSE", "-q",
+		"-sDEVICE=" + device,
+		fmt.Sprintf("-r%d", dpi),
+		fmt.Sprintf("-dFirstPage=%d", page),
+		fmt.Sprintf("-dLastPage=%d", page),
+		"-dTextAlphaBits=4", "-dGraphicsAlphaBits=4",
+	}
+	args = append(args, extraArgs...)
+	args = append(args, "-o", out, pdfPath)
+
+	var stderr bytes.Buffer
+	cmd := commandContext(ctx, "gs", args...)
+	cmd.Stderr = &stderr
+	if err := runCommand(ctx, cmd); err != nil {
+		return fmt.Errorf("rendering page %d: %w: %s", page, err, lastLine(stderr.String()))
+	}
+	return nil
+}
+
+// parseTesseractTSV groups the words of tesseract's TSV output into one
+// block per paragraph, lines separated by "\n". scale converts pixels to
+// points.
+func parseTesseractTSV(data []byte, scale float64) ([]TextBlock, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = '\t'
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	type key struct{ block, par int }
+	var order []key
+	paragraphs := map[key]*TextBlock{}
+	currentLine := map[key]int{}
+
+	for first := true; ; first = false {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tesseract output: %w", err)
+		}
+		// level page block par line word left top width height conf text
+		if first || len(rec) < 12 || rec[0] != "5" {
+			continue
+		}
+		text := strings.TrimSpace(rec[11])
+		if text == "" {
+			continue
+		}
+
+		n := make([]int, 10)
+		for i := range n {
+			if n[i], err = strconv.Atoi(rec[i]); err != nil {
+				return nil, fmt.Errorf("tesseract output: bad field %q", rec[i])
+			}
+		}
+		k := key{n[2], n[3]}
+		line := n[4]
+		x0, y0 := float64(n[6])*scale, float64(n[7])*scale
+		x1, y1 := x0+float64(n[8])*scale, y0+float64(n[9])*scale
+
+		p, ok := paragraphs[k]
+		if !ok {
+			p = &TextBlock{Text: text, X0: x0, Y0: y0, X1: x1, Y1: y1}
+			paragraphs[k] = p
+			order = append(order, k)
+			currentLine[k] = line
+			continue
+		}
+
+		sep := " "
+		if line != currentLine[k] {
+			sep = "\n"
+			currentLine[k] = line
+		}
+		p.Text += sep + text
+		p.X0, p.Y0 = min(p.X0, x0), min(p.Y0, y0)
+		p.X1, p.Y1 = max(p.X1, x1), max(p.Y1, y1)
+	}
+
+	blocks := make([]TextBlock, 0, len(order))
+	for _, k := range order {
+		blocks = append(blocks, *paragraphs[k])
+	}
+	return blocks, nil
+}
@@ -0,0 +1,286 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OCR makes scanned PDFs searchable by adding an invisible text layer read
+// by tesseract. Pages keep their original images unless Deskew is set, in
+// which case they are replaced by the straightened scan.
+type OCR struct {
+	// Language is the tesseract language spec, e.g. "bul+eng". Empty means
+	// DefaultOCRLanguage.
+	Language string
+
+	// Deskew straightens every OCR'd page with ImageMagick first. The page
+	// is then rebuilt from the deskewed image instead of keeping the
+	// original content.
+	Deskew bool
+
+	// Force OCRs pages that already have a text layer too.
+	Force bool
+
+	// DPI is the rendering resolution. 0 means 300.
+	DPI int
+
+	// Workers limits how many pages are processed at once. 0 means the
+	// number of CPUs.
+	Workers int
+
+	// Extractor finds the pages that already have text. nil uses the first
+	// available extractor; without one every page is OCR'd.
+	Extractor TextExtractor
+}
+
+// OCRResult describes a finished OCR run.
+type OCRResult struct {
+	PageCount    int
+	OCRPages     []int // 1-based pages that got a text layer
+	SkippedPages []int // pages that already had text
+	Duration     time.Duration
+}
+
+func NewOCR(language string) *OCR {
+	return &OCR{Language: language}
+}
+
+// Run writes a searchable copy of inputPath to outputPath.
+func (o *OCR) Run(ctx context.Context, inputPath string, outputPath string) (result *OCRResult, err error) {
+	started := time.Now()
+	lang := o.Language
+	if lang == "" {
+		lang = DefaultOCRLanguage
+	}
+	if err := CheckOCRLanguage(ctx, lang); err != nil {
+		return nil, err
+	}
+	if o.Deskew && !ImageMagickAvailable() {
+		return nil, fmt.Errorf("deskew needs ImageMagick (magick or convert)")
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(outputPath)
+		}
+	}()
+
+	total, err := PageCount(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result = &OCRResult{PageCount: total}
+	pages, err := o.pagesToOCR(ctx, inputPath, total, result)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		result.Duration = time.Since(started)
+		return result, copyFile(inputPath, outputPath)
+	}
+
+	workDir, err := os.MkdirTemp(filepath.Dir(outputPath), "ocr_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	pagePDFs, err := o.ocrPages(ctx, inputPath, pages, lang, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Deskew {
+		err = replacePages(ctx, inputPath, outputPath, total, pages, pagePDFs)
+	} else {
+		err = overlayText(ctx, inputPath, outputPath, pages, pagePDFs, workDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.OCRPages = pages
+	result.Duration = time.Since(started)
+	return result, nil
+}
+
+// pagesToOCR returns the pages without a text layer (all pages with Force
+// or when no extractor is available) and records the others as skipped.
+func (o *OCR) pagesToOCR(ctx context.Context, inputPath string, total int, result *OCRResult) ([]int, error) {
+	all := make([]int, total)
+	for i := range all {
+		all[i] = i + 1
+	}
+	if o.Force {
+		return all, nil
+	}
+
+	extractor := o.Extractor
+	if extractor == nil {
+		var err error
+		if extractor, err = defaultExtractor(); err != nil {
+			return all, nil
+		}
+	}
+
+	text, err := extractor.Extract(ctx, inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("checking for a text layer: %w", err)
+	}
+
+	hasText := map[int]bool{}
+	for _, page := range text {
+		hasText[page.Number] = hasTextLayer(page)
+	}
+
+	var pages []int
+	for _, n := range all {
+		if hasText[n] {
+			result.SkippedPages = append(result.SkippedPages, n)
+		} else {
+			pages = append(pages, n)
+		}
+	}
+	return pages, nil
+}
+
+// ocrPages runs tesseract on every page in parallel and returns one PDF per
+// page: only the invisible text, or with Deskew the image plus text.
+func (o *OCR) ocrPages(ctx context.Context, inputPath string, pages []int, lang string, workDir string) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := o.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	outputs := make([]string, len(pages))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	for i, page := range pages {
+		wg.Add(1)
+		go func(idx int, page int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			out, err := o.ocrPageToPDF(ctx, inputPath, page, lang, workDir, workers > 1)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("page %d: %w", page, err)
+				}
+				cancel()
+				return
+			}
+			outputs[idx] = out
+		}(i, page)
+	}
+
+	wg.Wait()
+	if ctx.Err() != nil && firstErr == nil {
+		return nil, contextError(ctx)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return outputs, nil
+}
+
+func (o *OCR) ocrPageToPDF(ctx context.Context, inputPath string, page int, lang string, workDir string, parallel bool) (string, error) {
+	dpi := o.DPI
+	if dpi <= 0 {
+		dpi = ocrDPI
+	}
+
+	base := filepath.Join(workDir, fmt.Sprintf("page-%d", page))
+	image := base + ".png"
+
+	args := []string{"-l", lang, "--dpi", strconv.Itoa(dpi)}
+	if o.Deskew {
+		if err := renderPage(ctx, inputPath, page, dpi, "png16m", image); err != nil {
+			return "", err
+		}
+		deskewed := base + ".jpg"
+		if err := runMagick(ctx, image, "-deskew", "40%", "+repage", "-quality", "85", deskewed); err != nil {
+			return "", err
+		}
+		image = deskewed
+	} else {
+		if err := renderPage(ctx, inputPath, page, dpi, "pnggray", image); err != nil {
+			return "", err
+		}
+		args = append(args, "-c", "textonly_pdf=1")
+	}
+
+	cmd := commandContext(ctx, "tesseract", append(append([]string{image, base}, args...), "pdf")...)
+	if parallel {
+		// Pages already run side by side; tesseract's own threads would
+		// only compete with each other.
+		cmd.Env = append(os.Environ(), "OMP_THREAD_LIMIT=1")
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := runCommand(ctx, cmd); err != nil {
+		return "", fmt.Errorf("tesseract: %w: %s", err, lastLine(stderr.String()))
+	}
+	return base + ".pdf", nil
+}
+
+// overlayText stamps the text-only pages onto the original pages.
+func overlayText(ctx context.Context, inputPath string, outputPath string, pages []int, pagePDFs []string, workDir string) error {
+	textLayer := filepath.Join(workDir, "text.pdf")
+	args := []string{"--empty", "--pages"}
+	args = append(args, pagePDFs...)
+	args = append(args, "--", textLayer)
+	if err := runQPDF(ctx, args...); err != nil {
+		return fmt.Errorf("joining text layers: %w", err)
+	}
+
+	return runQPDF(ctx, inputPath,
+		"--overlay", textLayer, "--to="+pageRanges(pages), fmt.Sprintf("--from=1-%d", len(pages)), "--",
+		outputPath)
+}
+
+// replacePages swaps the OCR'd pages for their rebuilt versions, keeping
+// the rest of the document (outline, metadata) from inputPath.
+func replacePages(ctx context.Context, inputPath string, outputPath string, total int, pages []int, pagePDFs []string) error {
+	replacement := map[int]string{}
+	for i, page := range pages {
+		replacement[page] = pagePDFs[i]
+	}
+
+	args := []string{inputPath, "--pages"}
+	var kept []int
+	flush := func() {
+		if len(kept) > 0 {
+			args = append(args, inputPath, pageRanges(kept))
+			kept = nil
+		}
+	}
+	for page := 1; page <= total; page++ {
+		if pdf, ok := replacement[page]; ok {
+			flush()
+			args = append(args, pdf, "1")
+			continue
+		}
+		kept = append(kept, page)
+	}
+	flush()
+	args = append(args, "--", outputPath)
+
+	return runQPDF(ctx, args...)
+}
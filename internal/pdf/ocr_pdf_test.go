@@ -0,0 +1,58 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestPageRanges(t *testing.T) {
+	cases := map[string][]int{
+		"":           nil,
+		"4":          {4},
+		"1-3,7":      {1, 2, 3, 7},
+		"2,4-5,9-10": {2, 4, 5, 9, 10},
+	}
+	for want, pages := range cases {
+		if got := pageRanges(pages); got != want {
+			t.Errorf("pageRanges(%v) = %q, want %q", pages, got, want)
+		}
+	}
+}
+
+func TestOCR_Run_Integration(t *testing.T) {
+	for _, bin := range []string{"gs", "qpdf"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not found, skipping OCR test", bin)
+		}
+	}
+	if err := CheckOCRLanguage(context.Background(), DefaultOCRLanguage); err != nil {
+		t.Skipf("%v, skipping OCR test", err)
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	outputPath := filepath.Join(tempDir, "searchable.pdf")
+
+	ocr := NewOCR(DefaultOCRLanguage)
+	ocr.Force = true
+
+	t.Logf("🚀 Adding a text layer to: %s", inputPath)
+	res, err := ocr.Run(context.Background(), inputPath, outputPath)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if len(res.OCRPages) != res.PageCount {
+		t.Errorf("❌ OCR'd %d of %d pages with Force", len(res.OCRPages), res.PageCount)
+	}
+	if n, err := PageCount(context.Background(), outputPath); err != nil || n != res.PageCount {
+		t.Errorf("❌ output has %d pages (%v), want %d", n, err, res.PageCount)
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		t.Fatalf("❌ output was not created: %v", err)
+	}
+
+	t.Logf("✅ OCR'd %d pages in %v", len(res.OCRPages), res.Duration)
+}
@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestParseTesseractTSV(t *testing.T) {
+	const tsv = "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+		"1\t1\t0\t0\t0\t0\t0\t0\t2480\t3508\t-1\t\n" +
+		"5\t1\t1\t1\t1\t1\t300\t300\t200\t50\t96.1\tЗдравей,\n" +
+		"5\t1\t1\t1\t1\t2\t520\t300\t180\t50\t95.0\tсвят\n" +
+		"5\t1\t1\t1\t2\t1\t300\t360\t150\t50\t91.3\tнов\"ред\n" +
+		"5\t1\t1\t1\t2\t2\t460\t360\t10\t50\t10.0\t \n" +
+		"5\t1\t2\t1\t1\t1\t300\t1000\t400\t50\t93.3\tSecond\n"
+
+	got, err := parseTesseractTSV([]byte(tsv), 72.0/300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []TextBlock{
+		{Text: "Здравей, свят\nнов\"ред", X0: 72, Y0: 72, X1: 168, Y1: 98.4},
+		{Text: "Second", X0: 72, Y0: 240, X1: 168, Y1: 252},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d blocks, want %d: %#v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Text != w.Text || !near(g.X0, w.X0) || !near(g.Y0, w.Y0) || !near(g.X1, w.X1) || !near(g.Y1, w.Y1) {
+			t.Errorf("block %d = %#v, want %#v", i, g, w)
+		}
+	}
+}
+
+func near(a, b float64) bool {
+	return a-b < 0.01 && b-a < 0.01
+}
+
+func TestOCRTextlessPages_WarnsWithoutOCR(t *testing.T) {
+	pages := []PageText{
+		{Number: 1, Blocks: []TextBlock{{Text: "Enough text on this page to count."}}},
+		{Number: 2, Blocks: []TextBlock{{Text: "12"}}},
+		{Number: 3},
+	}
+
+	result := &ConvertResult{}
+	if err := ocrTextlessPages(context.Background(), "in.pdf", pages, t.TempDir(), ConvertOptions{}, result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"pages 2, 3 have no text layer, enable OCR to read scanned pages"}
+	if !reflect.DeepEqual(result.Warnings, want) || len(result.OCRPages) != 0 {
+		t.Errorf("result = %+v, want warnings %q", result, want)
+	}
+}
+
+func TestCheckOCRLanguage(t *testing.T) {
+	for _, lang := range []string{"-l", "bul eng", "eng+", "../eng"} {
+		if err := CheckOCRLanguage(context.Background(), lang); !errors.Is(err, ErrInvalidOCRLanguage) {
+			t.Errorf("CheckOCRLanguage(%q) = %v, want invalid language error", lang, err)
+		}
+	}
+
+	if _, err := exec.LookPath("tesseract"); err != nil {
+		t.Skip("tesseract not found, skipping language check")
+	}
+	if err := CheckOCRLanguage(context.Background(), "zzz"); !errors.Is(err, ErrOCRLanguageMissing) {
+		t.Error("CheckOCRLanguage(zzz) should report missing trained data")
+	}
+}
+
+func TestConverter_OCR_Integration(t *testing.T) {
+	if _, err := defaultExtractor(); err != nil {
+		t.Skipf("%v, skipping OCR test", err)
+	}
+	if _, err := exec.LookPath("gs"); err != nil {
+		t.Skip("Ghostscript (gs) not found, skipping OCR test")
+	}
+	if err := CheckOCRLanguage(context.Background(), DefaultOCRLanguage); err != nil {
+		t.Skipf("%v, skipping OCR test", err)
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+
+	// Newspaper pages have a text layer, so nothing should be OCR'd
+	res, err := NewConverter().ToText(context.Background(), inputPath, tempDir, ConvertOptions{Sort: true, OCR: true})
+	if err != nil {
+		t.Fatalf("ToText with OCR returned error: %v", err)
+	}
+	if len(res.OCRPages) != 0 {
+		t.Errorf("❌ OCR ran on pages %v that have text", res.OCRPages)
+	}
+	t.Logf("✅ Converted with OCR enabled: %s", res.Path)
+}
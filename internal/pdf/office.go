@@ -0,0 +1,134 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultOfficeTimeout bounds a single LibreOffice conversion.
+const DefaultOfficeTimeout = 90 * time.Second
+
+// officeExtensions are the document types OfficeConverter accepts.
+var officeExtensions = []string{".docx", ".doc", ".odt", ".rtf", ".xlsx", ".xls", ".ods", ".pptx", ".ppt", ".odp"}
+
+// IsOfficeDocument reports whether name has an extension OfficeConverter
+// accepts.
+func IsOfficeDocument(name string) bool {
+	return slices.Contains(officeExtensions, strings.ToLower(filepath.Ext(name)))
+}
+
+// officeBinary returns "soffice" or, as some distributions name it,
+// "libreoffice".
+func officeBinary() (string, error) {
+	for _, name := range []string{"soffice", "libreoffice"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("LibreOffice (soffice) is not installed")
+}
+
+// OfficeAvailable reports whether LibreOffice is on PATH.
+func OfficeAvailable() bool {
+	_, err := officeBinary()
+	return err == nil
+}
+
+// OfficeConverter turns DOCX, XLSX, PPTX, ODT and similar documents into
+// PDF with a headless LibreOffice. Every job gets its own user profile, as
+// concurrent soffice processes sharing one profile block or fail.
+type OfficeConverter struct {
+	// Timeout per document; 0 means DefaultOfficeTimeout.
+	Timeout time.Duration
+
+	slots chan struct{}
+}
+
+// NewOfficeConverter returns a converter running at most maxJobs soffice
+// processes at once (1 when maxJobs < 1). Share one converter to enforce
+// the cap.
+func NewOfficeConverter(maxJobs int) *OfficeConverter {
+	return &OfficeConverter{
+		Timeout: DefaultOfficeTimeout,
+		slots:   make(chan struct{}, max(maxJobs, 1)),
+	}
+}
+
+// Convert writes outputDir/<name>.pdf and returns its path. It waits for a
+// free slot first; cancelling ctx while waiting or converting returns
+// ErrCanceled or ErrTimeout.
+func (c *OfficeConverter) Convert(ctx context.Context, inputPath string, outputDir string) (string, error) {
+	if !IsOfficeDocument(inputPath) {
+		return "", fmt.Errorf("unsupported document type %q (use %s)", filepath.Ext(inputPath), strings.Join(officeExtensions, ", "))
+	}
+	bin, err := officeBinary()
+	if err != nil {
+		return "", err
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+		defer func() { <-c.slots }()
+	case <-ctx.Done():
+		return "", contextError(ctx)
+	}
+
+	workDir, err := os.MkdirTemp(outputDir, "office_")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	profile, err := filepath.Abs(filepath.Join(workDir, "profile"))
+	if err != nil {
+		return "", err
+	}
+	convertedDir := filepath.Join(workDir, "out")
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultOfficeTimeout
+	}
+	jobCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := commandContext(jobCtx, bin,
+		"--headless", "--norestore", "--nolockcheck", "--nodefault",
+		"-env:UserInstallation="+(&url.URL{Scheme: "file", Path: profile}).String(),
+		"--convert-to", "pdf",
+		"--outdir", convertedDir,
+		inputPath,
+	)
+	cmd.Stderr = &stderr
+	err = runCommand(jobCtx, cmd)
+	if IsAborted(err) && ctx.Err() == nil {
+		// Our own timeout, not the caller's: report it as a failure
+		return "", fmt.Errorf("soffice did not finish within %v", timeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("soffice: %w: %s", err, lastLine(stderr.String()))
+	}
+
+	fileName := filepath.Base(inputPath)
+	baseName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	converted := filepath.Join(convertedDir, baseName+".pdf")
+	// soffice exits 0 even when it could not read the document
+	if _, err := os.Stat(converted); err != nil {
+		return "", fmt.Errorf("soffice produced no PDF: %s", lastLine(stderr.String()))
+	}
+
+	outputPath := filepath.Join(outputDir, baseName+".pdf")
+	if err := os.Rename(converted, outputPath); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
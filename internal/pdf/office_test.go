@@ -0,0 +1,114 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSoffice puts an "soffice" shell script first on PATH. It records the
+// profile it was given and runs body.
+func fakeSoffice(t *testing.T, body string) string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found, skipping fake soffice test")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "soffice"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return bin
+}
+
+func TestIsOfficeDocument(t *testing.T) {
+	for name, want := range map[string]bool{"a.DOCX": true, "b.odt": true, "c.pptx": true, "d.pdf": false, "e": false} {
+		if got := IsOfficeDocument(name); got != want {
+			t.Errorf("IsOfficeDocument(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestOfficeConverter_Convert(t *testing.T) {
+	// Writes <outdir>/<name>.pdf like soffice and logs the profile option
+	fakeSoffice(t, `
+for arg; do
+	case "$prev" in --outdir) outdir="$arg" ;; esac
+	case "$arg" in -env:UserInstallation=*) echo "$arg" > "$LOG" ;; esac
+	prev="$arg"; last="$arg"
+done
+mkdir -p "$outdir"
+name=$(basename "$last"); echo "%PDF-1.4" > "$outdir/${name%.*}.pdf"`)
+
+	dir := t.TempDir()
+	t.Setenv("LOG", filepath.Join(dir, "args.log"))
+	input := filepath.Join(dir, "report.docx")
+	os.WriteFile(input, []byte("docx"), 0644)
+
+	out, err := NewOfficeConverter(1).Convert(context.Background(), input, dir)
+	if err != nil {
+		t.Fatalf("Convert returned error: %v", err)
+	}
+	if out != filepath.Join(dir, "report.pdf") {
+		t.Errorf("output = %s, want report.pdf in the output dir", out)
+	}
+
+	logged, _ := os.ReadFile(filepath.Join(dir, "args.log"))
+	if !strings.Contains(string(logged), "file://"+dir) || !strings.Contains(string(logged), "/profile") {
+		t.Errorf("soffice did not get a private profile: %s", logged)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "office_*")); len(leftovers) != 0 {
+		t.Errorf("work dirs were not removed: %v", leftovers)
+	}
+}
+
+func TestOfficeConverter_Timeout(t *testing.T) {
+	fakeSoffice(t, "sleep 30")
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "slow.odt")
+	os.WriteFile(input, []byte("odt"), 0644)
+
+	c := NewOfficeConverter(1)
+	c.Timeout = 200 * time.Millisecond
+	_, err := c.Convert(context.Background(), input, dir)
+	if err == nil || IsAborted(err) || !strings.Contains(err.Error(), "did not finish") {
+		t.Errorf("expected a converter timeout that is not an abort, got %v", err)
+	}
+}
+
+func TestOfficeConverter_WaitsForSlot(t *testing.T) {
+	fakeSoffice(t, "exit 0")
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "queued.xlsx")
+	os.WriteFile(input, []byte("xlsx"), 0644)
+
+	c := NewOfficeConverter(1)
+	c.slots <- struct{}{} // another job holds the only slot
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.Convert(ctx, input, dir); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout while waiting for a slot, got %v", err)
+	}
+}
+
+func TestOfficeConverter_NoOutput(t *testing.T) {
+	fakeSoffice(t, "echo 'Error: source file could not be loaded' >&2")
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "broken.pptx")
+	os.WriteFile(input, []byte("pptx"), 0644)
+
+	_, err := NewOfficeConverter(1).Convert(context.Background(), input, dir)
+	if err == nil || !strings.Contains(err.Error(), "could not be loaded") {
+		t.Errorf("expected soffice's error, got %v", err)
+	}
+}
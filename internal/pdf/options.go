@@ -0,0 +1,296 @@
+package pdf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DownsampleType selects the filter Ghostscript uses when lowering image resolution.
+type DownsampleType string
+
+const (
+	DownsampleBicubic   DownsampleType = "Bicubic"   // best quality
+	DownsampleAverage   DownsampleType = "Average"   // good quality, faster
+	DownsampleSubsample DownsampleType = "Subsample" // fastest, visible aliasing
+)
+
+// ColorStrategy is passed to Ghostscript as -sColorConversionStrategy.
+type ColorStrategy string
+
+const (
+	ColorUnchanged ColorStrategy = "LeaveColorUnchanged"
+	ColorRGB       ColorStrategy = "RGB"  // drops CMYK ink channels
+	ColorGray      ColorStrategy = "Gray" // grayscale output
+	ColorCMYK      ColorStrategy = "CMYK"
+)
+
+// CompressOptions describes one run of the compression pipeline. The
+// CompressionLevel presets are built on it; callers can start from a preset
+// via CompressionLevel.Options and adjust single fields.
+type CompressOptions struct {
+	// PDFSettings is the Ghostscript distiller preset ("/screen", "/ebook",
+	// "/printer") the explicit settings below are applied on top of.
+	PDFSettings string
+
+	// Target resolution of color, grayscale and monochrome images.
+	ColorDPI int
+	GrayDPI  int
+	MonoDPI  int
+
+	// JPEGQuality (1-100) forces every color and gray image to be re-encoded
+	// as JPEG with this quality. 0 lets Ghostscript pass existing JPEGs through.
+	JPEGQuality int
+
+	Downsample DownsampleType
+
+	KeepBookmarks   bool
+	KeepAnnotations bool // comments, links, stamps, ...
+	KeepForms       bool // interactive AcroForm fields
+
+	ColorStrategy ColorStrategy
+
+	// CompatibilityLevel is the PDF version of the output, e.g. "1.4".
+	CompatibilityLevel string
+
+	// Lossless skips Ghostscript and every image setting above: only object
+	// streams, Flate recompression and removal of unused and duplicate
+	// objects are applied, so pages render exactly as before.
+	Lossless bool
+
+	// StripMetadata removes the document Info dictionary and the XMP
+	// metadata stream. When that fails the compression fails too, rather
+	// than return a file that still has them.
+	StripMetadata bool
+
+	// KeepMetadata lists fields of MetadataFields copied from the input to
+	// the result, e.g. "title", "author". Without it the metadata is what
+	// the backends leave (Ghostscript sets its own producer and dates), or
+	// nothing with StripMetadata.
+	KeepMetadata []string
+
+	// Force compresses digitally signed PDFs too. Every backend rewrites
+	// the whole file, which invalidates the signatures, so without Force a
+	// signed input is copied unchanged.
+	Force bool
+
+	// SourceSignatures is the number of digital signatures of the original
+	// file when the input is a copy an earlier step rewrote, e.g. decrypted
+	// or OCRed. Those signatures are broken already: without Force the
+	// compression fails with ErrSigned, with Force the report says they were
+	// invalidated. 0 means the input itself is checked.
+	SourceSignatures int
+}
+
+// Options returns the preset the level stands for.
+func (l CompressionLevel) Options() CompressOptions {
+	opts := CompressOptions{
+		PDFSettings:        string(LevelEbook),
+		ColorDPI:           150,
+		GrayDPI:            150,
+		MonoDPI:            150,
+		Downsample:         DownsampleBicubic,
+		KeepBookmarks:      true,
+		ColorStrategy:      ColorUnchanged,
+		CompatibilityLevel: "1.4",
+	}
+
+	switch l {
+	case LevelExtreme:
+		opts.PDFSettings = string(LevelScreen)
+		opts.ColorDPI, opts.GrayDPI, opts.MonoDPI = 72, 72, 72
+		opts.JPEGQuality = 60
+		opts.KeepBookmarks = false
+		opts.ColorStrategy = ColorRGB
+	case LevelScreen:
+		opts.PDFSettings = string(LevelScreen)
+		opts.ColorDPI, opts.GrayDPI, opts.MonoDPI = 72, 72, 72
+		opts.KeepBookmarks = false
+	case LevelPrinter:
+		opts.PDFSettings = string(LevelPrinter)
+		opts.ColorDPI, opts.GrayDPI, opts.MonoDPI = 300, 300, 300
+	case LevelLossless:
+		opts.KeepAnnotations = true
+		opts.KeepForms = true
+		opts.Lossless = true
+		opts.StripMetadata = true
+	}
+
+	return opts
+}
+
+// ParseLevel maps the user facing level names ("extreme", "screen", "ebook",
+// "printer", "lossless") to a CompressionLevel.
+func ParseLevel(name string) (CompressionLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "extreme":
+		return LevelExtreme, nil
+	case "screen":
+		return LevelScreen, nil
+	case "ebook", "":
+		return LevelEbook, nil
+	case "printer":
+		return LevelPrinter, nil
+	case "lossless":
+		return LevelLossless, nil
+	}
+	return "", fmt.Errorf("unknown compression level %q", name)
+}
+
+// Validate reports the first out of range field.
+func (o CompressOptions) Validate() error {
+	for name, dpi := range map[string]int{"color": o.ColorDPI, "gray": o.GrayDPI, "mono": o.MonoDPI} {
+		if dpi < 10 || dpi > 2400 {
+			return fmt.Errorf("%s DPI must be between 10 and 2400, got %d", name, dpi)
+		}
+	}
+
+	if o.JPEGQuality < 0 || o.JPEGQuality > 100 {
+		return fmt.Errorf("JPEG quality must be between 1 and 100 (0 keeps the images as they are), got %d", o.JPEGQuality)
+	}
+
+	switch o.Downsample {
+	case DownsampleBicubic, DownsampleAverage, DownsampleSubsample:
+	default:
+		return fmt.Errorf("unknown downsample type %q", o.Downsample)
+	}
+
+	switch o.ColorStrategy {
+	case ColorUnchanged, ColorRGB, ColorGray, ColorCMYK:
+	default:
+		return fmt.Errorf("unknown color strategy %q", o.ColorStrategy)
+	}
+
+	for _, name := range o.KeepMetadata {
+		if !slices.Contains(MetadataFields, name) {
+			return fmt.Errorf("unknown metadata field %q (use %s)", name, strings.Join(MetadataFields, ", "))
+		}
+	}
+
+	switch o.CompatibilityLevel {
+	case "1.3", "1.4", "1.5", "1.6", "1.7", "2.0":
+	default:
+		return fmt.Errorf("unsupported PDF compatibility level %q", o.CompatibilityLevel)
+	}
+
+	return nil
+}
+
+// ghostscriptArgs translates the options into pdfwrite parameters.
+func (o CompressOptions) ghostscriptArgs() []string {
+	args := []string{
+		fmt.Sprintf("-dPDFSETTINGS=%s", o.PDFSettings),
+		fmt.Sprintf("-dCompatibilityLevel=%s", o.CompatibilityLevel),
+		// Force Downsampling (resolution reduction)
+		"-dDownsampleColorImages=true",
+		"-dDownsampleGrayImages=true",
+		"-dDownsampleMonoImages=true",
+		fmt.Sprintf("-dColorImageResolution=%d", o.ColorDPI),
+		fmt.Sprintf("-dGrayImageResolution=%d", o.GrayDPI),
+		fmt.Sprintf("-dMonoImageResolution=%d", o.MonoDPI),
+		fmt.Sprintf("-dColorImageDownsampleType=/%s", o.Downsample),
+		fmt.Sprintf("-dGrayImageDownsampleType=/%s", o.Downsample),
+		fmt.Sprintf("-dMonoImageDownsampleType=/%s", o.Downsample),
+	}
+
+	if o.JPEGQuality > 0 {
+		args = append(args,
+			// We prohibit GS from passing already compressed images.
+			// This causes GS to decode and re-encode them with our settings.
+			"-dPassThroughJPEGImages=false",
+			// Force JPEG compression (DCTEncode)
+			"-dAutoFilterColorImages=false",
+			"-dAutoFilterGrayImages=false",
+			"-dEncodeColorImages=true",
+			"-dEncodeGrayImages=true",
+			"-dColorImageFilter=/DCTEncode",
+			"-dGrayImageFilter=/DCTEncode",
+		)
+	}
+
+	if o.ColorStrategy != ColorUnchanged {
+		args = append(args, fmt.Sprintf("-sColorConversionStrategy=%s", o.ColorStrategy))
+		switch o.ColorStrategy {
+		case ColorRGB:
+			args = append(args, "-sProcessColorModel=DeviceRGB")
+		case ColorGray:
+			args = append(args, "-sProcessColorModel=DeviceGray")
+		case ColorCMYK:
+			args = append(args, "-sProcessColorModel=DeviceCMYK")
+		}
+	}
+
+	if !o.KeepBookmarks {
+		args = append(args, "-dDiscardBookmarks=true")
+	}
+
+	// Annotations that are not preserved get flattened into the page content.
+	switch {
+	case o.KeepAnnotations && o.KeepForms:
+		args = append(args, "-dPreserveAnnots=true")
+	case o.KeepForms:
+		args = append(args, "-dPreserveAnnots=true", "-dPreserveAnnotTypes=[/Widget]")
+	case o.KeepAnnotations:
+		args = append(args, "-dPreserveAnnots=true", "-dPreserveAnnotTypes="+nonFormAnnotTypes)
+	default:
+		args = append(args,
+			"-dDiscardPageAnnotations=true", // Remove notes and forms
+			"-dPreserveAnnots=false",        // Forces annotation removal
+		)
+	}
+
+	return args
+}
+
+// nonFormAnnotTypes lists every annotation subtype except /Widget.
+const nonFormAnnotTypes = "[/Text /Link /FreeText /Line /Square /Circle /Polygon /PolyLine /Highlight /Underline " +
+	"/Squiggly /StrikeOut /Stamp /Caret /Ink /Popup /FileAttachment /Sound /Movie /Screen /PrinterMark /TrapNet " +
+	"/Watermark /3D /Redact]"
+
+// jpegQFactor converts a 1-100 JPEG quality into the distiller QFactor using
+// the same scaling libjpeg applies to its quantization tables.
+func jpegQFactor(quality int) float64 {
+	if quality < 50 {
+		return 50 / float64(quality)
+	}
+	q := float64(200-2*quality) / 100
+	if q < 0.01 {
+		q = 0.01
+	}
+	return q
+}
+
+// distillerParams returns PostScript that sets the JPEG QFactor, or "" when
+// Ghostscript should keep its preset quality.
+func (o CompressOptions) distillerParams() string {
+	if o.JPEGQuality == 0 {
+		return ""
+	}
+	dict := fmt.Sprintf("<< /QFactor %.2f /Blend 1 /HSamples [2 1 1 2] /VSamples [2 1 1 2] >>", jpegQFactor(o.JPEGQuality))
+	return fmt.Sprintf("<< /ColorImageDict %s /GrayImageDict %s >> setdistillerparams", dict, dict)
+}
+
+// ParseDownsampleType accepts "bicubic", "average" or "subsample" in any case.
+func ParseDownsampleType(name string) (DownsampleType, error) {
+	for _, t := range []DownsampleType{DownsampleBicubic, DownsampleAverage, DownsampleSubsample} {
+		if strings.EqualFold(name, string(t)) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown downsample type %q", name)
+}
+
+// ParseColorStrategy accepts "unchanged", "rgb", "gray" or "cmyk" in any case.
+func ParseColorStrategy(name string) (ColorStrategy, error) {
+	switch strings.ToLower(name) {
+	case "unchanged", "leavecolorunchanged":
+		return ColorUnchanged, nil
+	case "rgb":
+		return ColorRGB, nil
+	case "gray", "grey":
+		return ColorGray, nil
+	case "cmyk":
+		return ColorCMYK, nil
+	}
+	return "", fmt.Errorf("unknown color strategy %q", name)
+}
@@ -0,0 +1,90 @@
+package pdf
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompressionLevel_Options_Valid(t *testing.T) {
+	for _, level := range []CompressionLevel{LevelExtreme, LevelScreen, LevelEbook, LevelPrinter, LevelLossless} {
+		if err := level.Options().Validate(); err != nil {
+			t.Errorf("preset %s is invalid: %v", level, err)
+		}
+	}
+}
+
+func TestCompressOptions_GhostscriptArgs(t *testing.T) {
+	opts := LevelExtreme.Options()
+	args := opts.ghostscriptArgs()
+
+	for _, want := range []string{
+		"-dPDFSETTINGS=/screen",
+		"-dColorImageResolution=72",
+		"-dColorImageFilter=/DCTEncode",
+		"-sColorConversionStrategy=RGB",
+		"-dDiscardBookmarks=true",
+		"-dPreserveAnnots=false",
+	} {
+		if !slices.Contains(args, want) {
+			t.Errorf("extreme preset is missing %s", want)
+		}
+	}
+
+	opts = LevelPrinter.Options()
+	opts.KeepForms = true
+	args = opts.ghostscriptArgs()
+
+	if slices.Contains(args, "-dDiscardBookmarks=true") {
+		t.Error("printer preset must keep bookmarks")
+	}
+	if !slices.Contains(args, "-dPreserveAnnotTypes=[/Widget]") {
+		t.Error("KeepForms must preserve widget annotations")
+	}
+	if opts.distillerParams() != "" {
+		t.Error("printer preset must not force a JPEG quality")
+	}
+}
+
+func TestCompressOptions_Validate(t *testing.T) {
+	opts := LevelEbook.Options()
+	opts.ColorDPI = 5
+	if err := opts.Validate(); err == nil {
+		t.Error("expected error for too low DPI")
+	}
+
+	opts = LevelEbook.Options()
+	opts.CompatibilityLevel = "3.0"
+	if err := opts.Validate(); err == nil {
+		t.Error("expected error for unknown PDF version")
+	}
+
+	opts = LevelEbook.Options()
+	opts.KeepMetadata = []string{"title", "colour"}
+	if err := opts.Validate(); err == nil {
+		t.Error("expected error for unknown metadata field")
+	}
+	for _, quality := range []int{-1, 101} {
+		opts = LevelEbook.Options()
+		opts.JPEGQuality = quality
+		if err := opts.Validate(); err == nil {
+			t.Errorf("expected error for JPEG quality %d", quality)
+		}
+	}
+	opts = LevelEbook.Options()
+	opts.JPEGQuality = 0
+	if err := opts.Validate(); err != nil {
+		t.Errorf("JPEG quality 0 keeps the images, got %v", err)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if level, _ := ParseLevel("extreme"); level != LevelExtreme {
+		t.Errorf("ParseLevel(extreme) = %s", level)
+	}
+	if level, _ := ParseLevel(""); level != LevelEbook {
+		t.Errorf("empty level must default to ebook, got %s", level)
+	}
+	if _, err := ParseLevel("maximum"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
@@ -0,0 +1,182 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Bookmark is an outline entry pointing at a page.
+type Bookmark struct {
+	Title    string
+	Page     int // 1-based, 0 if the destination could not be resolved
+	Children []Bookmark
+}
+
+// readQPDFJSONKeys decodes the given top level keys of `qpdf --json=2`
+// (e.g. "pages", "outlines") into dst.
+func readQPDFJSONKeys(ctx context.Context, path string, dst any, keys ...string) error {
+	args := []string{"--json=2"}
+	for _, key := range keys {
+		args = append(args, "--json-key="+key)
+	}
+	args = append(args, path)
+
+	var stdout, stderr bytes.Buffer
+	cmd := commandContext(ctx, "qpdf", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := runCommand(ctx, cmd); err != nil {
+		// exit code 3 means warnings, the JSON is still complete
+		if IsAborted(err) || stdout.Len() == 0 {
+			return fmt.Errorf("qpdf json: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), dst); err != nil {
+		return fmt.Errorf("qpdf json: %w", err)
+	}
+	return nil
+}
+
+// qpdfOutline is one entry of the "outlines" key.
+type qpdfOutline struct {
+	Title string        `json:"title"`
+	Page  *int          `json:"destpageposfrom1"`
+	Kids  []qpdfOutline `json:"kids"`
+}
+
+// ReadOutline returns the bookmarks of path.
+func ReadOutline(ctx context.Context, path string) ([]Bookmark, error) {
+	var raw struct {
+		Outlines []qpdfOutline `json:"outlines"`
+	}
+	if err := readQPDFJSONKeys(ctx, path, &raw, "outlines"); err != nil {
+		return nil, err
+	}
+	return convertOutline(raw.Outlines), nil
+}
+
+func convertOutline(items []qpdfOutline) []Bookmark {
+	bookmarks := make([]Bookmark, 0, len(items))
+	for _, item := range items {
+		b := Bookmark{Title: item.Title, Children: convertOutline(item.Kids)}
+		if item.Page != nil {
+			b.Page = *item.Page
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks
+}
+
+// pageRefs returns the page object references ("3 0 R") in page order.
+func pageRefs(ctx context.Context, path string) ([]string, error) {
+	var raw struct {
+		Pages []struct {
+			Object string `json:"object"`
+		} `json:"pages"`
+	}
+	if err := readQPDFJSONKeys(ctx, path, &raw, "pages"); err != nil {
+		return nil, err
+	}
+
+	refs := make([]string, len(raw.Pages))
+	for i, p := range raw.Pages {
+		refs[i] = p.Object
+	}
+	return refs, nil
+}
+
+// writeOutline copies in to out replacing its outline with bookmarks.
+// Entries whose page is out of range are dropped together with their
+// children.
+func writeOutline(ctx context.Context, in string, out string, bookmarks []Bookmark) error {
+	doc, err := readQPDFJSON(ctx, in, "trailer")
+	if err != nil {
+		return err
+	}
+	rootKey := objectKey(doc.trailer()["/Root"])
+	root, err := readQPDFJSON(ctx, in, rootKey)
+	if err != nil {
+		return err
+	}
+	catalog := root.Objects[rootKey].dict()
+	if catalog == nil {
+		return fmt.Errorf("catalog %s not found", rootKey)
+	}
+
+	pages, err := pageRefs(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	b := outlineBuilder{pages: pages, nextID: doc.maxObjectID() + 1, objects: map[string]qpdfObject{}}
+	outlinesRef := b.newRef()
+	first, last, count := b.build(outlinesRef, bookmarks)
+
+	outlines := map[string]any{"/Type": "/Outlines"}
+	if first != "" {
+		outlines["/First"], outlines["/Last"], outlines["/Count"] = first, last, count
+		catalog["/PageMode"] = "/UseOutlines"
+	}
+	b.objects["obj:"+outlinesRef] = qpdfObject{Value: outlines}
+	catalog["/Outlines"] = outlinesRef
+	b.objects[rootKey] = qpdfObject{Value: catalog}
+
+	return updateWithJSON(ctx, in, out, doc.Header, b.objects)
+}
+
+// outlineBuilder allocates the outline item objects for writeOutline.
+type outlineBuilder struct {
+	pages   []string
+	nextID  int
+	objects map[string]qpdfObject
+}
+
+func (b *outlineBuilder) newRef() string {
+	ref := fmt.Sprintf("%d 0 R", b.nextID)
+	b.nextID++
+	return ref
+}
+
+// build links items under parent and returns the first and last item
+// references and the number of visible entries. Items with children are
+// left closed.
+func (b *outlineBuilder) build(parent string, items []Bookmark) (first string, last string, count int) {
+	var refs []string
+	var dicts []map[string]any
+
+	for _, item := range items {
+		if item.Page < 1 || item.Page > len(b.pages) {
+			continue
+		}
+		ref := b.newRef()
+		dict := map[string]any{
+			"/Title":  "u:" + item.Title,
+			"/Parent": parent,
+			"/Dest":   []any{b.pages[item.Page-1], "/Fit"},
+		}
+		if kidFirst, kidLast, kidCount := b.build(ref, item.Children); kidFirst != "" {
+			dict["/First"], dict["/Last"], dict["/Count"] = kidFirst, kidLast, -kidCount
+		}
+		refs = append(refs, ref)
+		dicts = append(dicts, dict)
+	}
+
+	for i, dict := range dicts {
+		if i > 0 {
+			dict["/Prev"] = refs[i-1]
+		}
+		if i < len(dicts)-1 {
+			dict["/Next"] = refs[i+1]
+		}
+		b.objects["obj:"+refs[i]] = qpdfObject{Value: dict}
+	}
+
+	if len(refs) == 0 {
+		return "", "", 0
+	}
+	return refs[0], refs[len(refs)-1], len(refs)
+}
@@ -0,0 +1,111 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Position is where a watermark or stamp sits on the page.
+type Position string
+
+const (
+	PositionCenter      Position = "center"
+	PositionTop         Position = "top"
+	PositionBottom      Position = "bottom"
+	PositionLeft        Position = "left"
+	PositionRight       Position = "right"
+	PositionTopLeft     Position = "top-left"
+	PositionTopRight    Position = "top-right"
+	PositionBottomLeft  Position = "bottom-left"
+	PositionBottomRight Position = "bottom-right"
+)
+
+// positionAnchors place a mark inside the page minus the margins: 0 is the
+// left or bottom edge, 1 the right or top edge.
+var positionAnchors = map[Position][2]float64{
+	PositionCenter:      {0.5, 0.5},
+	PositionTop:         {0.5, 1},
+	PositionBottom:      {0.5, 0},
+	PositionLeft:        {0, 0.5},
+	PositionRight:       {1, 0.5},
+	PositionTopLeft:     {0, 1},
+	PositionTopRight:    {1, 1},
+	PositionBottomLeft:  {0, 0},
+	PositionBottomRight: {1, 0},
+}
+
+// ParsePosition accepts center, top, bottom, left, right and the corners
+// (top-left, ...); empty means def.
+func ParsePosition(s string, def Position) (Position, error) {
+	p := Position(strings.ToLower(strings.TrimSpace(s)))
+	if p == "" {
+		return def, nil
+	}
+	if _, ok := positionAnchors[p]; !ok {
+		return "", fmt.Errorf("unknown position %q (use center, top, bottom, left, right, top-left, top-right, bottom-left or bottom-right)", s)
+	}
+	return p, nil
+}
+
+// capHeight is roughly the height of capital letters in the standard
+// fonts; text is centred on it.
+const capHeight = 0.7
+
+// markCenter returns where the centre of a width x height mark, rotated by
+// the angle with cos and sin, goes on a page of size so that its bounding
+// box sits at pos, marginX and marginY points from the edges.
+func markCenter(size pageSize, width, height, cos, sin float64, pos Position, marginX, marginY float64) (cx, cy float64) {
+	boxW := math.Abs(width*cos) + math.Abs(height*sin)
+	boxH := math.Abs(width*sin) + math.Abs(height*cos)
+
+	anchor := positionAnchors[pos]
+	cx = marginX + boxW/2 + anchor[0]*(size.Width-2*marginX-boxW)
+	cy = marginY + boxH/2 + anchor[1]*(size.Height-2*marginY-boxH)
+	return cx, cy
+}
+
+// selectPages returns the sorted pages of spec ("1-3,5", see
+// ParsePageRanges); empty means all.
+func selectPages(spec string, total int) ([]int, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = "1-"
+	}
+	pages, err := pagePositions(spec, total)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(pages)
+	return slices.Compact(pages), nil
+}
+
+// applyOverlay lays the pages of the PDF overlay over (or under) pages of
+// inputPath with qpdf and writes outputPath. from lists the overlay page
+// for each of pages.
+func applyOverlay(ctx context.Context, inputPath, outputPath string, overlay []byte, pages []int, from string, underlay bool) error {
+	workDir, err := os.MkdirTemp(filepath.Dir(outputPath), "overlay_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	overlayPath := filepath.Join(workDir, "overlay.pdf")
+	if err := os.WriteFile(overlayPath, overlay, 0644); err != nil {
+		return err
+	}
+
+	layer := "--overlay"
+	if underlay {
+		layer = "--underlay"
+	}
+	args := []string{inputPath, layer, overlayPath, "--to=" + pageRanges(pages), "--from=" + from, "--", outputPath}
+	if err := runQPDF(ctx, args...); err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PageOp is one page edit. Pages refers to the page numbers as they are
+// after the previous operations, in the syntax of ParsePageRanges.
+//
+// As JSON: {"op": "rotate", "pages": "2,4", "angle": 90},
+// {"op": "delete", "pages": "7-9"}, {"op": "move", "pages": "10", "to": 1},
+// {"op": "extract", "pages": "3-5"}.
+type PageOp struct {
+	Op    string `json:"op"`
+	Pages string `json:"pages"`
+	Angle int    `json:"angle,omitempty"` // rotate: clockwise, a multiple of 90
+	To    int    `json:"to,omitempty"`    // move: the position the first moved page gets
+}
+
+// ParsePageOps parses operations written as text, separated by ';' or new
+// lines: "rotate 2,4 by 90; delete 7-9; move 10 to 1; extract 3-5".
+func ParsePageOps(s string) ([]PageOp, error) {
+	var ops []PageOp
+	for _, line := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '\n' }) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		op := PageOp{Op: strings.ToLower(fields[0])}
+		var err error
+		switch {
+		case len(fields) == 2 && (op.Op == "delete" || op.Op == "extract"):
+			op.Pages = fields[1]
+		case len(fields) == 4 && op.Op == "rotate" && fields[2] == "by":
+			op.Pages = fields[1]
+			op.Angle, err = strconv.Atoi(fields[3])
+		case len(fields) == 4 && op.Op == "move" && fields[2] == "to":
+			op.Pages = fields[1]
+			op.To, err = strconv.Atoi(fields[3])
+		default:
+			err = fmt.Errorf("unknown")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid page operation %q", strings.TrimSpace(line))
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// PageEditResult describes an edited document.
+type PageEditResult struct {
+	// Pages lists, for every output page, the page of the input it came from.
+	Pages []int
+}
+
+// EditPages applies ops to inputPath and writes outputPath. The operations
+// only rearrange page objects and their /Rotate entries in a single qpdf
+// run, so nothing is re-rendered.
+func EditPages(ctx context.Context, inputPath string, outputPath string, ops []PageOp) (*PageEditResult, error) {
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("no page operations given")
+	}
+
+	total, err := PageCount(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, rotation, err := planPageEdits(total, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{inputPath, "--pages", ".", pageRanges(pages), "--"}
+	args = append(args, rotateArgs(rotation)...)
+	args = append(args, outputPath)
+
+	if err := runQPDF(ctx, args...); err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+	return &PageEditResult{Pages: pages}, nil
+}
+
+// planPageEdits applies ops to the pages 1..total and returns the resulting
+// order of input pages together with the extra rotation of each output
+// page. A page listed twice in one operation is an error.
+func planPageEdits(total int, ops []PageOp) ([]int, []int, error) {
+	pages := make([]int, total)
+	for i := range pages {
+		pages[i] = i + 1
+	}
+	rotation := make([]int, total)
+
+	for _, op := range ops {
+		positions, err := pagePositions(op.Pages, len(pages))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op.Op, err)
+		}
+		seen := map[int]bool{}
+		for _, pos := range positions {
+			if seen[pos] {
+				return nil, nil, fmt.Errorf("%s: page %d is listed twice in %q", op.Op, pos, op.Pages)
+			}
+			seen[pos] = true
+		}
+
+		switch strings.ToLower(op.Op) {
+		case "rotate":
+			if op.Angle%90 != 0 {
+				return nil, nil, fmt.Errorf("rotate: angle %d is not a multiple of 90", op.Angle)
+			}
+			for _, pos := range positions {
+				rotation[pos-1] = ((rotation[pos-1]+op.Angle)%360 + 360) % 360
+			}
+		case "delete":
+			pages = removePositions(pages, positions)
+			rotation = removePositions(rotation, positions)
+		case "extract":
+			pages = pickPositions(pages, positions)
+			rotation = pickPositions(rotation, positions)
+		case "move":
+			rest := removePositions(pages, positions)
+			if op.To < 1 || op.To > len(rest)+1 {
+				return nil, nil, fmt.Errorf("move: target %d is outside 1-%d", op.To, len(rest)+1)
+			}
+			moved := pickPositions(pages, positions)
+			pages = slices.Insert(rest, op.To-1, moved...)
+			rotation = slices.Insert(removePositions(rotation, positions), op.To-1, pickPositions(rotation, positions)...)
+		default:
+			return nil, nil, fmt.Errorf("unknown page operation %q (use rotate, delete, move or extract)", op.Op)
+		}
+
+		if len(pages) == 0 {
+			return nil, nil, fmt.Errorf("%s %s leaves no pages", op.Op, op.Pages)
+		}
+	}
+	return pages, rotation, nil
+}
+
+// pagePositions expands a page range spec into positions in 1..total.
+func pagePositions(spec string, total int) ([]int, error) {
+	ranges, err := ParsePageRanges(spec, total)
+	if err != nil {
+		return nil, err
+	}
+	var positions []int
+	for _, r := range ranges {
+		for pos := r.First; pos <= r.Last; pos++ {
+			positions = append(positions, pos)
+		}
+	}
+	return positions, nil
+}
+
+func removePositions[T any](items []T, positions []int) []T {
+	var rest []T
+	for i, item := range items {
+		if !slices.Contains(positions, i+1) {
+			rest = append(rest, item)
+		}
+	}
+	return rest
+}
+
+// pickPositions returns the items at positions, in that order.
+func pickPositions[T any](items []T, positions []int) []T {
+	picked := make([]T, len(positions))
+	for i, pos := range positions {
+		picked[i] = items[pos-1]
+	}
+	return picked
+}
+
+// rotateArgs returns qpdf's --rotate options for the output pages. qpdf
+// applies them after --pages, so they refer to output page numbers, and "+"
+// adds to a rotation the page already has.
+func rotateArgs(rotation []int) []string {
+	byAngle := map[int][]int{}
+	for i, angle := range rotation {
+		if angle != 0 {
+			byAngle[angle] = append(byAngle[angle], i+1)
+		}
+	}
+
+	var args []string
+	for _, angle := range []int{90, 180, 270} {
+		if positions := byAngle[angle]; len(positions) > 0 {
+			args = append(args, fmt.Sprintf("--rotate=+%d:%s", angle, pageRanges(positions)))
+		}
+	}
+	return args
+}
@@ -0,0 +1,108 @@
+package pdf
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsePageOps(t *testing.T) {
+	got, err := ParsePageOps("rotate 2,4 by 90; delete 7-9\nmove 10 to 1; extract 3-5")
+	want := []PageOp{
+		{Op: "rotate", Pages: "2,4", Angle: 90},
+		{Op: "delete", Pages: "7-9"},
+		{Op: "move", Pages: "10", To: 1},
+		{Op: "extract", Pages: "3-5"},
+	}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePageOps = %+v, %v; want %+v", got, err, want)
+	}
+
+	for _, bad := range []string{"rotate 2", "move 3 1", "flip 1", "delete"} {
+		if _, err := ParsePageOps(bad); err == nil {
+			t.Errorf("ParsePageOps(%q) returned no error", bad)
+		}
+	}
+}
+
+func TestPlanPageEdits(t *testing.T) {
+	pages, rotation, err := planPageEdits(10, []PageOp{
+		{Op: "rotate", Pages: "2,4", Angle: 90},
+		{Op: "delete", Pages: "7-9"},
+		{Op: "move", Pages: "7", To: 1}, // page 10 is 7th after the delete
+		{Op: "rotate", Pages: "3", Angle: -180},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{10, 1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+	if want := []int{0, 0, 270, 0, 90, 0, 0}; !reflect.DeepEqual(rotation, want) {
+		t.Errorf("rotation = %v, want %v", rotation, want)
+	}
+
+	wantArgs := []string{"--rotate=+90:5", "--rotate=+270:3"}
+	if args := rotateArgs(rotation); !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("rotateArgs = %q, want %q", args, wantArgs)
+	}
+}
+
+func TestPlanPageEdits_RotationFollowsPosition(t *testing.T) {
+	// The rotation travels with the output page it was given to
+	pages, rotation, err := planPageEdits(3, []PageOp{
+		{Op: "extract", Pages: "1-3"},
+		{Op: "rotate", Pages: "2", Angle: 90},
+		{Op: "move", Pages: "2", To: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 3, 2}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+	if want := []int{0, 0, 90}; !reflect.DeepEqual(rotation, want) {
+		t.Errorf("rotation = %v, want %v", rotation, want)
+	}
+}
+
+func TestPlanPageEdits_Errors(t *testing.T) {
+	cases := map[string][]PageOp{
+		"odd angle":    {{Op: "rotate", Pages: "1", Angle: 45}},
+		"out of range": {{Op: "delete", Pages: "11"}},
+		"no pages":     {{Op: "delete", Pages: "1-"}},
+		"bad target":   {{Op: "move", Pages: "1", To: 11}},
+		"unknown":      {{Op: "flip", Pages: "1"}},
+		"twice":        {{Op: "extract", Pages: "1,1"}},
+		"overlap":      {{Op: "move", Pages: "2-4,3", To: 1}},
+	}
+	for name, ops := range cases {
+		if _, _, err := planPageEdits(10, ops); err == nil {
+			t.Errorf("%s: planPageEdits returned no error", name)
+		}
+	}
+}
+
+func TestEditPages_Integration(t *testing.T) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping page edit test")
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	outputPath := filepath.Join(tempDir, "edited.pdf")
+
+	ops := []PageOp{{Op: "extract", Pages: "1"}, {Op: "rotate", Pages: "1", Angle: 90}}
+	t.Logf("🚀 Editing pages of: %s", inputPath)
+	res, err := EditPages(context.Background(), inputPath, outputPath, ops)
+	if err != nil {
+		t.Fatalf("EditPages returned error: %v", err)
+	}
+
+	if n, _ := PageCount(context.Background(), outputPath); n != 1 || len(res.Pages) != 1 {
+		t.Errorf("❌ output has %d pages, want 1", n)
+	}
+	t.Logf("✅ Edited pages: %v", res.Pages)
+}
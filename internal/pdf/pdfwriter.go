@@ -0,0 +1,68 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// pdfWriter builds a small PDF from scratch. Objects are numbered from 1 in
+// the order they are added; "N 0 R" references may point at objects added
+// later.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func newPDFWriter() *pdfWriter {
+	w := &pdfWriter{}
+	// The binary comment marks the file as binary for transfer programs
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	return w
+}
+
+// nextID returns the number the next added object gets.
+func (w *pdfWriter) nextID() int {
+	return len(w.offsets) + 1
+}
+
+// add writes a plain object and returns its number.
+func (w *pdfWriter) add(obj string) int {
+	id := w.nextID()
+	w.offsets = append(w.offsets, w.buf.Len())
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, obj)
+	return id
+}
+
+// reserve allocates an object number to be written later with addAt, e.g.
+// for a page tree whose kids are not known yet.
+func (w *pdfWriter) reserve() int {
+	w.offsets = append(w.offsets, -1)
+	return len(w.offsets)
+}
+
+// addAt writes the object reserved as id.
+func (w *pdfWriter) addAt(id int, obj string) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, obj)
+}
+
+// addStream writes a stream object; dict holds the entries besides /Length.
+func (w *pdfWriter) addStream(dict string, data []byte) int {
+	id := w.nextID()
+	w.offsets = append(w.offsets, w.buf.Len())
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return id
+}
+
+// finish writes the cross-reference table and trailer and returns the file.
+func (w *pdfWriter) finish(root int) []byte {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, off := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, root, xref)
+	return w.buf.Bytes()
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package pdf
+
+import "os/exec"
+
+// setProcessGroup is a no-op on platforms without process groups; the
+// default exec.Cmd cancellation kills only the direct child.
+func setProcessGroup(cmd *exec.Cmd) {}
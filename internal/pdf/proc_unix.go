@@ -0,0 +1,16 @@
+//go:build unix
+
+package pdf
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// A negative pid signals every process in the group.
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,78 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// qpdfWarningExit is qpdf's exit status when the output was written but
+// qpdf had to work around problems in the input.
+const qpdfWarningExit = 3
+
+// runQPDF runs qpdf with args. Warnings (exit status 3) are not errors.
+func runQPDF(ctx context.Context, args ...string) error {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		return fmt.Errorf("qpdf not found")
+	}
+
+	var stderr bytes.Buffer
+	cmd := commandContext(ctx, "qpdf", args...)
+	cmd.Stderr = &stderr
+	err := runCommand(ctx, cmd)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == qpdfWarningExit {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("qpdf: %w: %s", err, lastLine(stderr.String()))
+	}
+	return nil
+}
+
+// PageCount returns the number of pages of path.
+func PageCount(ctx context.Context, path string) (int, error) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		return 0, fmt.Errorf("qpdf not found")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := commandContext(ctx, "qpdf", "--show-npages", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := runCommand(ctx, cmd)
+
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == qpdfWarningExit) {
+		return 0, fmt.Errorf("qpdf --show-npages: %w: %s", err, lastLine(stderr.String()))
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
+	if err != nil {
+		return 0, fmt.Errorf("qpdf --show-npages: unexpected output %q", stdout.String())
+	}
+	return n, nil
+}
+
+// pageRanges formats 1-based page numbers for qpdf, e.g. "1-3,7".
+func pageRanges(pages []int) string {
+	var parts []string
+	for i := 0; i < len(pages); {
+		j := i
+		for j+1 < len(pages) && pages[j+1] == pages[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(pages[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", pages[i], pages[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
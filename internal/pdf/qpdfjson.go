@@ -0,0 +1,177 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// objectRefPattern matches qpdf's JSON form of references, "N G R".
+var objectRefPattern = regexp.MustCompile(`^\d+ \d+ R$`)
+
+// qpdfDocument is the "qpdf" section of `qpdf --json=2`: a header followed
+// by the objects, keyed "obj:N G R" plus "trailer". Values keep qpdf's JSON
+// encoding ("/Name", "u:text", "N G R"), so they can be written back with
+// --update-from-json unchanged.
+type qpdfDocument struct {
+	Header  map[string]any
+	Objects map[string]qpdfObject
+}
+
+type qpdfObject struct {
+	Value  any         `json:"value,omitempty"`
+	Stream *qpdfStream `json:"stream,omitempty"`
+}
+
+type qpdfStream struct {
+	Dict map[string]any `json:"dict"`
+	Data string         `json:"data,omitempty"` // base64, only with --json-stream-data=inline
+}
+
+// readQPDFJSON returns the given objects ("trailer", "obj:1 0 R" or
+// "1 0 R") of path. Without objects, every object is returned.
+func readQPDFJSON(ctx context.Context, path string, objects ...string) (*qpdfDocument, error) {
+	args := []string{"--json=2", "--json-key=qpdf"}
+	for _, obj := range objects {
+		args = append(args, "--json-object="+strings.TrimPrefix(obj, "obj:"))
+	}
+	args = append(args, path)
+
+	var stdout, stderr bytes.Buffer
+	cmd := commandContext(ctx, "qpdf", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := runCommand(ctx, cmd); err != nil {
+		// exit code 3 means warnings, the JSON is still complete
+		if IsAborted(err) || stdout.Len() == 0 {
+			return nil, fmt.Errorf("qpdf json: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	var raw struct {
+		QPDF []json.RawMessage `json:"qpdf"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &raw); err != nil {
+		return nil, fmt.Errorf("qpdf json: %w", err)
+	}
+	if len(raw.QPDF) != 2 {
+		return nil, fmt.Errorf("qpdf json: unexpected layout, is qpdf older than 11.0?")
+	}
+
+	doc := &qpdfDocument{}
+	if err := json.Unmarshal(raw.QPDF[0], &doc.Header); err != nil {
+		return nil, fmt.Errorf("qpdf json header: %w", err)
+	}
+	if err := json.Unmarshal(raw.QPDF[1], &doc.Objects); err != nil {
+		return nil, fmt.Errorf("qpdf json objects: %w", err)
+	}
+	return doc, nil
+}
+
+// dict returns the dictionary of a plain object or of a stream.
+func (o qpdfObject) dict() map[string]any {
+	if o.Stream != nil {
+		return o.Stream.Dict
+	}
+	d, _ := o.Value.(map[string]any)
+	return d
+}
+
+// trailer returns the trailer dictionary.
+func (d *qpdfDocument) trailer() map[string]any {
+	return d.Objects["trailer"].dict()
+}
+
+// maxObjectID returns the highest object number in use, new objects must be
+// numbered above it.
+func (d *qpdfDocument) maxObjectID() int {
+	n, _ := d.Header["maxobjectid"].(float64)
+	return int(n)
+}
+
+// inherited returns the page attribute key of dict, walking up /Parent
+// until it is found.
+func (d *qpdfDocument) inherited(dict map[string]any, key string) any {
+	for range 64 {
+		if dict == nil {
+			return nil
+		}
+		if v, ok := dict[key]; ok {
+			return v
+		}
+		dict = d.Objects[objectKey(dict["/Parent"])].dict()
+	}
+	return nil
+}
+
+// resolve returns the value of the object v refers to, or v itself when it
+// is not a reference.
+func (d *qpdfDocument) resolve(v any) any {
+	if ref, ok := v.(string); ok && objectRefPattern.MatchString(ref) {
+		if obj, ok := d.Objects[objectKey(ref)]; ok {
+			if obj.Stream != nil {
+				return obj.Stream.Dict
+			}
+			return obj.Value
+		}
+		return nil
+	}
+	return v
+}
+
+// readStreamData returns the decoded data of the stream ref ("N G R").
+func readStreamData(ctx context.Context, path string, ref string) ([]byte, error) {
+	var id, gen int
+	if _, err := fmt.Sscanf(ref, "%d %d R", &id, &gen); err != nil {
+		return nil, fmt.Errorf("invalid object reference %q", ref)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := commandContext(ctx, "qpdf", fmt.Sprintf("--show-object=%d,%d", id, gen), "--filtered-stream-data", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := runCommand(ctx, cmd); err != nil {
+		// exit code 3 means warnings, the data is still complete
+		if IsAborted(err) || stdout.Len() == 0 {
+			return nil, fmt.Errorf("qpdf: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return stdout.Bytes(), nil
+}
+
+// objectKey turns a reference value such as "12 0 R" into the key used in
+// qpdfDocument.Objects.
+func objectKey(ref any) string {
+	s, _ := ref.(string)
+	return "obj:" + s
+}
+
+// updateWithJSON copies in to out with qpdf, replacing or adding objects.
+// Objects missing from the patch are left untouched. extraArgs are passed to
+// qpdf before the file names.
+func updateWithJSON(ctx context.Context, in string, out string, header map[string]any, objects map[string]qpdfObject, extraArgs ...string) error {
+	patch, err := json.Marshal(map[string]any{
+		"qpdf": []any{header, objects},
+	})
+	if err != nil {
+		return err
+	}
+
+	patchFile := filepath.Join(filepath.Dir(out), "."+filepath.Base(out)+".patch.json")
+	if err := os.WriteFile(patchFile, patch, 0644); err != nil {
+		return err
+	}
+	defer os.Remove(patchFile)
+
+	args := append([]string{}, extraArgs...)
+	args = append(args, "--update-from-json="+patchFile, in, out)
+
+	cmd := commandContext(ctx, "qpdf", args...)
+	cmd.Stderr = os.Stderr
+	return runCommand(ctx, cmd)
+}
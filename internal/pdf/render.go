@@ -0,0 +1,328 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ImageFormat is an output format of Renderer.
+type ImageFormat string
+
+const (
+	FormatPNG  ImageFormat = "png"
+	FormatJPEG ImageFormat = "jpeg"
+	// FormatWebP is rendered as PNG and converted with ImageMagick, as
+	// Ghostscript has no WebP device.
+	FormatWebP ImageFormat = "webp"
+)
+
+// ParseImageFormat accepts png, jpeg (or jpg) and webp; empty means png.
+func ParseImageFormat(s string) (ImageFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "png":
+		return FormatPNG, nil
+	case "jpeg", "jpg":
+		return FormatJPEG, nil
+	case "webp":
+		return FormatWebP, nil
+	default:
+		return "", fmt.Errorf("unknown image format %q (use png, jpeg or webp)", s)
+	}
+}
+
+// Ext returns the file extension of the format, e.g. ".jpg".
+func (f ImageFormat) Ext() string {
+	if f == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + string(f)
+}
+
+const (
+	DefaultRenderDPI = 150
+	defaultImageQ    = 85
+)
+
+// RenderedPage is one written image.
+type RenderedPage struct {
+	Page int
+	Path string
+}
+
+// Renderer rasterizes pages with Ghostscript.
+type Renderer struct {
+	Format ImageFormat // "" means FormatPNG
+	DPI    int         // 0 means DefaultRenderDPI
+
+	// MaxWidth and MaxHeight cap the image size in pixels; the resolution of
+	// a page is lowered until it fits. 0 means no limit.
+	MaxWidth, MaxHeight int
+
+	// Quality of JPEG and WebP images, 1-100 (0 means 85).
+	Quality int
+
+	// Workers is the number of pages rendered at once (0 means one per CPU).
+	Workers int
+}
+
+func NewRenderer() *Renderer {
+	return &Renderer{Format: FormatPNG, DPI: DefaultRenderDPI}
+}
+
+// Render writes the pages selected by spec ("1-3,5", see
+// ParsePageRanges; empty means all) of inputPath to outputDir as
+// <name>_p<N><ext>. On error, images written so far are removed.
+func (r *Renderer) Render(ctx context.Context, inputPath string, outputDir string, spec string) ([]RenderedPage, error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+
+	sizes, err := pageSizes(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(spec) == "" {
+		spec = "1-"
+	}
+	pages, err := pagePositions(spec, len(sizes))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(pages)
+	pages = slices.Compact(pages)
+
+	fileName := filepath.Base(inputPath)
+	baseName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	width := len(fmt.Sprint(len(sizes)))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := r.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	rendered := make([]RenderedPage, len(pages))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	for i, page := range pages {
+		wg.Add(1)
+		go func(idx int, page int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			out := filepath.Join(outputDir, fmt.Sprintf("%s_p%0*d%s", baseName, width, page, r.format().Ext()))
+			err := r.renderPage(ctx, inputPath, page, sizes[page-1], out)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				cancel()
+				return
+			}
+			rendered[idx] = RenderedPage{Page: page, Path: out}
+		}(i, page)
+	}
+
+	wg.Wait()
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = contextError(ctx)
+	}
+	if firstErr != nil {
+		for _, img := range rendered {
+			if img.Path != "" {
+				os.Remove(img.Path)
+			}
+		}
+		return nil, firstErr
+	}
+	return rendered, nil
+}
+
+// RenderPage writes a single page to outputPath. Only that page and the
+// page tree above it are read, not the whole document.
+func (r *Renderer) RenderPage(ctx context.Context, inputPath string, page int, outputPath string) error {
+	if err := r.check(); err != nil {
+		return err
+	}
+	refs, err := pageRefs(ctx, inputPath)
+	if err != nil {
+		return err
+	}
+	if page < 1 || page > len(refs) {
+		return fmt.Errorf("page %d is outside 1-%d", page, len(refs))
+	}
+	size, err := readPageSize(ctx, inputPath, refs[page-1])
+	if err != nil {
+		return err
+	}
+	return r.renderPage(ctx, inputPath, page, size, outputPath)
+}
+
+func (r *Renderer) format() ImageFormat {
+	if r.Format == "" {
+		return FormatPNG
+	}
+	return r.Format
+}
+
+func (r *Renderer) check() error {
+	if r.Quality < 0 || r.Quality > 100 {
+		return fmt.Errorf("image quality must be between 1 and 100, got %d", r.Quality)
+	}
+	if r.DPI != 0 && (r.DPI < 10 || r.DPI > 2400) {
+		return fmt.Errorf("DPI must be between 10 and 2400, got %d", r.DPI)
+	}
+	if r.format() == FormatWebP && !ImageMagickAvailable() {
+		return fmt.Errorf("WebP output needs ImageMagick (magick or convert)")
+	}
+	return nil
+}
+
+// dpiFor returns the resolution that renders a page of size points within
+// MaxWidth x MaxHeight pixels, at most r.DPI.
+func (r *Renderer) dpiFor(size pageSize) int {
+	dpi := float64(r.DPI)
+	if dpi == 0 {
+		dpi = DefaultRenderDPI
+	}
+	if r.MaxWidth > 0 && size.Width > 0 {
+		dpi = min(dpi, float64(r.MaxWidth)*72/size.Width)
+	}
+	if r.MaxHeight > 0 && size.Height > 0 {
+		dpi = min(dpi, float64(r.MaxHeight)*72/size.Height)
+	}
+	return max(int(dpi), 1)
+}
+
+func (r *Renderer) renderPage(ctx context.Context, inputPath string, page int, size pageSize, out string) error {
+	dpi := r.dpiFor(size)
+	quality := r.Quality
+	if quality == 0 {
+		quality = defaultImageQ
+	}
+
+	// The size above is the CropBox, the part of the page readers show
+	switch r.format() {
+	case FormatJPEG:
+		return renderPage(ctx, inputPath, page, dpi, "jpeg", out, "-dUseCropBox", fmt.Sprintf("-dJPEGQ=%d", quality))
+	case FormatWebP:
+		png := out + ".png"
+		defer os.Remove(png)
+		if err := renderPage(ctx, inputPath, page, dpi, "png16m", png, "-dUseCropBox"); err != nil {
+			return err
+		}
+		if err := runMagick(ctx, png, "-quality", fmt.Sprint(quality), out); err != nil {
+			os.Remove(out)
+			return fmt.Errorf("page %d to webp: %w", page, err)
+		}
+		return nil
+	default:
+		return renderPage(ctx, inputPath, page, dpi, "png16m", out, "-dUseCropBox")
+	}
+}
+
+// pageSize is the visible size of a page in points, rotation applied.
+type pageSize struct {
+	Width, Height float64
+}
+
+// pageSizes returns the size of every page from its CropBox (or MediaBox),
+// looking up boxes and /Rotate inherited from the page tree.
+func pageSizes(ctx context.Context, path string) ([]pageSize, error) {
+	doc, err := readQPDFJSON(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := pageRefs(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make([]pageSize, len(refs))
+	for i, ref := range refs {
+		sizes[i] = doc.pageSize(doc.Objects[objectKey(ref)].dict())
+	}
+	return sizes, nil
+}
+
+// readPageSize returns the size of the page ref, reading only the page, the
+// page tree nodes above it and an indirect box.
+func readPageSize(ctx context.Context, path string, ref string) (pageSize, error) {
+	doc, err := readQPDFJSON(ctx, path, ref)
+	if err != nil {
+		return pageSize{}, err
+	}
+
+	load := func(ref string) error {
+		if _, ok := doc.Objects[objectKey(ref)]; ok {
+			return nil
+		}
+		more, err := readQPDFJSON(ctx, path, ref)
+		if err != nil {
+			return err
+		}
+		maps.Copy(doc.Objects, more.Objects)
+		return nil
+	}
+
+	page := doc.Objects[objectKey(ref)].dict()
+	for node, depth := page, 0; node != nil && depth < 64; depth++ {
+		parent, ok := node["/Parent"].(string)
+		if !ok || !objectRefPattern.MatchString(parent) {
+			break
+		}
+		if err := load(parent); err != nil {
+			return pageSize{}, err
+		}
+		node = doc.Objects[objectKey(parent)].dict()
+	}
+	for _, key := range []string{"/CropBox", "/MediaBox"} {
+		if box, ok := doc.inherited(page, key).(string); ok && objectRefPattern.MatchString(box) {
+			if err := load(box); err != nil {
+				return pageSize{}, err
+			}
+		}
+	}
+	return doc.pageSize(page), nil
+}
+
+// pageSize returns the size of page from its CropBox (or MediaBox), looking
+// up boxes and /Rotate inherited from the page tree.
+func (d *qpdfDocument) pageSize(page map[string]any) pageSize {
+	box := d.inherited(page, "/CropBox")
+	if box == nil {
+		box = d.inherited(page, "/MediaBox")
+	}
+
+	if ref, ok := box.(string); ok {
+		box = d.Objects[objectKey(ref)].Value
+	}
+
+	size := pageSize{Width: 612, Height: 792} // Letter when the box is missing
+	if nums, ok := box.([]any); ok && len(nums) == 4 {
+		x0, _ := nums[0].(float64)
+		y0, _ := nums[1].(float64)
+		x1, _ := nums[2].(float64)
+		y1, _ := nums[3].(float64)
+		size = pageSize{Width: max(x1-x0, x0-x1), Height: max(y1-y0, y0-y1)}
+	}
+	if rotate, _ := d.inherited(page, "/Rotate").(float64); int(rotate)%180 != 0 {
+		size.Width, size.Height = size.Height, size.Width
+	}
+	return size
+}
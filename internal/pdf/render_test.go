@@ -0,0 +1,92 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestParseImageFormat(t *testing.T) {
+	cases := map[string]ImageFormat{"": FormatPNG, "JPG": FormatJPEG, "jpeg": FormatJPEG, "webp": FormatWebP}
+	for in, want := range cases {
+		if got, err := ParseImageFormat(in); err != nil || got != want {
+			t.Errorf("ParseImageFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseImageFormat("gif"); err == nil {
+		t.Error("ParseImageFormat(gif) returned no error")
+	}
+	if FormatJPEG.Ext() != ".jpg" || FormatWebP.Ext() != ".webp" {
+		t.Errorf("unexpected extensions %q, %q", FormatJPEG.Ext(), FormatWebP.Ext())
+	}
+}
+
+func TestRenderer_DPIFor(t *testing.T) {
+	a4 := pageSize{Width: 595, Height: 842}
+	cases := []struct {
+		r    Renderer
+		want int
+	}{
+		{Renderer{}, DefaultRenderDPI},
+		{Renderer{DPI: 300}, 300},
+		{Renderer{DPI: 300, MaxWidth: 200}, 24},  // 200 px over 595 pt
+		{Renderer{DPI: 300, MaxHeight: 842}, 72}, // height binds
+		{Renderer{DPI: 72, MaxWidth: 10000}, 72}, // never upscaled past DPI
+		{Renderer{MaxWidth: 1, MaxHeight: 1}, 1}, // at least 1 dpi
+	}
+	for _, c := range cases {
+		if got := c.r.dpiFor(a4); got != c.want {
+			t.Errorf("dpiFor(%+v) = %d, want %d", c.r, got, c.want)
+		}
+	}
+}
+
+func TestQPDFDocument_PageSize(t *testing.T) {
+	doc := &qpdfDocument{Objects: map[string]qpdfObject{
+		"obj:1 0 R": {Value: map[string]any{"/Type": "/Pages", "/MediaBox": "2 0 R", "/Rotate": 90.0}},
+		"obj:2 0 R": {Value: []any{0.0, 0.0, 595.0, 842.0}},
+	}}
+	cases := []struct {
+		page map[string]any
+		want pageSize
+	}{
+		{map[string]any{"/Parent": "1 0 R"}, pageSize{842, 595}}, // inherited indirect box and rotation
+		{map[string]any{"/Parent": "1 0 R", "/CropBox": []any{50.0, 50.0, 350.0, 250.0}}, pageSize{200, 300}},
+		{map[string]any{"/MediaBox": []any{0.0, 0.0, 100.0, 200.0}}, pageSize{100, 200}},
+		{map[string]any{}, pageSize{612, 792}},
+	}
+	for _, c := range cases {
+		if got := doc.pageSize(c.page); got != c.want {
+			t.Errorf("pageSize(%v) = %+v, want %+v", c.page, got, c.want)
+		}
+	}
+}
+
+func TestRenderer_Render_Integration(t *testing.T) {
+	for _, bin := range []string{"gs", "qpdf"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not found, skipping render test", bin)
+		}
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	r := NewRenderer()
+	r.Format = FormatJPEG
+	r.MaxWidth = 300
+
+	t.Logf("🚀 Rendering page 1 of: %s", inputPath)
+	images, err := r.Render(context.Background(), inputPath, tempDir, "1")
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+
+	if len(images) != 1 {
+		t.Fatalf("❌ got %d images, want 1", len(images))
+	}
+	info, err := os.Stat(images[0].Path)
+	if err != nil || info.Size() == 0 {
+		t.Fatalf("❌ image was not written: %v", err)
+	}
+	t.Logf("✅ Rendered %s (%d bytes)", images[0].Path, info.Size())
+}
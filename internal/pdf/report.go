@@ -0,0 +1,82 @@
+package pdf
+
+import (
+	"os"
+	"time"
+)
+
+// StageStatus tells how a pipeline stage ended.
+type StageStatus string
+
+const (
+	StageRan      StageStatus = "ran"      // the stage produced the output
+	StageFallback StageStatus = "fallback" // the stage failed and the previous output was passed on
+	StageSkipped  StageStatus = "skipped"  // the stage was not run
+)
+
+// SignatureStatus tells what compression did to the digital signatures of
+// its input.
+type SignatureStatus string
+
+const (
+	SignaturePreserved   SignatureStatus = "signature preserved"   // the signed input was copied unchanged
+	SignatureInvalidated SignatureStatus = "signature invalidated" // the file was rewritten (CompressOptions.Force)
+)
+
+// StageReport describes one step of the compression pipeline.
+type StageReport struct {
+	Name     string
+	Status   StageStatus
+	Size     int64 // size of the file the stage handed on, in bytes
+	Duration time.Duration
+	Err      error // why the stage fell back, nil otherwise
+}
+
+// CompressionReport is returned by the Compress methods instead of logging
+// sizes as a side effect.
+type CompressionReport struct {
+	InputSize    int64
+	OutputSize   int64
+	Stages       []StageReport
+	KeptOriginal bool // the pipeline result was not smaller, the input was copied instead
+	Warnings     []string
+	Duration     time.Duration
+
+	// Signatures is the number of digital signatures in the input;
+	// SignatureStatus is empty when there are none.
+	Signatures      int
+	SignatureStatus SignatureStatus
+}
+
+// Saved returns the number of bytes saved. A larger result is replaced by
+// the original, so it is only negative when the metadata options made the
+// original grow.
+func (r *CompressionReport) Saved() int64 {
+	return r.InputSize - r.OutputSize
+}
+
+// SavedPercent returns Saved as a percentage of InputSize.
+func (r *CompressionReport) SavedPercent() float64 {
+	if r.InputSize == 0 {
+		return 0
+	}
+	return float64(r.Saved()) / float64(r.InputSize) * 100
+}
+
+func (r *CompressionReport) addStage(name string, status StageStatus, path string, started time.Time, err error) {
+	r.Stages = append(r.Stages, StageReport{
+		Name:     name,
+		Status:   status,
+		Size:     fileSize(path),
+		Duration: time.Since(started),
+		Err:      err,
+	})
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -1,108 +1,76 @@
+import os
 import sys
+import json
 import fitz  # PyMuPDF
-from docx import Document
-from docx.shared import Pt
-import re
 
-def remove_control_characters(text):
-    return re.sub(r'[\x00-\x08\x0B\x0C\x0E-\x1F\x7F]', '', text)
+# Text extractor backend for the Go converter (pdf.PyMuPDFExtractor).
+# Prints every page's text blocks as JSON; linearization and DOCX writing
+# happen in Go. With an image directory as second argument, image blocks
+# are saved there as PNG/JPEG and listed too.
 
+# Formats Word can embed as they are; everything else is converted to PNG
+WORD_IMAGE_EXTS = {"png", "jpeg", "jpg"}
 
-def pdf_to_linear_docx(pdf_path, docx_path, sort_mode):
-    doc_word = Document()
-    
+
+def save_image(block, image_dir, name):
+    ext = block.get("ext", "png").lower()
+    data = block["image"]
+    if ext not in WORD_IMAGE_EXTS:
+        pix = fitz.Pixmap(data)
+        if pix.n - pix.alpha >= 4:  # CMYK
+            pix = fitz.Pixmap(fitz.csRGB, pix)
+        data, ext = pix.tobytes("png"), "png"
+
+    path = os.path.join(image_dir, f"{name}.{ext}")
+    with open(path, "wb") as f:
+        f.write(data)
+    return path
+
+
+def extract_blocks(pdf_path, image_dir=None):
     try:
         pdf_document = fitz.open(pdf_path)
     except Exception as e:
         print(f"Error opening PDF: {e}", file=sys.stderr)
         sys.exit(1)
 
-    body_buffer = []
-
-    def flush_buffer():
-        if not body_buffer: return
-        raw_text = "\n".join(body_buffer)
-        hyphen_pattern = r'(?<=[а-яА-Яa-zA-Z])-\s*\n\s*(?=[а-яА-Яa-zA-Z])'
-        text_dehyphenated = re.sub(hyphen_pattern, '', raw_text)
-        linear_text = text_dehyphenated.replace('\n', ' ')
-        clean_text = re.sub(r'\s+', ' ', linear_text).strip()
-        if not clean_text:
-            body_buffer.clear()
-            return
-        split_pattern = r'(?<=[.!?])\s+(?=[А-ЯA-Z])'
-        paragraphs = re.split(split_pattern, clean_text)
-        for para_text in paragraphs:
-            if not para_text.strip(): continue
-            p = doc_word.add_paragraph()
-            runner = p.add_run(para_text.strip())
-            runner.font.size = Pt(11)
-            p.paragraph_format.space_after = Pt(6)
-        body_buffer.clear()
-
+    pages = []
     for page_num, page in enumerate(pdf_document):
-        blocks = page.get_text("blocks", sort=sort_mode)
-
-        for block in blocks:
-            text = block[4].strip()
-            text = remove_control_characters(text)
-            
-            if not text: continue
-            
-            text_lower = text.lower().strip()
-            if ("на стр." in text_lower) or ("от стр." in text_lower): 
-                continue
-
-            if (text_lower == "квантов" or text_lower == "преход") or ("квантов" in text_lower or "преход" in text_lower):
+        blocks = []
+        # Extraction order; the Go side sorts when asked to
+        for block in page.get_text("blocks", sort=False):
+            x0, y0, x1, y1, text, _block_no, block_type = block[:7]
+            if block_type != 0:  # 1 = image block
                 continue
+            blocks.append({"text": text, "bbox": [x0, y0, x1, y1]})
 
-            if text_lower.isdigit():
-                continue
-
-            is_all_caps = text.isupper() and len(text) > 2
-            ends_with_sentence_punct = text.endswith(('.', ',', ':', ';', '!', '?'))
-            ends_with_hyphen = text.endswith('-')
-            is_title_candidate = (len(text) < 150 and not ends_with_sentence_punct and not ends_with_hyphen)
-            
-            if is_all_caps or is_title_candidate:
-                flush_buffer()
-                clean_title = text.replace('\n', ' ').replace('- ', '')
-                clean_title = re.sub(r'\s+', ' ', clean_title).strip()
-                clean_title = remove_control_characters(clean_title)
-
-                if clean_title.lower() in ["квантов", "преход", "източник:"]: 
+        images = []
+        if image_dir:
+            for i, block in enumerate(page.get_text("dict")["blocks"]):
+                if block.get("type") != 1:
                     continue
-                
-                p = doc_word.add_paragraph()
-                runner = p.add_run(clean_title)
-                runner.bold = True
-                runner.font.size = Pt(12)
-                p.paragraph_format.space_after = Pt(12)
-            else:
-                body_buffer.append(text)
+                try:
+                    path = save_image(block, image_dir, f"page{page_num + 1}-{i}")
+                except Exception as e:
+                    print(f"Skipping image on page {page_num + 1}: {e}", file=sys.stderr)
+                    continue
+                images.append({"path": path, "bbox": list(block["bbox"])})
 
-    flush_buffer()
+        pages.append({
+            "number": page_num + 1,
+            "width": page.rect.width,
+            "height": page.rect.height,
+            "blocks": blocks,
+            "images": images,
+        })
+
+    return pages
 
-    try:
-        doc_word.save(docx_path)
-        print(f"Successfully saved to {docx_path}")
-    except Exception as e:
-        print(f"Error saving DOCX: {e}", file=sys.stderr)
-        sys.exit(1)
 
 if __name__ == "__main__":
-    # Expect 3 arguments (script + input + output + sort)
-    # If the 4th argument (index 3) is not provided, default to True
-    if len(sys.argv) < 3:
-        print("Usage: python convert_word.py <input_pdf> <output_docx> [sort_mode]")
+    if len(sys.argv) < 2:
+        print("Usage: python convert_word.py <input_pdf> [image_dir]", file=sys.stderr)
         sys.exit(1)
 
-    input_file = sys.argv[1]
-    output_file = sys.argv[2]
-    
-    # Read the sort argument
-    sort_arg = True
-    if len(sys.argv) >= 4:
-        # Accept 'false', '0', 'no' as False, everything else is True
-        sort_arg = sys.argv[3].lower() not in ('false', '0', 'no')
-
-    pdf_to_linear_docx(input_file, output_file, sort_arg)
\ No newline at end of file
+    image_dir = sys.argv[2] if len(sys.argv) > 2 else None
+    json.dump(extract_blocks(sys.argv[1], image_dir), sys.stdout, ensure_ascii=False)
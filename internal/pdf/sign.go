@@ -0,0 +1,457 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Signer applies PAdES baseline B signatures (ETSI EN 319 142-1) with a
+// local key. The signature is appended to the file as an incremental
+// update, so earlier signatures stay valid; compressing or otherwise
+// rewriting a signed file afterwards breaks it.
+type Signer struct {
+	Key crypto.Signer // RSA or ECDSA
+	// Certificates holds the signer's certificate first, then the chain
+	// that is embedded with it.
+	Certificates []*x509.Certificate
+
+	// Optional details shown by PDF readers.
+	Name, Reason, Location, ContactInfo string
+
+	// Visible draws a box with the signer's name and the date on Page (1
+	// is the first, 0 the last), Width x Height mm at Position, Margin mm
+	// from the edges. Invisible signatures go on the first page.
+	Visible       bool
+	Page          int
+	Position      Position
+	Width, Height float64
+	Margin        float64
+
+	Time time.Time // zero means now
+}
+
+// NewSigner returns a Signer for key with a visible box's defaults. certs
+// must hold key's certificate; the rest is the chain, in any order.
+func NewSigner(key crypto.Signer, certs []*x509.Certificate) (*Signer, error) {
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", key.Public())
+	}
+	i := slices.IndexFunc(certs, func(c *x509.Certificate) bool { return pub.Equal(c.PublicKey) })
+	if i < 0 {
+		return nil, fmt.Errorf("no certificate matches the private key")
+	}
+
+	// The signer's certificate goes first
+	ordered := append([]*x509.Certificate{certs[i]}, slices.Delete(slices.Clone(certs), i, i+1)...)
+	return &Signer{
+		Key:          key,
+		Certificates: ordered,
+		Position:     PositionBottomRight,
+		Width:        60,
+		Height:       20,
+		Margin:       15,
+	}, nil
+}
+
+// LoadSigner reads a signing identity: a PKCS#12 file (.p12, .pfx) with
+// its password, or PEM files with the certificate chain and the key.
+func LoadSigner(certPath string, keyPath string, password string) (*Signer, error) {
+	switch strings.ToLower(filepath.Ext(certPath)) {
+	case ".p12", ".pfx":
+		return NewSignerFromPKCS12(certPath, password)
+	}
+	if keyPath == "" {
+		keyPath = certPath
+	}
+	return NewSignerFromPEM(certPath, keyPath)
+}
+
+// NewSignerFromPKCS12 reads the key and certificates from a PKCS#12 file
+// (.p12, .pfx).
+func NewSignerFromPKCS12(path string, password string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, cert, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			return nil, fmt.Errorf("%s: wrong password", path)
+		}
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported key type %T", path, key)
+	}
+	return NewSigner(signer, append([]*x509.Certificate{cert}, chain...))
+}
+
+// NewSignerFromPEM reads the certificate chain from certPath and the
+// unencrypted private key (PKCS#8, PKCS#1 or SEC 1) from keyPath; both may
+// be the same file.
+func NewSignerFromPEM(certPath string, keyPath string) (*Signer, error) {
+	var certs []*x509.Certificate
+	var key crypto.Signer
+	for _, path := range slices.Compact([]string{certPath, keyPath}) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			switch {
+			case block.Type == "CERTIFICATE":
+				cert, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+				certs = append(certs, cert)
+			case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+				if key, err = parsePrivateKey(block); err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+			}
+		}
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s: no certificate found", certPath)
+	}
+	if key == nil {
+		return nil, fmt.Errorf("%s: no private key found", keyPath)
+	}
+	return NewSigner(key, certs)
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if block.Type == "ENCRYPTED PRIVATE KEY" || block.Headers["Proc-Type"] != "" {
+		return nil, fmt.Errorf("the private key is encrypted, use a PKCS#12 file or decrypt it with openssl")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return signer, nil
+}
+
+// SignResult describes the signature Sign added.
+type SignResult struct {
+	Field  string // name of the signature field
+	Page   int
+	Signer string // common name of the certificate
+}
+
+// Sign writes inputPath with a new signature to outputPath.
+func (s *Signer) Sign(ctx context.Context, inputPath string, outputPath string) (*SignResult, error) {
+	if s.Key == nil || len(s.Certificates) == 0 {
+		return nil, fmt.Errorf("no signing key")
+	}
+	if encrypted, err := IsEncrypted(ctx, inputPath); err != nil {
+		return nil, err
+	} else if encrypted {
+		return nil, fmt.Errorf("encrypted PDFs cannot be signed, decrypt the file first")
+	}
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := readQPDFJSON(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := pageRefs(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	signed, res, err := s.sign(data, doc, pages)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(outputPath, signed, 0644); err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+	return res, nil
+}
+
+// sign appends the signature field, its appearance and the signature to
+// data, whose objects doc holds.
+func (s *Signer) sign(data []byte, doc *qpdfDocument, pages []string) ([]byte, *SignResult, error) {
+	page := 1
+	if s.Visible {
+		page = s.Page
+		if page == 0 {
+			page = len(pages)
+		}
+	}
+	if page < 1 || page > len(pages) {
+		return nil, nil, fmt.Errorf("page %d out of range (document has %d pages)", page, len(pages))
+	}
+	signedAt := s.Time
+	if signedAt.IsZero() {
+		signedAt = time.Now()
+	}
+
+	trailer := doc.trailer()
+	rootRef, _ := trailer["/Root"].(string)
+	catalog := doc.Objects[objectKey(rootRef)].dict()
+	if catalog == nil {
+		return nil, nil, fmt.Errorf("catalog %s not found", rootRef)
+	}
+	pageRef := pages[page-1]
+	pageDict := doc.Objects[objectKey(pageRef)].dict()
+	if pageDict == nil {
+		return nil, nil, fmt.Errorf("page %s not found", pageRef)
+	}
+
+	u := newIncrementalUpdate(data, doc.maxObjectID())
+	sigRef, widgetRef, apRef := u.newRef(), u.newRef(), u.newRef()
+
+	// Form fields: the AcroForm and its /Fields may each be inline or a
+	// separate object; whichever changes is rewritten
+	acroRef, _ := catalog["/AcroForm"].(string)
+	acroForm, _ := doc.resolve(catalog["/AcroForm"]).(map[string]any)
+	if acroForm == nil {
+		acroForm = map[string]any{}
+	}
+	fieldsRef, _ := acroForm["/Fields"].(string)
+	fields, _ := doc.resolve(acroForm["/Fields"]).([]any)
+	name := s.fieldName(doc, fields)
+
+	rect := []any{0, 0, 0, 0}
+	var appearance []byte
+	var bbox string
+	if s.Visible {
+		var width, height float64
+		rect, width, height = s.rect(doc, pageDict)
+		bbox = fmt.Sprintf("[0 0 %.2f %.2f]", width, height)
+		appearance = s.appearance(width, height, signedAt)
+	} else {
+		bbox = "[0 0 0 0]"
+	}
+
+	fontRef := u.newRef()
+	helvetica, _ := lookupFont("")
+	u.write(fontRef, helvetica.fontObject())
+	u.writeStream(apRef, fmt.Sprintf("/Type /XObject /Subtype /Form /BBox %s /Resources << /Font << /F0 %s >> >>", bbox, fontRef), appearance)
+	u.write(widgetRef, pdfValue(map[string]any{
+		"/Type": "/Annot", "/Subtype": "/Widget", "/FT": "/Sig",
+		"/T": "u:" + name, "/V": sigRef, "/P": pageRef,
+		"/F":    132, // print, locked
+		"/Rect": rect,
+		"/AP":   map[string]any{"/N": apRef},
+	}))
+
+	annots, _ := doc.resolve(pageDict["/Annots"]).([]any)
+	annots = append(slices.Clone(annots), widgetRef)
+	if annotsRef, ok := pageDict["/Annots"].(string); ok {
+		u.write(annotsRef, pdfValue(annots))
+	} else {
+		pageDict["/Annots"] = annots
+		u.write(pageRef, pdfValue(pageDict))
+	}
+
+	fields = append(slices.Clone(fields), widgetRef)
+	if fieldsRef != "" {
+		u.write(fieldsRef, pdfValue(fields))
+	} else {
+		acroForm["/Fields"] = fields
+	}
+	acroForm["/SigFlags"] = 3 // signatures exist, append only
+	if acroRef != "" {
+		u.write(acroRef, pdfValue(acroForm))
+	} else {
+		catalog["/AcroForm"] = acroForm
+		u.write(rootRef, pdfValue(catalog))
+	}
+
+	// The signature goes last, with room for the CMS; /ByteRange and
+	// /Contents are filled in once the file is complete
+	reserve := 8192
+	for _, cert := range s.Certificates {
+		reserve += len(cert.Raw)
+	}
+	byteRangeHolder := "[0 0000000000 0000000000 0000000000]"
+	sigStart := u.offset()
+	u.write(sigRef, "<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /ETSI.CAdES.detached"+
+		" /ByteRange "+byteRangeHolder+
+		" /Contents <"+strings.Repeat("0", 2*reserve)+">"+
+		" /M "+pdfString([]byte(pdfDate(signedAt)))+
+		s.details()+" >>")
+
+	file, err := u.finish(trailer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	brStart := sigStart + bytes.Index(file[sigStart:], []byte(byteRangeHolder))
+	contentsStart := sigStart + bytes.Index(file[sigStart:], []byte("/Contents <")) + len("/Contents ")
+	contentsEnd := contentsStart + 2*reserve + 2
+	byteRange := fmt.Sprintf("[0 %d %d %d]", contentsStart, contentsEnd, len(file)-contentsEnd)
+	copy(file[brStart:], fmt.Sprintf("%-*s", len(byteRangeHolder), byteRange))
+
+	h := sha256.New()
+	h.Write(file[:contentsStart])
+	h.Write(file[contentsEnd:])
+	cms, err := signCMS(s.Key, s.Certificates, h.Sum(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(cms) > reserve {
+		return nil, nil, fmt.Errorf("signature is %d bytes, only %d reserved", len(cms), reserve)
+	}
+	hex.Encode(file[contentsStart+1:], cms)
+
+	return file, &SignResult{Field: name, Page: page, Signer: s.Certificates[0].Subject.CommonName}, nil
+}
+
+// fieldName returns the first free name "Signature1", "Signature2", ...
+func (s *Signer) fieldName(doc *qpdfDocument, fields []any) string {
+	taken := map[string]bool{}
+	for _, field := range fields {
+		if dict, ok := doc.resolve(field).(map[string]any); ok {
+			if t, ok := dict["/T"].(string); ok {
+				taken[strings.TrimPrefix(t, "u:")] = true
+			}
+		}
+	}
+	for i := 1; ; i++ {
+		if name := fmt.Sprintf("Signature%d", i); !taken[name] {
+			return name
+		}
+	}
+}
+
+// rect places the visible box on the page and returns its /Rect and size
+// in points. The box is laid out on the unrotated page.
+func (s *Signer) rect(doc *qpdfDocument, page map[string]any) ([]any, float64, float64) {
+	x0, y0, x1, y1 := 0.0, 0.0, 612.0, 792.0 // Letter when the box is missing
+	box := doc.resolve(doc.inherited(page, "/CropBox"))
+	if box == nil {
+		box = doc.resolve(doc.inherited(page, "/MediaBox"))
+	}
+	if nums, ok := box.([]any); ok && len(nums) == 4 {
+		x0, _ = nums[0].(float64)
+		y0, _ = nums[1].(float64)
+		x1, _ = nums[2].(float64)
+		y1, _ = nums[3].(float64)
+		x0, x1 = min(x0, x1), max(x0, x1)
+		y0, y1 = min(y0, y1), max(y0, y1)
+	}
+
+	width, height := s.Width*72/25.4, s.Height*72/25.4
+	margin := s.Margin * 72 / 25.4
+	pos := s.Position
+	if pos == "" {
+		pos = PositionBottomRight
+	}
+	cx, cy := markCenter(pageSize{Width: x1 - x0, Height: y1 - y0}, width, height, 1, 0, pos, margin, margin)
+	left, bottom := x0+cx-width/2, y0+cy-height/2
+	return []any{round2(left), round2(bottom), round2(left + width), round2(bottom + height)}, width, height
+}
+
+// appearance draws the visible box: a frame and the signer, date, reason
+// and location, sized to fit.
+func (s *Signer) appearance(width, height float64, signedAt time.Time) []byte {
+	font, _ := lookupFont("")
+	lines := []string{"Digitally signed by " + s.signerName(), "Date: " + signedAt.Format("2006-01-02 15:04:05 -07:00")}
+	if s.Reason != "" {
+		lines = append(lines, "Reason: "+s.Reason)
+	}
+	if s.Location != "" {
+		lines = append(lines, "Location: "+s.Location)
+	}
+
+	encoded := make([][]byte, len(lines))
+	size := min(10, (height-6)/(1.25*float64(len(lines))))
+	for i, line := range lines {
+		encoded[i], _ = winAnsi(latin1(line))
+		if w := font.textWidth(encoded[i], 1); w > 0 {
+			size = min(size, (width-8)/w)
+		}
+	}
+	size = max(size, 1)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "q 0.2 0.3 0.6 RG 1 w 0.5 0.5 %.2f %.2f re S Q\n", width-1, height-1)
+	b.WriteString("BT 0.1 0.1 0.1 rg\n")
+	lineHeight := 1.25 * size
+	top := height/2 + lineHeight*float64(len(lines))/2
+	for i, text := range encoded {
+		fmt.Fprintf(&b, "/F0 %.2f Tf 1 0 0 1 4 %.2f Tm %s Tj\n", size, top-lineHeight*float64(i+1)+0.25*size, pdfString(text))
+	}
+	b.WriteString("ET")
+	return []byte(b.String())
+}
+
+// details returns the optional entries of the signature dictionary.
+func (s *Signer) details() string {
+	var b strings.Builder
+	for _, e := range []struct{ key, value string }{
+		{"/Name", s.signerName()},
+		{"/Reason", s.Reason},
+		{"/Location", s.Location},
+		{"/ContactInfo", s.ContactInfo},
+	} {
+		if e.value != "" {
+			b.WriteString(" " + e.key + " " + pdfTextString(e.value))
+		}
+	}
+	return b.String()
+}
+
+func (s *Signer) signerName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Certificates[0].Subject.CommonName
+}
+
+// pdfDate formats t as a PDF date, D:YYYYMMDDHHmmSS+HH'mm'.
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+	sign := byte('+')
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("D:%s%c%02d'%02d'", t.Format("20060102150405"), sign, offset/3600, offset%3600/60)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
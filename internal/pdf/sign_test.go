@@ -0,0 +1,300 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// testIdentity returns a key and a self-signed certificate for it.
+func testIdentity(t *testing.T, key crypto.Signer, name string) *x509.Certificate {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// testPDF returns a one page PDF and the qpdf JSON view of its objects.
+func testPDF() ([]byte, *qpdfDocument, []string) {
+	w := newPDFWriter()
+	pagesID := w.reserve()
+	catalogID := w.add("<< /Type /Catalog /Pages 1 0 R >>")
+	w.add("<< /Type /Page /Parent 1 0 R /MediaBox [0 0 595 842] >>")
+	w.addAt(pagesID, "<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	data := w.finish(catalogID)
+
+	doc := &qpdfDocument{
+		Header: map[string]any{"maxobjectid": 3.0},
+		Objects: map[string]qpdfObject{
+			"trailer":   {Value: map[string]any{"/Root": "2 0 R", "/Size": 4.0}},
+			"obj:1 0 R": {Value: map[string]any{"/Type": "/Pages", "/Kids": []any{"3 0 R"}, "/Count": 1.0}},
+			"obj:2 0 R": {Value: map[string]any{"/Type": "/Catalog", "/Pages": "1 0 R"}},
+			"obj:3 0 R": {Value: map[string]any{"/Type": "/Page", "/Parent": "1 0 R", "/MediaBox": []any{0.0, 0.0, 595.0, 842.0}}},
+		},
+	}
+	return data, doc, []string{"3 0 R"}
+}
+
+// xrefStreamPDF returns testPDF with a cross-reference stream, object 4,
+// instead of a table.
+func xrefStreamPDF() []byte {
+	w := newPDFWriter()
+	pagesID := w.reserve()
+	catalogID := w.add("<< /Type /Catalog /Pages 1 0 R >>")
+	w.add("<< /Type /Page /Parent 1 0 R /MediaBox [0 0 595 842] >>")
+	w.addAt(pagesID, "<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+
+	// W [1 4 2]: type, offset, generation
+	xref := w.buf.Len()
+	rows := []byte{0, 0, 0, 0, 0, 0xff, 0xff}
+	for _, off := range append(w.offsets, xref) {
+		rows = append(rows, 1, byte(off>>24), byte(off>>16), byte(off>>8), byte(off), 0, 0)
+	}
+	w.addStream(fmt.Sprintf("/Type /XRef /Size 5 /Root %d 0 R /W [1 4 2]", catalogID), rows)
+	fmt.Fprintf(&w.buf, "startxref\n%d\n%%%%EOF\n", xref)
+	return w.buf.Bytes()
+}
+
+func TestSigner_XRefStreamBase(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, err := NewSigner(key, []*x509.Certificate{testIdentity(t, key, "Signer")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := xrefStreamPDF()
+	_, doc, pages := testPDF()
+	doc.Header["maxobjectid"] = 4.0
+	doc.Objects["trailer"] = qpdfObject{Value: map[string]any{"/Root": "2 0 R", "/Size": 5.0}}
+
+	signed, _, err := signer.sign(data, doc, pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := signed[len(data):]
+	if bytes.Contains(update, []byte("\nxref\n")) || !bytes.Contains(update, []byte("/Type /XRef")) {
+		t.Errorf("the update does not end in a cross-reference stream:\n%s", update[bytes.LastIndex(update, []byte("endobj")):])
+	}
+	if sigs := verifySignatures(signed); len(sigs) != 1 || !sigs[0].Valid || !sigs[0].WholeDocument {
+		t.Errorf("signatures = %+v, want one valid for the whole file", sigs)
+	}
+
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		return
+	}
+	path := filepath.Join(t.TempDir(), "signed.pdf")
+	os.WriteFile(path, signed, 0644)
+	if out, err := exec.Command("qpdf", "--check", path).CombinedOutput(); err != nil {
+		t.Errorf("qpdf --check: %v\n%s", err, out)
+	}
+}
+
+func TestSigner_SignAndVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	for name, key := range map[string]crypto.Signer{"rsa": rsaKey, "ecdsa": ecKey} {
+		t.Run(name, func(t *testing.T) {
+			signer, err := NewSigner(key, []*x509.Certificate{testIdentity(t, key, "Jane Doe")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			signer.Reason = "Approved"
+			signer.Location = "София"
+			signer.Visible = true
+
+			data, doc, pages := testPDF()
+			signed, res, err := signer.sign(data, doc, pages)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+			if res.Field != "Signature1" || res.Page != 1 || res.Signer != "Jane Doe" {
+				t.Errorf("result = %+v", res)
+			}
+			if !bytes.HasPrefix(signed, data) {
+				t.Fatal("the original bytes were changed, want an incremental update")
+			}
+
+			sigs := verifySignatures(signed)
+			if len(sigs) != 1 {
+				t.Fatalf("found %d signatures, want 1", len(sigs))
+			}
+			sig := sigs[0]
+			if !sig.Valid || !sig.WholeDocument || sig.Trusted {
+				t.Errorf("signature = %+v, want valid, whole document, untrusted (self-signed)", sig)
+			}
+			if sig.Signer != "Jane Doe" || sig.Reason != "Approved" || sig.Location != "София" || sig.SubFilter != "ETSI.CAdES.detached" {
+				t.Errorf("signature details = %+v", sig)
+			}
+			if sig.SignedAt.IsZero() {
+				t.Error("signing time missing")
+			}
+
+			// Any change to the signed bytes breaks the signature
+			tampered := bytes.Clone(signed)
+			i := bytes.Index(tampered, []byte("/MediaBox [0 0 595"))
+			tampered[i+len("/MediaBox [0 0 ")] = '4'
+			if sigs := verifySignatures(tampered); len(sigs) != 1 || sigs[0].Valid {
+				t.Errorf("tampered file verified: %+v", sigs)
+			}
+		})
+	}
+}
+
+func TestSigner_SecondSignatureKeepsFirst(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, err := NewSigner(key, []*x509.Certificate{testIdentity(t, key, "Signer")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, doc, pages := testPDF()
+	first, _, err := signer.sign(data, doc, pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// What qpdf reports for the first revision's new objects: signature 4,
+	// widget 5, appearance 6, font 7
+	doc.Header["maxobjectid"] = 7.0
+	doc.Objects["obj:2 0 R"] = qpdfObject{Value: map[string]any{
+		"/Type": "/Catalog", "/Pages": "1 0 R",
+		"/AcroForm": map[string]any{"/Fields": []any{"5 0 R"}, "/SigFlags": 3.0},
+	}}
+	doc.Objects["obj:5 0 R"] = qpdfObject{Value: map[string]any{"/FT": "/Sig", "/T": "u:Signature1"}}
+	doc.Objects["obj:3 0 R"].Value.(map[string]any)["/Annots"] = []any{"5 0 R"}
+
+	second, res, err := signer.sign(first, doc, pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Field != "Signature2" {
+		t.Errorf("field = %s, want Signature2", res.Field)
+	}
+	if !bytes.Contains(second[len(first):], []byte("/Fields [5 0 R 9 0 R]")) {
+		t.Error("the new field was not added next to the first one")
+	}
+
+	sigs := verifySignatures(second)
+	if len(sigs) != 2 {
+		t.Fatalf("found %d signatures, want 2", len(sigs))
+	}
+	if !sigs[0].Valid || sigs[0].WholeDocument {
+		t.Errorf("first signature = %+v, want valid for its revision only", sigs[0])
+	}
+	if !sigs[1].Valid || !sigs[1].WholeDocument {
+		t.Errorf("second signature = %+v, want valid for the whole file", sigs[1])
+	}
+}
+
+func TestNewSigner_KeyMismatch(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := NewSigner(key, []*x509.Certificate{testIdentity(t, other, "Other")}); err == nil {
+		t.Error("expected an error for a certificate of another key")
+	}
+}
+
+func TestNewSignerFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	cert := testIdentity(t, key, "Files")
+
+	p12, err := pkcs12.Modern.Encode(key, cert, nil, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p12Path := filepath.Join(dir, "id.p12")
+	os.WriteFile(p12Path, p12, 0600)
+
+	if s, err := NewSignerFromPKCS12(p12Path, "secret"); err != nil || s.Certificates[0].Subject.CommonName != "Files" {
+		t.Errorf("NewSignerFromPKCS12 = %v, %v", s, err)
+	}
+	if _, err := NewSignerFromPKCS12(p12Path, "wrong"); err == nil {
+		t.Error("expected an error for a wrong password")
+	}
+
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	pemPath := filepath.Join(dir, "id.pem")
+	os.WriteFile(pemPath, append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})...), 0600)
+
+	if s, err := NewSignerFromPEM(pemPath, pemPath); err != nil || s.Certificates[0].Subject.CommonName != "Files" {
+		t.Errorf("NewSignerFromPEM = %v, %v", s, err)
+	}
+}
+
+func TestPDFDate(t *testing.T) {
+	tm := time.Date(2024, 5, 1, 14, 30, 0, 0, time.FixedZone("", 3*3600))
+	if got := pdfDate(tm); got != "D:20240501143000+03'00'" {
+		t.Errorf("pdfDate = %s", got)
+	}
+	if got := parsePDFDate(pdfDate(tm)); !got.Equal(tm) {
+		t.Errorf("parsePDFDate = %v, want %v", got, tm)
+	}
+	if got := parsePDFDate("D:2024"); !got.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parsePDFDate(D:2024) = %v", got)
+	}
+}
+
+func TestSigner_Sign_Integration(t *testing.T) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping signing test")
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	ctx := context.Background()
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	signer, err := NewSigner(key, []*x509.Certificate{testIdentity(t, key, "Integration")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.Visible = true
+
+	t.Logf("✍️  Signing: %s", inputPath)
+	output := filepath.Join(tempDir, "signed.pdf")
+	if _, err := signer.Sign(ctx, inputPath, output); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if err := runQPDF(ctx, "--check", output); err != nil {
+		t.Errorf("qpdf --check: %v", err)
+	}
+
+	sigs, err := Verify(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 1 || !sigs[0].Valid {
+		t.Errorf("Verify = %+v", sigs)
+	}
+}
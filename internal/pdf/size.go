@@ -0,0 +1,46 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxSize is the largest size ParseSize accepts, far beyond any PDF the
+// tools handle but well inside int64.
+const maxSize = 1 << 40
+
+// ParseSize parses human readable sizes such as "2MB", "1.5 mb", "500K" or
+// "734003" (plain bytes). Units are binary: 1 KB = 1024 bytes. Sizes must
+// lie between 1 byte and 1024 GB.
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(str, "B")
+
+	multiplier := int64(1)
+	if n := len(str); n > 0 {
+		switch str[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			str = str[:n-1]
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	size := value * float64(multiplier)
+	if size < 1 || size > maxSize {
+		return 0, fmt.Errorf("invalid size %q (use 1 byte to 1024 GB)", s)
+	}
+	return int64(size), nil
+}
@@ -0,0 +1,238 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SplitMode says where a document is cut.
+type SplitMode string
+
+const (
+	// SplitRanges writes one file per comma separated range, e.g. "1-3,5,8-".
+	SplitRanges SplitMode = "ranges"
+	// SplitEvery writes chunks of a fixed number of pages.
+	SplitEvery SplitMode = "every"
+	// SplitBookmarks writes one file per top-level bookmark.
+	SplitBookmarks SplitMode = "bookmarks"
+)
+
+// ParseSplitMode accepts "ranges", "every" and "bookmarks".
+func ParseSplitMode(s string) (SplitMode, error) {
+	switch mode := SplitMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case SplitRanges, SplitEvery, SplitBookmarks:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown split mode %q (use ranges, every or bookmarks)", s)
+	}
+}
+
+// PageRange is an inclusive range of 1-based pages.
+type PageRange struct {
+	First, Last int
+}
+
+// SplitPart is one written piece of a split document.
+type SplitPart struct {
+	Path  string
+	Title string // bookmark title in SplitBookmarks mode
+	PageRange
+}
+
+// Splitter cuts a PDF into several files with qpdf.
+type Splitter struct {
+	Mode SplitMode
+
+	// Ranges is used by SplitRanges: pages or ranges separated by commas,
+	// an open range ("8-") runs to the last page.
+	Ranges string
+
+	// Every is the chunk size of SplitEvery.
+	Every int
+}
+
+// Split writes the parts of inputPath to outputDir as <name>_<pages>.pdf, or
+// <name>_<bookmark title>.pdf when splitting by bookmarks. On error, parts
+// written so far are removed.
+func (s *Splitter) Split(ctx context.Context, inputPath string, outputDir string) (parts []SplitPart, err error) {
+	total, err := PageCount(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []PageRange
+	var titles []string
+	switch s.Mode {
+	case SplitRanges:
+		ranges, err = ParsePageRanges(s.Ranges, total)
+	case SplitEvery:
+		ranges, err = chunkRanges(s.Every, total)
+	case SplitBookmarks:
+		var outline []Bookmark
+		if outline, err = ReadOutline(ctx, inputPath); err == nil {
+			ranges, titles, err = bookmarkRanges(outline, total)
+		}
+	default:
+		err = fmt.Errorf("unknown split mode %q", s.Mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			for _, part := range parts {
+				os.Remove(part.Path)
+			}
+		}
+	}()
+
+	fileName := filepath.Base(inputPath)
+	baseName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	used := map[string]int{}
+
+	for i, r := range ranges {
+		part := SplitPart{PageRange: r}
+		suffix := r.String()
+		if titles != nil {
+			part.Title = titles[i]
+			suffix = safeFileName(titles[i])
+		}
+		name := baseName + "_" + suffix
+		if used[name]++; used[name] > 1 {
+			name += "_" + strconv.Itoa(used[name])
+		}
+		part.Path = filepath.Join(outputDir, name+".pdf")
+
+		// Selecting from the input itself (".") keeps its metadata
+		if err := runQPDF(ctx, inputPath, "--pages", ".", r.String(), "--", part.Path); err != nil {
+			os.Remove(part.Path)
+			return parts, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+func (r PageRange) String() string {
+	if r.First == r.Last {
+		return strconv.Itoa(r.First)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// ParsePageRanges parses "1-3,5,8-" for a document of total pages.
+func ParsePageRanges(spec string, total int) ([]PageRange, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, fmt.Errorf("no page ranges given")
+	}
+
+	var ranges []PageRange
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		first, last, isRange := strings.Cut(item, "-")
+
+		r := PageRange{First: 1, Last: total}
+		var err error
+		if first != "" {
+			r.First, err = strconv.Atoi(strings.TrimSpace(first))
+		}
+		if err == nil && !isRange {
+			r.Last = r.First
+		} else if err == nil && last != "" {
+			r.Last, err = strconv.Atoi(strings.TrimSpace(last))
+		}
+
+		switch {
+		case err != nil || item == "" || item == "-":
+			return nil, fmt.Errorf("invalid page range %q", item)
+		case r.First < 1 || r.Last > total:
+			return nil, fmt.Errorf("page range %q is outside 1-%d", item, total)
+		case r.First > r.Last:
+			return nil, fmt.Errorf("page range %q is reversed", item)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// chunkRanges cuts total pages into ranges of n pages, the last one may be
+// shorter.
+func chunkRanges(n int, total int) ([]PageRange, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("pages per file must be at least 1")
+	}
+	var ranges []PageRange
+	for first := 1; first <= total; first += n {
+		ranges = append(ranges, PageRange{First: first, Last: min(first+n-1, total)})
+	}
+	return ranges, nil
+}
+
+// bookmarkRanges returns one range per top-level bookmark, running to the
+// page before the next one. Pages in front of the first bookmark (e.g. a
+// cover) go to the first part.
+func bookmarkRanges(outline []Bookmark, total int) ([]PageRange, []string, error) {
+	var marks []Bookmark
+	for _, b := range outline {
+		if b.Page >= 1 && b.Page <= total {
+			marks = append(marks, b)
+		}
+	}
+	if len(marks) == 0 {
+		return nil, nil, fmt.Errorf("the document has no bookmarks to split by")
+	}
+	slices.SortStableFunc(marks, func(a, b Bookmark) int { return a.Page - b.Page })
+
+	var ranges []PageRange
+	var titles []string
+	for i, b := range marks {
+		last := total
+		if i+1 < len(marks) {
+			last = marks[i+1].Page - 1
+		}
+		first := b.Page
+		if i == 0 {
+			first = 1
+		}
+		if last < first {
+			// Several bookmarks on one page: the last of them gets it
+			continue
+		}
+		ranges = append(ranges, PageRange{First: first, Last: last})
+		titles = append(titles, b.Title)
+	}
+	return ranges, titles, nil
+}
+
+// safeFileName turns a bookmark title into a file name: letters (any
+// script), digits, '-' and '_' are kept, runs of anything else become '_'.
+func safeFileName(title string) string {
+	var b strings.Builder
+	gap := false
+	for _, r := range title {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			if gap && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			gap = false
+		} else {
+			gap = true
+		}
+	}
+	name := []rune(b.String())
+	if len(name) > 60 {
+		name = name[:60]
+	}
+	if len(name) == 0 {
+		return "untitled"
+	}
+	return string(name)
+}
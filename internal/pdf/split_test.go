@@ -0,0 +1,103 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestParsePageRanges(t *testing.T) {
+	got, err := ParsePageRanges("1-3, 5,8-", 10)
+	want := []PageRange{{1, 3}, {5, 5}, {8, 10}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePageRanges = %v, %v; want %v", got, err, want)
+	}
+
+	for _, bad := range []string{"", "0", "4-2", "11", "3-12", "a", "1,,2", "-"} {
+		if _, err := ParsePageRanges(bad, 10); err == nil {
+			t.Errorf("ParsePageRanges(%q) returned no error", bad)
+		}
+	}
+}
+
+func TestChunkRanges(t *testing.T) {
+	got, _ := chunkRanges(4, 10)
+	want := []PageRange{{1, 4}, {5, 8}, {9, 10}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("chunkRanges(4, 10) = %v, want %v", got, want)
+	}
+	if _, err := chunkRanges(0, 10); err == nil {
+		t.Error("chunkRanges(0) returned no error")
+	}
+}
+
+func TestBookmarkRanges(t *testing.T) {
+	ranges, titles, err := bookmarkRanges([]Bookmark{
+		{Title: "Part 2", Page: 6},
+		{Title: "Part 1", Page: 2},
+		{Title: "Intro to part 2", Page: 6},
+		{Title: "Broken", Page: 0},
+	}, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRanges := []PageRange{{1, 5}, {6, 9}}
+	wantTitles := []string{"Part 1", "Intro to part 2"}
+	if !reflect.DeepEqual(ranges, wantRanges) || !reflect.DeepEqual(titles, wantTitles) {
+		t.Errorf("bookmarkRanges = %v %q, want %v %q", ranges, titles, wantRanges, wantTitles)
+	}
+
+	if _, _, err := bookmarkRanges(nil, 9); err == nil {
+		t.Error("bookmarkRanges without bookmarks returned no error")
+	}
+}
+
+func TestSafeFileName(t *testing.T) {
+	cases := map[string]string{
+		"Глава 1: Увод": "Глава_1_Увод",
+		"  a/b\\c  ":    "a_b_c",
+		"???":           "untitled",
+	}
+	for in, want := range cases {
+		if got := safeFileName(in); got != want {
+			t.Errorf("safeFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSplitter_Split_Integration(t *testing.T) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping split test")
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	ctx := context.Background()
+	total, err := PageCount(ctx, inputPath)
+	if err != nil {
+		t.Fatalf("pageCount: %v", err)
+	}
+
+	splitter := &Splitter{Mode: SplitEvery, Every: 1}
+	t.Logf("🚀 Splitting %s into single pages", inputPath)
+	parts, err := splitter.Split(ctx, inputPath, tempDir)
+	if err != nil {
+		t.Fatalf("Split returned error: %v", err)
+	}
+
+	if len(parts) != total {
+		t.Fatalf("❌ got %d parts, want %d", len(parts), total)
+	}
+	for _, part := range parts {
+		if _, err := os.Stat(part.Path); err != nil {
+			t.Errorf("❌ part %s was not written: %v", part.PageRange, err)
+		}
+		if n, _ := PageCount(ctx, part.Path); n != 1 {
+			t.Errorf("❌ part %s has %d pages, want 1", part.PageRange, n)
+		}
+	}
+
+	t.Logf("✅ Split into %d files", len(parts))
+}
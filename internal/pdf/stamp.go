@@ -0,0 +1,232 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStampTemplate is the text a Stamper writes when none is given.
+const DefaultStampTemplate = "Page {page} of {total}"
+
+// stampPlaceholder matches the fields of a stamp template.
+var stampPlaceholder = regexp.MustCompile(`\{[a-z]+\}`)
+
+// Stamper writes a line of text, such as page numbers or Bates numbers, on
+// pages, with qpdf like Watermarker. The template may contain {page},
+// {total}, {filename}, {date} and {bates}.
+//
+// The Bates counter continues from one Stamp call to the next, so a batch of
+// files stamped with one Stamper is numbered straight through; stamp them
+// one after the other, in order.
+type Stamper struct {
+	Template string
+	Position Position // "" means PositionBottom
+	Font     string   // see FontNames; "" means Helvetica
+	FontSize float64  // points
+	Color    string   // hex, e.g. "#000000"
+
+	// MarginX is the distance from the left and right edges, MarginY from
+	// the top and bottom edges, in mm.
+	MarginX, MarginY float64
+
+	// Pages selects the pages ("1-3,5", see ParsePageRanges); empty means
+	// all. Only stamped pages use up Bates numbers.
+	Pages string
+
+	// {bates} is BatesPrefix followed by the counter, zero-padded to
+	// BatesDigits, starting at BatesStart.
+	BatesPrefix string
+	BatesDigits int
+	BatesStart  int
+
+	// Date is written as {date} in DateFormat (a Go layout, "" means
+	// 2006-01-02). The zero time means today.
+	Date       time.Time
+	DateFormat string
+
+	used int // Bates numbers used by earlier Stamp calls
+}
+
+func NewStamper() *Stamper {
+	return &Stamper{
+		Template:    DefaultStampTemplate,
+		Position:    PositionBottom,
+		FontSize:    10,
+		Color:       "#000000",
+		MarginX:     15,
+		MarginY:     10,
+		BatesDigits: 6,
+		BatesStart:  1,
+	}
+}
+
+// StampResult lists the stamped pages and, when the template has {bates},
+// the first and last Bates number used.
+type StampResult struct {
+	Pages      []int
+	FirstBates string
+	LastBates  string
+}
+
+// Stamp writes inputPath with the template on the selected pages to
+// outputPath and advances the Bates counter.
+func (s *Stamper) Stamp(ctx context.Context, inputPath string, outputPath string) (*StampResult, error) {
+	font, color, err := s.check()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes, err := pageSizes(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := selectPages(s.Pages, len(sizes))
+	if err != nil {
+		return nil, err
+	}
+
+	filename := filepath.Base(inputPath)
+	overlay := s.overlay(font, color, sizes, pages, filename)
+	from := fmt.Sprintf("1-%d", len(pages))
+	if err := applyOverlay(ctx, inputPath, outputPath, overlay, pages, from, false); err != nil {
+		return nil, err
+	}
+
+	res := &StampResult{Pages: pages}
+	if strings.Contains(s.Template, "{bates}") {
+		res.FirstBates = s.bates(0)
+		res.LastBates = s.bates(len(pages) - 1)
+		s.used += len(pages)
+	}
+	return res, nil
+}
+
+// check validates the settings and returns the font and text color.
+func (s *Stamper) check() (standardFont, [3]float64, error) {
+	if strings.TrimSpace(s.Template) == "" {
+		return standardFont{}, [3]float64{}, fmt.Errorf("no stamp text given")
+	}
+	for _, field := range stampPlaceholder.FindAllString(s.Template, -1) {
+		switch field {
+		case "{page}", "{total}", "{filename}", "{date}", "{bates}":
+		default:
+			return standardFont{}, [3]float64{}, fmt.Errorf("unknown field %s (use {page}, {total}, {filename}, {date} or {bates})", field)
+		}
+	}
+	if _, err := winAnsi(s.Template); err != nil {
+		return standardFont{}, [3]float64{}, err
+	}
+	for _, v := range []struct {
+		name  string
+		value float64
+	}{{"font size", s.FontSize}, {"horizontal margin", s.MarginX}, {"vertical margin", s.MarginY}} {
+		if math.IsNaN(v.value) || math.IsInf(v.value, 0) {
+			return standardFont{}, [3]float64{}, fmt.Errorf("%s must be a finite number, got %g", v.name, v.value)
+		}
+	}
+	if s.FontSize < 1 || s.FontSize > 200 {
+		return standardFont{}, [3]float64{}, fmt.Errorf("font size must be between 1 and 200, got %g", s.FontSize)
+	}
+	if s.MarginX < 0 || s.MarginX > 100 || s.MarginY < 0 || s.MarginY > 100 {
+		return standardFont{}, [3]float64{}, fmt.Errorf("margins must be between 0 and 100 mm")
+	}
+	if s.BatesDigits < 0 || s.BatesDigits > 12 {
+		return standardFont{}, [3]float64{}, fmt.Errorf("bates digits must be between 0 and 12, got %d", s.BatesDigits)
+	}
+	if s.BatesStart < 0 {
+		return standardFont{}, [3]float64{}, fmt.Errorf("bates start must not be negative, got %d", s.BatesStart)
+	}
+	if _, ok := positionAnchors[s.position()]; !ok {
+		return standardFont{}, [3]float64{}, fmt.Errorf("unknown position %q", s.Position)
+	}
+
+	font, err := lookupFont(s.Font)
+	if err != nil {
+		return standardFont{}, [3]float64{}, err
+	}
+	color, err := ParseColor(s.Color)
+	if err != nil {
+		return standardFont{}, [3]float64{}, err
+	}
+	return font, color, nil
+}
+
+func (s *Stamper) position() Position {
+	if s.Position == "" {
+		return PositionBottom
+	}
+	return s.Position
+}
+
+// bates returns the Bates number of the i-th page stamped by the next
+// Stamp call.
+func (s *Stamper) bates(i int) string {
+	return fmt.Sprintf("%s%0*d", s.BatesPrefix, s.BatesDigits, s.BatesStart+s.used+i)
+}
+
+// text fills in the template for the i-th stamped page.
+func (s *Stamper) text(i, page, total int, filename, date string) string {
+	return stampPlaceholder.ReplaceAllStringFunc(s.Template, func(field string) string {
+		switch field {
+		case "{page}":
+			return strconv.Itoa(page)
+		case "{total}":
+			return strconv.Itoa(total)
+		case "{filename}":
+			return filename
+		case "{date}":
+			return date
+		case "{bates}":
+			return s.bates(i)
+		}
+		return field
+	})
+}
+
+// overlay draws one overlay page, the size of the page it goes on, for each
+// of pages.
+func (s *Stamper) overlay(font standardFont, color [3]float64, sizes []pageSize, pages []int, filename string) []byte {
+	date := s.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	layout := s.DateFormat
+	if layout == "" {
+		layout = "2006-01-02"
+	}
+	dateText := date.Format(layout)
+
+	w := newPDFWriter()
+	// The page tree is written once all pages are known
+	pagesID := w.reserve()
+	catalogID := w.add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	fontID := w.add(font.fontObject())
+	resourcesID := w.add(fmt.Sprintf("<< /Font << /F0 %d 0 R >> >>", fontID))
+
+	marginX, marginY := s.MarginX*72/25.4, s.MarginY*72/25.4
+	kids := make([]string, len(pages))
+	for i, page := range pages {
+		size := sizes[page-1]
+		// File names are not checked like the template; winAnsi cannot fail
+		// on latin1 text
+		text, _ := winAnsi(latin1(s.text(i, page, len(sizes), filename, dateText)))
+		width, height := font.textWidth(text, s.FontSize), capHeight*s.FontSize
+		cx, cy := markCenter(size, width, height, 1, 0, s.position(), marginX, marginY)
+
+		content := fmt.Sprintf("q BT /F0 %.2f Tf %.3f %.3f %.3f rg %.4f %.4f Td %s Tj ET Q",
+			s.FontSize, color[0], color[1], color[2], cx-width/2, cy-height/2, pdfString(text))
+		contentID := w.addStream("", []byte(content))
+		pageID := w.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Contents %d 0 R /Resources %d 0 R >>",
+			pagesID, size.Width, size.Height, contentID, resourcesID))
+		kids[i] = fmt.Sprintf("%d 0 R", pageID)
+	}
+
+	w.addAt(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	return w.finish(catalogID)
+}
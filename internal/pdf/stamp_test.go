@@ -0,0 +1,112 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStamper_Text(t *testing.T) {
+	s := NewStamper()
+	s.Template = "{bates} | Page {page} of {total} | {filename} | {date}"
+	s.BatesPrefix = "ACME"
+	s.used = 41
+
+	got := s.text(1, 3, 10, "contract.pdf", "2024-05-01")
+	want := "ACME000043 | Page 3 of 10 | contract.pdf | 2024-05-01"
+	if got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
+
+func TestStamper_Check(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*Stamper)
+	}{
+		{"empty", func(s *Stamper) { s.Template = " " }},
+		{"unknown field", func(s *Stamper) { s.Template = "Page {pg}" }},
+		{"cyrillic", func(s *Stamper) { s.Template = "Страница {page}" }},
+		{"font size", func(s *Stamper) { s.FontSize = 0 }},
+		{"margin", func(s *Stamper) { s.MarginY = 150 }},
+		{"NaN font size", func(s *Stamper) { s.FontSize = math.NaN() }},
+		{"NaN margin", func(s *Stamper) { s.MarginX = math.NaN() }},
+		{"infinite margin", func(s *Stamper) { s.MarginY = math.Inf(1) }},
+		{"digits", func(s *Stamper) { s.BatesDigits = 20 }},
+		{"position", func(s *Stamper) { s.Position = "middle" }},
+	}
+	for _, tt := range tests {
+		s := NewStamper()
+		tt.edit(s)
+		if _, _, err := s.check(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	if _, _, err := NewStamper().check(); err != nil {
+		t.Errorf("default stamper: %v", err)
+	}
+}
+
+func TestStamper_Overlay(t *testing.T) {
+	s := NewStamper()
+	s.Template = "{bates} {filename} {date}"
+	s.BatesPrefix = "X-"
+	s.BatesDigits = 3
+	s.Date = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	s.DateFormat = "02.01.2006"
+	font, color, err := s.check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a4 := pageSize{Width: 595, Height: 842}
+	data := s.overlay(font, color, []pageSize{a4, a4, a4}, []int{2, 3}, "договор.pdf")
+
+	if n := bytes.Count(data, []byte("/Type /Page ")); n != 2 {
+		t.Errorf("overlay has %d pages, want one per stamped page", n)
+	}
+	for _, want := range []string{"(X-001 ???????.pdf 01.05.2024)", "(X-002 ???????.pdf 01.05.2024)"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("overlay is missing %s", want)
+		}
+	}
+}
+
+func TestStamper_Stamp_Integration(t *testing.T) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping stamp test")
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	ctx := context.Background()
+	pages, err := PageCount(ctx, inputPath)
+	if err != nil {
+		t.Fatalf("pageCount: %v", err)
+	}
+
+	s := NewStamper()
+	s.Template = "{bates}"
+	s.BatesPrefix = "DOC"
+
+	t.Logf("🔢 Bates numbering two copies of: %s", inputPath)
+	first, err := s.Stamp(ctx, inputPath, filepath.Join(tempDir, "first.pdf"))
+	if err != nil {
+		t.Fatalf("Stamp: %v", err)
+	}
+	second, err := s.Stamp(ctx, inputPath, filepath.Join(tempDir, "second.pdf"))
+	if err != nil {
+		t.Fatalf("Stamp: %v", err)
+	}
+
+	if first.FirstBates != "DOC000001" || len(first.Pages) != pages {
+		t.Errorf("first file: %+v", first)
+	}
+	if want := s.bates(-pages); second.FirstBates != want {
+		t.Errorf("second file starts at %s, want %s", second.FirstBates, want)
+	}
+}
@@ -0,0 +1,170 @@
+package pdf
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Table is a grid found among the text blocks of a page.
+type Table struct {
+	Rows           [][]string
+	X0, Y0, X1, Y1 float64
+}
+
+const (
+	// maxCellRunes and maxCellLines keep body columns out of tables.
+	maxCellRunes = 80
+	maxCellLines = 3
+)
+
+// detectTables finds runs of at least two rows of at least two short blocks
+// each, whose cells line up under the columns of the first row. It returns
+// the blocks that are not part of a table, in their original order, and the
+// tables top to bottom.
+func detectTables(blocks []TextBlock) ([]TextBlock, []Table) {
+	var candidates []int
+	for i, b := range blocks {
+		text := strings.TrimSpace(b.Text)
+		if text != "" && utf8.RuneCountInString(text) <= maxCellRunes && strings.Count(text, "\n") < maxCellLines {
+			candidates = append(candidates, i)
+		}
+	}
+
+	rows := groupRows(blocks, candidates)
+
+	used := map[int]bool{}
+	var tables []Table
+	for start := 0; start < len(rows); {
+		if len(rows[start]) < 2 {
+			start++
+			continue
+		}
+
+		columns := rows[start]
+		table := [][]int{columns}
+		end := start + 1
+		for ; end < len(rows); end++ {
+			prev := table[len(table)-1]
+			if len(rows[end]) < 2 || !rowsAdjacent(blocks, prev, rows[end]) {
+				break
+			}
+			cells, ok := alignRow(blocks, columns, rows[end])
+			if !ok {
+				break
+			}
+			table = append(table, cells)
+		}
+
+		if len(table) < 2 {
+			start++
+			continue
+		}
+
+		t := Table{X0: math.Inf(1), Y0: math.Inf(1), X1: math.Inf(-1), Y1: math.Inf(-1)}
+		for _, row := range table {
+			cells := make([]string, len(row))
+			for c, idx := range row {
+				if idx < 0 {
+					continue
+				}
+				b := blocks[idx]
+				cells[c] = collapseSpaces(removeControlCharacters(b.Text))
+				used[idx] = true
+				t.X0, t.Y0 = min(t.X0, b.X0), min(t.Y0, b.Y0)
+				t.X1, t.Y1 = max(t.X1, b.X1), max(t.Y1, b.Y1)
+			}
+			t.Rows = append(t.Rows, cells)
+		}
+		tables = append(tables, t)
+		start = end
+	}
+
+	if len(tables) == 0 {
+		return blocks, nil
+	}
+
+	rest := make([]TextBlock, 0, len(blocks)-len(used))
+	for i, b := range blocks {
+		if !used[i] {
+			rest = append(rest, b)
+		}
+	}
+	return rest, tables
+}
+
+// groupRows groups the candidate blocks whose vertical centers line up,
+// rows top to bottom and cells left to right.
+func groupRows(blocks []TextBlock, candidates []int) [][]int {
+	sorted := append([]int(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return center(blocks[sorted[i]]) < center(blocks[sorted[j]])
+	})
+
+	var rows [][]int
+	for _, idx := range sorted {
+		b := blocks[idx]
+		if n := len(rows); n > 0 {
+			first := blocks[rows[n-1][0]]
+			tolerance := max(3, min(first.Y1-first.Y0, b.Y1-b.Y0)/2)
+			if math.Abs(center(b)-center(first)) <= tolerance {
+				rows[n-1] = append(rows[n-1], idx)
+				continue
+			}
+		}
+		rows = append(rows, []int{idx})
+	}
+
+	for _, row := range rows {
+		sort.Slice(row, func(i, j int) bool { return blocks[row[i]].X0 < blocks[row[j]].X0 })
+	}
+	return rows
+}
+
+// alignRow maps each cell of row to the column it overlaps most. Columns
+// without a cell get -1. It fails if two cells fall in the same column, a
+// cell matches no column or neighbouring cells overlap.
+func alignRow(blocks []TextBlock, columns, row []int) ([]int, bool) {
+	cells := make([]int, len(columns))
+	for i := range cells {
+		cells[i] = -1
+	}
+
+	for i, idx := range row {
+		b := blocks[idx]
+		if i > 0 && blocks[row[i-1]].X1 > b.X0 {
+			return nil, false
+		}
+
+		best, bestOverlap := -1, 0.0
+		for c, col := range columns {
+			if overlap := min(b.X1, blocks[col].X1) - max(b.X0, blocks[col].X0); overlap > bestOverlap {
+				best, bestOverlap = c, overlap
+			}
+		}
+		if best < 0 || cells[best] >= 0 {
+			return nil, false
+		}
+		cells[best] = idx
+	}
+	return cells, true
+}
+
+// rowsAdjacent reports whether next starts within a couple of line heights
+// below prev.
+func rowsAdjacent(blocks []TextBlock, prev, next []int) bool {
+	var bottom, top, height float64 = math.Inf(-1), math.Inf(1), 0
+	for _, idx := range prev {
+		bottom = max(bottom, blocks[idx].Y1)
+		height = max(height, blocks[idx].Y1-blocks[idx].Y0)
+	}
+	for _, idx := range next {
+		top = min(top, blocks[idx].Y0)
+	}
+	return top-bottom <= 2*height+4
+}
+
+func center(b TextBlock) float64 {
+	return (b.Y0 + b.Y1) / 2
+}
@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectTables(t *testing.T) {
+	blocks := []TextBlock{
+		{Text: "A long paragraph of body text that sits above the table and is not part of it at all.", X0: 50, Y0: 40, X1: 540, Y1: 80},
+		{Text: "Product", X0: 50, Y0: 100, X1: 120, Y1: 112},
+		{Text: "Price", X0: 300, Y0: 100, X1: 340, Y1: 112},
+		{Text: "Qty", X0: 450, Y0: 101, X1: 480, Y1: 113},
+		{Text: "Apples", X0: 50, Y0: 118, X1: 100, Y1: 130},
+		{Text: "1.20", X0: 305, Y0: 118, X1: 335, Y1: 130},
+		{Text: "3", X0: 460, Y0: 118, X1: 468, Y1: 130},
+		// Empty price cell
+		{Text: "Pears", X0: 50, Y0: 136, X1: 95, Y1: 148},
+		{Text: "7", X0: 460, Y0: 136, X1: 468, Y1: 148},
+		{Text: "Footnote far below.", X0: 50, Y0: 400, X1: 200, Y1: 412},
+	}
+
+	rest, tables := detectTables(blocks)
+
+	if len(tables) != 1 {
+		t.Fatalf("found %d tables, want 1", len(tables))
+	}
+	wantRows := [][]string{
+		{"Product", "Price", "Qty"},
+		{"Apples", "1.20", "3"},
+		{"Pears", "", "7"},
+	}
+	if !reflect.DeepEqual(tables[0].Rows, wantRows) {
+		t.Errorf("rows = %q, want %q", tables[0].Rows, wantRows)
+	}
+	if tables[0].Y0 != 100 || tables[0].Y1 != 148 {
+		t.Errorf("table spans %v-%v, want 100-148", tables[0].Y0, tables[0].Y1)
+	}
+
+	if len(rest) != 2 || rest[0].Y0 != 40 || rest[1].Y0 != 400 {
+		t.Errorf("remaining blocks = %v", rest)
+	}
+}
+
+func TestDetectTables_IgnoresTextColumns(t *testing.T) {
+	// Two newspaper columns side by side are not a table
+	long := "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore."
+	blocks := []TextBlock{
+		{Text: long, X0: 50, Y0: 100, X1: 280, Y1: 200},
+		{Text: long, X0: 300, Y0: 100, X1: 530, Y1: 200},
+		{Text: long, X0: 50, Y0: 210, X1: 280, Y1: 310},
+		{Text: long, X0: 300, Y0: 210, X1: 530, Y1: 310},
+	}
+
+	rest, tables := detectTables(blocks)
+	if len(tables) != 0 || len(rest) != len(blocks) {
+		t.Errorf("detected %d tables in plain columns", len(tables))
+	}
+}
@@ -0,0 +1,200 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrTargetUnreachable is returned by CompressToSize when even the lowest
+// resolution and JPEG quality produce a file above the target.
+var ErrTargetUnreachable = errors.New("target size cannot be reached")
+
+// Search space of CompressToSize, ordered from best to worst quality.
+var (
+	targetDPIs      = []int{300, 200, 150, 120, 96, 72, 50}
+	targetQualities = []int{90, 80, 70, 60, 45, 30}
+)
+
+// TargetResult describes the settings CompressToSize settled on.
+type TargetResult struct {
+	Options      CompressOptions    // full options of the winning run
+	Size         int64              // size of the written output in bytes
+	Attempts     int                // Ghostscript runs it took to get there
+	KeptOriginal bool               // the input already fit, it was copied unchanged
+	Report       *CompressionReport // report of the winning run
+}
+
+// CompressToSize searches DPI and JPEG quality for the highest quality
+// output not larger than target bytes. It first looks for the highest DPI
+// that fits at a medium quality, then raises the quality as far as that DPI
+// allows. Every run is cached, so no setting is compressed twice. The other
+// fields of base (bookmarks, forms, colors, ...) are kept as given.
+func (c *Compressor) CompressToSize(ctx context.Context, inputPath string, outputPath string, target int64, base CompressOptions) (*TargetResult, error) {
+	if base.Lossless {
+		return nil, fmt.Errorf("target size search needs a lossy level, lossless has nothing to tune")
+	}
+
+	info, err := os.Stat(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() <= target {
+		report := &CompressionReport{InputSize: info.Size(), OutputSize: info.Size(), KeptOriginal: true}
+		if base.SourceSignatures > 0 {
+			// Already rewritten, copying it preserves nothing
+			if _, err := c.keepSigned(ctx, inputPath, outputPath, base, report); err != nil {
+				return nil, err
+			}
+		} else {
+			if report.Signatures, err = CountSignatures(ctx, inputPath); err != nil {
+				return nil, err
+			}
+			if report.Signatures > 0 {
+				report.SignatureStatus = SignaturePreserved
+			}
+		}
+		if err := copyFile(inputPath, outputPath); err != nil {
+			return nil, err
+		}
+		return &TargetResult{Options: base, Size: info.Size(), KeptOriginal: true, Report: report}, nil
+	}
+
+	// Without Force a signed input is not compressed at all; with it every
+	// run below reports the broken signatures
+	report := &CompressionReport{InputSize: info.Size()}
+	kept, err := c.keepSigned(ctx, inputPath, outputPath, base, report)
+	if err != nil {
+		return nil, err
+	}
+	if kept {
+		return &TargetResult{Options: base, Size: info.Size(), KeptOriginal: true, Report: report}, nil
+	}
+
+	workDir, err := os.MkdirTemp(filepath.Dir(outputPath), "target_*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	search := &targetSearch{
+		compressor: c,
+		input:      inputPath,
+		workDir:    workDir,
+		base:       base,
+		cache:      map[[2]int]targetRun{},
+	}
+
+	// Phase 1: highest DPI that fits at a medium quality, or at the lowest
+	// quality if nothing fits at the medium one.
+	dpi, fitQuality, ok := 0, 0, false
+	for _, q := range []int{len(targetQualities) / 2, len(targetQualities) - 1} {
+		idx, err := search.firstFit(ctx, len(targetDPIs), target, func(i int) (int, int) { return targetDPIs[i], targetQualities[q] })
+		if err != nil {
+			return nil, err
+		}
+		if idx >= 0 {
+			dpi, fitQuality, ok = targetDPIs[idx], q, true
+			break
+		}
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w: smallest result was %d bytes (target %d bytes)", ErrTargetUnreachable, search.smallest, target)
+	}
+
+	// Phase 2: highest quality that still fits at that DPI.
+	idx, err := search.firstFit(ctx, len(targetQualities), target, func(i int) (int, int) { return dpi, targetQualities[i] })
+	if err != nil {
+		return nil, err
+	}
+	if idx < 0 || idx > fitQuality {
+		// Sizes need not shrink with the quality; phase 1 already found
+		// one that fits
+		idx = fitQuality
+	}
+
+	best := search.cache[[2]int{dpi, targetQualities[idx]}]
+	if err := os.Rename(best.path, outputPath); err != nil {
+		if err := copyFile(best.path, outputPath); err != nil {
+			return nil, err
+		}
+	}
+
+	c.logger().Info("target size reached", "target", target, "dpi", dpi, "jpeg_quality", best.opts.JPEGQuality,
+		"size", best.size, "attempts", len(search.cache))
+
+	return &TargetResult{Options: best.opts, Size: best.size, Attempts: len(search.cache), Report: best.report}, nil
+}
+
+type targetRun struct {
+	opts   CompressOptions
+	path   string
+	size   int64
+	report *CompressionReport
+}
+
+type targetSearch struct {
+	compressor *Compressor
+	input      string
+	workDir    string
+	base       CompressOptions
+	cache      map[[2]int]targetRun
+	smallest   int64
+}
+
+// firstFit binary searches the n candidates (ordered from largest to
+// smallest output) for the first one not exceeding target. It returns -1
+// when none fits.
+func (s *targetSearch) firstFit(ctx context.Context, n int, target int64, candidate func(i int) (dpi, quality int)) (int, error) {
+	var runErr error
+	idx := sort.Search(n, func(i int) bool {
+		if runErr != nil {
+			return true
+		}
+		dpi, quality := candidate(i)
+		run, err := s.run(ctx, dpi, quality)
+		if err != nil {
+			runErr = err
+			return true
+		}
+		return run.size <= target
+	})
+
+	if runErr != nil {
+		return -1, runErr
+	}
+	if idx == n {
+		return -1, nil
+	}
+	return idx, nil
+}
+
+func (s *targetSearch) run(ctx context.Context, dpi, quality int) (targetRun, error) {
+	key := [2]int{dpi, quality}
+	if run, ok := s.cache[key]; ok {
+		return run, nil
+	}
+
+	opts := s.base
+	opts.ColorDPI, opts.GrayDPI = dpi, dpi
+	opts.MonoDPI = max(dpi, s.base.MonoDPI) // bilevel images barely shrink, keep them sharp
+	opts.JPEGQuality = quality
+
+	path := filepath.Join(s.workDir, fmt.Sprintf("dpi%d_q%d.pdf", dpi, quality))
+	report, err := s.compressor.CompressWith(ctx, s.input, path, opts)
+	if err != nil {
+		return targetRun{}, fmt.Errorf("%d DPI, quality %d: %w", dpi, quality, err)
+	}
+
+	run := targetRun{opts: opts, path: path, size: report.OutputSize, report: report}
+	s.cache[key] = run
+	if s.smallest == 0 || run.size < s.smallest {
+		s.smallest = run.size
+	}
+	return run, nil
+}
@@ -0,0 +1,67 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"2MB":    2 << 20,
+		"2 mb":   2 << 20,
+		"1.5M":   3 << 19,
+		"500KB":  500 << 10,
+		"734003": 734003,
+		"1G":     1 << 30,
+	}
+	for in, want := range cases {
+		got, err := ParseSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "MB", "-1MB", "two MB", "inf", "-Inf", "NaN", "1e30", "2048G", "0.1"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) expected error", in)
+		}
+	}
+}
+
+func TestCompressor_CompressToSize_Integration(t *testing.T) {
+	if _, err := exec.LookPath("gs"); err != nil {
+		t.Skip("Ghostscript (gs) not found, skipping target size test")
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	info, _ := os.Stat(inputPath)
+	target := info.Size() / 3
+
+	outputPath := filepath.Join(tempDir, "target.pdf")
+	res, err := NewCompressor().CompressToSize(context.Background(), inputPath, outputPath, target, LevelEbook.Options())
+	if errors.Is(err, ErrTargetUnreachable) {
+		t.Skipf("test file cannot be reduced to %d bytes: %v", target, err)
+	}
+	if err != nil {
+		t.Fatalf("CompressToSize returned error: %v", err)
+	}
+
+	outInfo, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatalf("❌ Output file was not created: %v", err)
+	}
+	if outInfo.Size() > target {
+		t.Errorf("❌ Output %d bytes exceeds target %d bytes", outInfo.Size(), target)
+	}
+
+	t.Logf("✅ %d DPI, quality %d, %d bytes after %d attempts", res.Options.ColorDPI, res.Options.JPEGQuality, res.Size, res.Attempts)
+
+	_, err = NewCompressor().CompressToSize(context.Background(), inputPath, outputPath, 1024, LevelEbook.Options())
+	if !errors.Is(err, ErrTargetUnreachable) {
+		t.Errorf("expected ErrTargetUnreachable for a 1 KB target, got %v", err)
+	}
+}
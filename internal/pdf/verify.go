@@ -0,0 +1,341 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf16"
+)
+
+var (
+	byteRangePattern = regexp.MustCompile(`/ByteRange\s*\[\s*(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*\]`)
+	// sigEntryPattern reads the string and name entries of a signature
+	// dictionary.
+	sigEntryPattern = regexp.MustCompile(`/(M|Name|Reason|Location|SubFilter)\s*(\((?:\\.|[^\\)])*\)|<[0-9A-Fa-f\s]*>|/[^\s/<>\[\]()]+)`)
+)
+
+// Signature describes one signature found by Verify.
+type Signature struct {
+	Signer   string // common name of the signing certificate
+	Issuer   string // common name of its issuer
+	Name     string
+	Reason   string
+	Location string
+	SignedAt time.Time // as claimed by the signer; zero when missing
+
+	SubFilter string // e.g. ETSI.CAdES.detached
+
+	// Valid means the signed bytes are unchanged and the signature matches
+	// the certificate embedded with it.
+	Valid bool
+	// WholeDocument means the signature covers the whole file; false when
+	// revisions were appended after signing, such as later signatures.
+	WholeDocument bool
+	// Trusted means the certificate chains to a root the system trusts.
+	Trusted bool
+
+	Problem string // why the signature is not valid or not trusted
+}
+
+// Verify lists the signatures of the PDF at path, in the order they were
+// applied, and checks each. A file without signatures gives an empty list.
+func Verify(path string) ([]Signature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return verifySignatures(data), nil
+}
+
+// CountSignatures returns the number of signatures in the PDF at path,
+// without checking them. Signature fields that were never signed have
+// nothing to break and are not counted. The AcroForm fields are read with
+// qpdf; without qpdf, or when it cannot open the file, the signature
+// dictionaries are searched in the raw bytes instead.
+func CountSignatures(ctx context.Context, path string) (int, error) {
+	n, err := countSignatureFields(ctx, path)
+	if err == nil || IsAborted(err) {
+		return n, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return len(signatureByteRanges(data)), nil
+}
+
+// countSignatureFields counts the /Sig fields of the AcroForm that have a
+// value, the signature dictionary.
+func countSignatureFields(ctx context.Context, path string) (int, error) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		return 0, err
+	}
+	doc, err := readQPDFJSON(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+
+	catalog, _ := doc.resolve(doc.trailer()["/Root"]).(map[string]any)
+	acroForm, _ := doc.resolve(catalog["/AcroForm"]).(map[string]any)
+	fields, _ := doc.resolve(acroForm["/Fields"]).([]any)
+	return doc.countSignedFields(fields, "", map[string]bool{}), nil
+}
+
+// countSignedFields walks fields and their /Kids. fieldType is the /FT
+// inherited from the parent; seen guards against reference cycles.
+func (d *qpdfDocument) countSignedFields(fields []any, fieldType string, seen map[string]bool) int {
+	n := 0
+	for _, ref := range fields {
+		if s, ok := ref.(string); ok {
+			if seen[s] {
+				continue
+			}
+			seen[s] = true
+		}
+		field, _ := d.resolve(ref).(map[string]any)
+		if field == nil {
+			continue
+		}
+
+		ft := fieldType
+		if v, ok := field["/FT"].(string); ok {
+			ft = v
+		}
+		if v, ok := field["/V"]; ok && v != nil && ft == "/Sig" {
+			n++
+		}
+		kids, _ := d.resolve(field["/Kids"]).([]any)
+		n += d.countSignedFields(kids, ft, seen)
+	}
+	return n
+}
+
+func verifySignatures(data []byte) []Signature {
+	var sigs []Signature
+	for _, sr := range signatureByteRanges(data) {
+		sigs = append(sigs, verifySignature(data, sr.at, sr.byteRange))
+	}
+	return sigs
+}
+
+// signatureRange is the /ByteRange of a signature dictionary and where it
+// was found.
+type signatureRange struct {
+	at        int
+	byteRange [4]int
+}
+
+// signatureByteRanges finds the values of /Sig fields, the signature
+// dictionaries, by their /ByteRange in the raw bytes. Verify needs the
+// offsets; CountSignatures only falls back to it without qpdf. A dictionary
+// rewritten by a later revision is listed once.
+func signatureByteRanges(data []byte) []signatureRange {
+	var ranges []signatureRange
+	seen := map[[4]int]bool{}
+	for _, m := range byteRangePattern.FindAllSubmatchIndex(data, -1) {
+		var br [4]int
+		for i := range br {
+			br[i], _ = strconv.Atoi(string(data[m[2+2*i]:m[3+2*i]]))
+		}
+		if seen[br] {
+			continue
+		}
+		seen[br] = true
+		ranges = append(ranges, signatureRange{at: m[0], byteRange: br})
+	}
+	return ranges
+}
+
+func verifySignature(data []byte, at int, br [4]int) Signature {
+	var sig Signature
+	contentsStart, contentsEnd := br[0]+br[1], br[2]
+	if br[0] != 0 || br[1] < 0 || contentsEnd <= contentsStart || br[3] < 0 || contentsEnd+br[3] > len(data) {
+		sig.Problem = "invalid byte range"
+		return sig
+	}
+	sig.WholeDocument = contentsEnd+br[3] == len(data)
+
+	// The entries of the dictionary around the signature value
+	objStart := bytes.LastIndex(data[:min(at, contentsStart)], []byte(" obj"))
+	objEnd := bytes.Index(data[contentsEnd:], []byte("endobj"))
+	if objStart >= 0 && objEnd >= 0 {
+		dict := append(bytes.Clone(data[objStart:contentsStart]), data[contentsEnd:contentsEnd+objEnd]...)
+		for _, e := range sigEntryPattern.FindAllSubmatch(dict, -1) {
+			value := decodePDFString(e[2])
+			switch string(e[1]) {
+			case "M":
+				sig.SignedAt = parsePDFDate(value)
+			case "Name":
+				sig.Name = value
+			case "Reason":
+				sig.Reason = value
+			case "Location":
+				sig.Location = value
+			case "SubFilter":
+				sig.SubFilter = string(e[2][1:])
+			}
+		}
+	}
+
+	contents := bytes.Trim(data[contentsStart:contentsEnd], "<> \r\n\t")
+	der, err := hex.DecodeString(string(bytes.Join(bytes.Fields(contents), nil)))
+	if err != nil {
+		sig.Problem = "the signature value is not hex"
+		return sig
+	}
+	if sig.SubFilter == "adbe.x509.rsa_sha1" {
+		sig.Problem = "adbe.x509.rsa_sha1 signatures are not supported"
+		return sig
+	}
+
+	cms, err := parseCMS(der)
+	if err != nil {
+		sig.Problem = err.Error()
+		return sig
+	}
+	sig.Signer = cms.signer.Subject.CommonName
+	sig.Issuer = cms.signer.Issuer.CommonName
+
+	h := cms.hash.New()
+	h.Write(data[:contentsStart])
+	h.Write(data[contentsEnd : contentsEnd+br[3]])
+	if err := cms.verify(h.Sum(nil)); err != nil {
+		sig.Problem = err.Error()
+		return sig
+	}
+	sig.Valid = true
+
+	intermediates := x509.NewCertPool()
+	for _, c := range cms.certs {
+		intermediates.AddCert(c)
+	}
+	opts := x509.VerifyOptions{
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		CurrentTime:   sig.SignedAt,
+	}
+	if _, err := cms.signer.Verify(opts); err != nil {
+		sig.Problem = "certificate not trusted: " + err.Error()
+	} else {
+		sig.Trusted = true
+	}
+	return sig
+}
+
+// decodePDFString decodes a literal or hex string (PDFDocEncoding or
+// UTF-16BE); other tokens, such as names, are returned as they are.
+func decodePDFString(token []byte) string {
+	var raw []byte
+	switch {
+	case len(token) >= 2 && token[0] == '(':
+		raw = unescapeLiteral(token[1 : len(token)-1])
+	case len(token) >= 2 && token[0] == '<':
+		digits := bytes.Join(bytes.Fields(token[1:len(token)-1]), nil)
+		if len(digits)%2 == 1 {
+			digits = append(digits, '0')
+		}
+		raw, _ = hex.DecodeString(string(digits))
+	default:
+		return string(token)
+	}
+
+	if bytes.HasPrefix(raw, []byte{0xfe, 0xff}) {
+		units := make([]uint16, (len(raw)-2)/2)
+		for i := range units {
+			units[i] = uint16(raw[2+2*i])<<8 | uint16(raw[3+2*i])
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(raw))
+	for i, c := range raw {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func unescapeLiteral(s []byte) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			out = append(out, s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case '\r', '\n':
+			// line continuation
+			if c == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		default:
+			if c >= '0' && c <= '7' {
+				n := 0
+				for j := 0; j < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; j++ {
+					n = n*8 + int(s[i]-'0')
+					i++
+				}
+				i--
+				out = append(out, byte(n))
+			} else {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
+
+// parsePDFDate reads a PDF date, D:YYYYMMDDHHmmSSOHH'mm', with any of the
+// trailing parts left out. It returns the zero time when s is not a date.
+func parsePDFDate(s string) time.Time {
+	if len(s) > 2 && s[:2] == "D:" {
+		s = s[2:]
+	}
+	digits := len(s)
+	for i, c := range s {
+		if c < '0' || c > '9' {
+			digits = i
+			break
+		}
+	}
+	if digits < 4 {
+		return time.Time{}
+	}
+	// Fill in the left out parts: month and day 01, time 00
+	stamp := s[:min(digits, 14)] + "0101000000"[min(digits, 14)-4:]
+	t, err := time.Parse("20060102150405", stamp)
+	if err != nil {
+		return time.Time{}
+	}
+
+	zone := s[digits:]
+	if len(zone) >= 3 && (zone[0] == '+' || zone[0] == '-') {
+		hours, _ := strconv.Atoi(zone[1:3])
+		var minutes int
+		if len(zone) >= 6 {
+			minutes, _ = strconv.Atoi(zone[4:6])
+		}
+		offset := hours*3600 + minutes*60
+		if zone[0] == '-' {
+			offset = -offset
+		}
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.FixedZone("", offset))
+	}
+	return t
+}
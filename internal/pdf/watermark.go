@@ -0,0 +1,323 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Watermarker stamps text or an image on pages. The watermark is drawn into
+// a separate PDF with one page per page size and laid over (or under) the
+// pages with qpdf, so the document is not re-rendered.
+type Watermarker struct {
+	// Text is written when no Image is given; Latin-1 only.
+	Text     string
+	Font     string  // see FontNames; "" means Helvetica
+	FontSize float64 // points
+	Color    string  // hex, e.g. "#ff0000"
+
+	// Image is a PNG, GIF or JPEG file used instead of Text. Transparency
+	// is kept.
+	Image string
+	// Scale is the width of the image as a fraction of the page width.
+	Scale float64
+
+	Opacity  float64 // 0-1
+	Rotation float64 // degrees, counter-clockwise
+	Position Position
+	Margin   float64 // distance from the page edges in mm
+
+	// Pages selects the pages ("1-3,5", see ParsePageRanges); empty means
+	// all.
+	Pages string
+
+	// Underlay puts the watermark behind the page content. Scanned pages
+	// cover it completely.
+	Underlay bool
+}
+
+func NewWatermarker() *Watermarker {
+	return &Watermarker{
+		FontSize: 48,
+		Color:    "#808080",
+		Scale:    0.3,
+		Opacity:  0.3,
+		Rotation: 45,
+		Position: PositionCenter,
+		Margin:   10,
+	}
+}
+
+// WatermarkResult lists the stamped pages.
+type WatermarkResult struct {
+	Pages []int
+}
+
+// Apply writes inputPath with the watermark on the selected pages to
+// outputPath.
+func (wm *Watermarker) Apply(ctx context.Context, inputPath string, outputPath string) (*WatermarkResult, error) {
+	mark, err := wm.prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes, err := pageSizes(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := wm.selectedPages(len(sizes))
+	if err != nil {
+		return nil, err
+	}
+
+	overlay, from := wm.overlay(mark, sizes, pages)
+
+	if err := applyOverlay(ctx, inputPath, outputPath, overlay, pages, from, wm.Underlay); err != nil {
+		return nil, err
+	}
+	return &WatermarkResult{Pages: pages}, nil
+}
+
+// Preview writes the first selected page, watermarked, as a one page PDF
+// to outputPath and returns its page number.
+func (wm *Watermarker) Preview(ctx context.Context, inputPath string, outputPath string) (int, error) {
+	total, err := PageCount(ctx, inputPath)
+	if err != nil {
+		return 0, err
+	}
+	pages, err := wm.selectedPages(total)
+	if err != nil {
+		return 0, err
+	}
+	page := pages[0]
+
+	workDir, err := os.MkdirTemp(filepath.Dir(outputPath), "watermark_")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(workDir)
+
+	single := filepath.Join(workDir, "page.pdf")
+	if err := runQPDF(ctx, inputPath, "--pages", ".", strconv.Itoa(page), "--", single); err != nil {
+		return 0, err
+	}
+
+	one := *wm
+	one.Pages = ""
+	if _, err := one.Apply(ctx, single, outputPath); err != nil {
+		return 0, err
+	}
+	return page, nil
+}
+
+// selectedPages returns the sorted pages of Pages.
+func (wm *Watermarker) selectedPages(total int) ([]int, error) {
+	return selectPages(wm.Pages, total)
+}
+
+// watermarkMark is the checked text or loaded image.
+type watermarkMark struct {
+	text  []byte // WinAnsi
+	font  standardFont
+	color [3]float64
+	pic   *picture
+}
+
+// prepare checks the settings and loads the image or encodes the text.
+func (wm *Watermarker) prepare() (*watermarkMark, error) {
+	// NaN passes every range check below and ends up in the content stream
+	for _, v := range []struct {
+		name  string
+		value float64
+	}{{"opacity", wm.Opacity}, {"rotation", wm.Rotation}, {"font size", wm.FontSize}, {"scale", wm.Scale}, {"margin", wm.Margin}} {
+		if math.IsNaN(v.value) || math.IsInf(v.value, 0) {
+			return nil, fmt.Errorf("%s must be a finite number, got %g", v.name, v.value)
+		}
+	}
+	if wm.Opacity <= 0 || wm.Opacity > 1 {
+		return nil, fmt.Errorf("opacity must be between 0 and 1, got %g", wm.Opacity)
+	}
+	if wm.Margin < 0 || wm.Margin > 100 {
+		return nil, fmt.Errorf("margin must be between 0 and 100 mm, got %g", wm.Margin)
+	}
+	if _, ok := positionAnchors[wm.position()]; !ok {
+		return nil, fmt.Errorf("unknown position %q", wm.Position)
+	}
+
+	if wm.Image != "" {
+		if wm.Scale <= 0 || wm.Scale > 1 {
+			return nil, fmt.Errorf("scale must be between 0 and 1, got %g", wm.Scale)
+		}
+		pic, err := watermarkPicture(wm.Image)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(wm.Image), err)
+		}
+		return &watermarkMark{pic: &pic}, nil
+	}
+
+	if strings.TrimSpace(wm.Text) == "" {
+		return nil, fmt.Errorf("no watermark text or image given")
+	}
+	if wm.FontSize < 1 || wm.FontSize > 500 {
+		return nil, fmt.Errorf("font size must be between 1 and 500, got %g", wm.FontSize)
+	}
+	text, err := winAnsi(strings.TrimSpace(wm.Text))
+	if err != nil {
+		return nil, err
+	}
+	font, err := lookupFont(wm.Font)
+	if err != nil {
+		return nil, err
+	}
+	color, err := ParseColor(wm.Color)
+	if err != nil {
+		return nil, err
+	}
+	return &watermarkMark{text: text, font: font, color: color}, nil
+}
+
+func (wm *Watermarker) position() Position {
+	if wm.Position == "" {
+		return PositionCenter
+	}
+	return wm.Position
+}
+
+// overlay draws the watermark PDF: one page for every distinct size among
+// pages. from lists, for each of pages, its overlay page for qpdf --from.
+func (wm *Watermarker) overlay(mark *watermarkMark, sizes []pageSize, pages []int) (data []byte, from string) {
+	w := newPDFWriter()
+	// The page tree is written once all pages are known
+	pagesID := w.reserve()
+	catalogID := w.add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	resourcesID := wm.addResources(w, mark)
+
+	var kids []string
+	index := make(map[pageSize]int)
+	overlayPages := make([]string, len(pages))
+	for i, page := range pages {
+		size := sizes[page-1]
+		n, ok := index[size]
+		if !ok {
+			contentID := w.addStream("", []byte(wm.content(mark, size)))
+			pageID := w.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Contents %d 0 R /Resources %d 0 R >>",
+				pagesID, size.Width, size.Height, contentID, resourcesID))
+			kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+			n = len(kids)
+			index[size] = n
+		}
+		overlayPages[i] = strconv.Itoa(n)
+	}
+
+	w.addAt(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	return w.finish(catalogID), strings.Join(overlayPages, ",")
+}
+
+// addResources writes the font or image and the transparency shared by all
+// overlay pages and returns the resource dictionary's number.
+func (wm *Watermarker) addResources(w *pdfWriter, mark *watermarkMark) int {
+	gsID := w.add(fmt.Sprintf("<< /Type /ExtGState /ca %.3f /CA %.3f >>", wm.Opacity, wm.Opacity))
+	if mark.pic == nil {
+		fontID := w.add(mark.font.fontObject())
+		return w.add(fmt.Sprintf("<< /Font << /F0 %d 0 R >> /ExtGState << /GS0 %d 0 R >> >>", fontID, gsID))
+	}
+
+	pic := mark.pic
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d %s", pic.width, pic.height, pic.dict)
+	if pic.mask != nil {
+		maskID := w.addStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode",
+			pic.width, pic.height), pic.mask)
+		dict += fmt.Sprintf(" /SMask %d 0 R", maskID)
+	}
+	imageID := w.addStream(dict, pic.data)
+	return w.add(fmt.Sprintf("<< /XObject << /Im0 %d 0 R >> /ExtGState << /GS0 %d 0 R >> >>", imageID, gsID))
+}
+
+// content draws the watermark on a page of size.
+func (wm *Watermarker) content(mark *watermarkMark, size pageSize) string {
+	// Size of the watermark before rotation
+	var width, height float64
+	if mark.pic != nil {
+		pw, ph := float64(mark.pic.width), float64(mark.pic.height)
+		if mark.pic.orientation >= 5 {
+			pw, ph = ph, pw
+		}
+		width = wm.Scale * size.Width
+		height = width * ph / pw
+	} else {
+		width = mark.font.textWidth(mark.text, wm.FontSize)
+		height = capHeight * wm.FontSize
+	}
+
+	rad := wm.Rotation * math.Pi / 180
+	cos, sin := math.Cos(rad), math.Sin(rad)
+	margin := wm.Margin * 72 / 25.4
+	cx, cy := markCenter(size, width, height, cos, sin, wm.position(), margin, margin)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "q /GS0 gs %.4f %.4f %.4f %.4f %.4f %.4f cm ", cos, sin, -sin, cos, cx, cy)
+	if mark.pic != nil {
+		m := orientationMatrix(mark.pic.orientation, -width/2, -height/2, width, height)
+		fmt.Fprintf(&b, "%.4f %.4f %.4f %.4f %.4f %.4f cm /Im0 Do Q", m[0], m[1], m[2], m[3], m[4], m[5])
+	} else {
+		fmt.Fprintf(&b, "BT /F0 %.2f Tf %.3f %.3f %.3f rg %.4f %.4f Td %s Tj ET Q",
+			wm.FontSize, mark.color[0], mark.color[1], mark.color[2], -width/2, -height/2, pdfString(mark.text))
+	}
+	return b.String()
+}
+
+// watermarkPicture loads a watermark image. Unlike ImagesToPDF it keeps the
+// alpha channel of PNG and GIF files as a soft mask.
+func watermarkPicture(path string) (picture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return picture{}, err
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return jpegPicture(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG")), bytes.HasPrefix(data, []byte("GIF8")):
+	default:
+		return picture{}, fmt.Errorf("watermark images must be PNG, GIF or JPEG")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return picture{}, err
+	}
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return decodedPicture(data)
+	}
+
+	b := img.Bounds()
+	var rgb, alpha bytes.Buffer
+	rgb.Grow(b.Dx() * b.Dy() * 3)
+	alpha.Grow(b.Dx() * b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			// RGBA is premultiplied by alpha
+			if a > 0 {
+				r, g, bl = r*0xffff/a, g*0xffff/a, bl*0xffff/a
+			}
+			rgb.Write([]byte{byte(r >> 8), byte(g >> 8), byte(bl >> 8)})
+			alpha.WriteByte(byte(a >> 8))
+		}
+	}
+
+	return picture{
+		dict:        "/ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode",
+		data:        deflate(rgb.Bytes()),
+		width:       b.Dx(),
+		height:      b.Dy(),
+		orientation: 1,
+		mask:        deflate(alpha.Bytes()),
+	}, nil
+}
@@ -0,0 +1,217 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want [3]float64
+		err  bool
+	}{
+		{"", [3]float64{0, 0, 0}, false},
+		{"#ff0000", [3]float64{1, 0, 0}, false},
+		{"00FF00", [3]float64{0, 1, 0}, false},
+		{"#00f", [3]float64{0, 0, 1}, false},
+		{"red", [3]float64{}, true},
+		{"#12345", [3]float64{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseColor(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWinAnsi(t *testing.T) {
+	got, err := winAnsi("Café (draft)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := pdfString(got); s != `(Caf\351 \(draft\))` {
+		t.Errorf("pdfString = %s", s)
+	}
+
+	if _, err := winAnsi("ПОВЕРИТЕЛНО"); err == nil {
+		t.Error("expected an error for Cyrillic text")
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	helvetica, _ := lookupFont("")
+	// H 722 + i 222 at 10 pt
+	if got := helvetica.textWidth([]byte("Hi"), 10); got != 9.44 {
+		t.Errorf("Helvetica width = %g, want 9.44", got)
+	}
+	courier, _ := lookupFont("Courier")
+	if got := courier.textWidth([]byte("Hi!"), 10); got != 18 {
+		t.Errorf("Courier width = %g, want 18", got)
+	}
+	if _, err := lookupFont("comic-sans"); err == nil {
+		t.Error("expected an error for an unknown font")
+	}
+	for _, name := range FontNames {
+		if f := fonts[name]; f.widths != nil && len(f.widths) != 95 {
+			t.Errorf("%s has %d widths, want 95", name, len(f.widths))
+		}
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	if p, err := ParsePosition("", PositionCenter); err != nil || p != PositionCenter {
+		t.Errorf("empty position = %q, %v", p, err)
+	}
+	if p, err := ParsePosition("Top-Right", PositionCenter); err != nil || p != PositionTopRight {
+		t.Errorf("Top-Right = %q, %v", p, err)
+	}
+	if _, err := ParsePosition("middle", PositionCenter); err == nil {
+		t.Error("expected an error for an unknown position")
+	}
+}
+
+func TestWatermarker_Prepare(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*Watermarker)
+	}{
+		{"no text", func(wm *Watermarker) { wm.Text = " " }},
+		{"opacity", func(wm *Watermarker) { wm.Opacity = 1.5 }},
+		{"font size", func(wm *Watermarker) { wm.FontSize = 0 }},
+		{"color", func(wm *Watermarker) { wm.Color = "blue" }},
+		{"font", func(wm *Watermarker) { wm.Font = "arial" }},
+		{"margin", func(wm *Watermarker) { wm.Margin = -1 }},
+		{"opacity NaN", func(wm *Watermarker) { wm.Opacity = math.NaN() }},
+		{"font size NaN", func(wm *Watermarker) { wm.FontSize = math.NaN() }},
+		{"rotation Inf", func(wm *Watermarker) { wm.Rotation = math.Inf(1) }},
+		{"image type", func(wm *Watermarker) { wm.Image = "../../test/newspaper.pdf" }},
+	}
+	for _, tt := range tests {
+		wm := NewWatermarker()
+		wm.Text = "CONFIDENTIAL"
+		tt.edit(wm)
+		if _, err := wm.prepare(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestWatermarker_Overlay(t *testing.T) {
+	wm := NewWatermarker()
+	wm.Text = "CONFIDENTIAL"
+	mark, err := wm.prepare()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a4 := pageSize{Width: 595, Height: 842}
+	landscape := pageSize{Width: 842, Height: 595}
+	sizes := []pageSize{a4, a4, landscape, a4}
+
+	data, from := wm.overlay(mark, sizes, []int{1, 3, 4})
+	if from != "1,2,1" {
+		t.Errorf("from = %q, want 1,2,1", from)
+	}
+	if n := bytes.Count(data, []byte("/Type /Page ")); n != 2 {
+		t.Errorf("overlay has %d pages, want one per page size", n)
+	}
+	if !bytes.Contains(data, []byte("/BaseFont /Helvetica")) || !bytes.Contains(data, []byte("/ca 0.300")) {
+		t.Error("overlay is missing the font or the opacity")
+	}
+}
+
+func TestWatermarker_Content(t *testing.T) {
+	wm := NewWatermarker()
+	wm.Text = "X"
+	wm.Rotation = 0
+	wm.Margin = 0
+	wm.Position = PositionBottomLeft
+	mark, _ := wm.prepare()
+
+	// X is 667/1000 em wide and sits in the bottom left corner
+	content := wm.content(mark, pageSize{Width: 600, Height: 800})
+	want := "1.0000 0.0000 -0.0000 1.0000 16.0080 16.8000 cm"
+	if !strings.Contains(content, want) {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestWatermarkPicture_KeepsAlpha(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{255, 0, 0, 255})
+	img.Set(1, 0, color.NRGBA{0, 0, 255, 0})
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+
+	path := filepath.Join(t.TempDir(), "logo.png")
+	os.WriteFile(path, buf.Bytes(), 0644)
+
+	pic, err := watermarkPicture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pic.mask == nil {
+		t.Fatal("transparent PNG has no soft mask")
+	}
+	if pic.width != 2 || pic.height != 1 {
+		t.Errorf("size = %dx%d, want 2x1", pic.width, pic.height)
+	}
+}
+
+func TestWatermarker_Apply_Integration(t *testing.T) {
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf not found, skipping watermark test")
+	}
+
+	tempDir, inputPath := setupTestFile(t)
+	ctx := context.Background()
+	pages, err := PageCount(ctx, inputPath)
+	if err != nil {
+		t.Fatalf("pageCount: %v", err)
+	}
+
+	wm := NewWatermarker()
+	wm.Text = "CONFIDENTIAL"
+	wm.Pages = "1"
+	outputPath := filepath.Join(tempDir, "watermarked.pdf")
+
+	t.Logf("💧 Watermarking page 1 of: %s", inputPath)
+	res, err := wm.Apply(ctx, inputPath, outputPath)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(res.Pages) != 1 || res.Pages[0] != 1 {
+		t.Errorf("stamped pages = %v, want [1]", res.Pages)
+	}
+
+	if got, _ := PageCount(ctx, outputPath); got != pages {
+		t.Errorf("watermarked PDF has %d pages, want %d", got, pages)
+	}
+	if out, err := exec.Command("qpdf", "--check", outputPath).CombinedOutput(); err != nil {
+		t.Errorf("qpdf --check failed: %v\n%s", err, out)
+	}
+
+	previewPath := filepath.Join(tempDir, "preview.pdf")
+	page, err := wm.Preview(ctx, inputPath, previewPath)
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if got, _ := PageCount(ctx, previewPath); page != 1 || got != 1 {
+		t.Errorf("preview of page %d has %d pages, want page 1 alone", page, got)
+	}
+}